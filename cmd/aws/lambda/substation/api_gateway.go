@@ -19,6 +19,8 @@ type gatewayMetadata struct {
 }
 
 func gatewayHandler(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	defer flushTraces(ctx)
+
 	// Retrieve and load configuration.
 	conf, err := getConfig(ctx)
 	if err != nil {
@@ -30,7 +32,7 @@ func gatewayHandler(ctx context.Context, request events.APIGatewayProxyRequest)
 		return gateway500Response, err
 	}
 
-	sub, err := substation.New(ctx, cfg)
+	sub, err := newSubstation(ctx, cfg)
 	if err != nil {
 		return gateway500Response, err
 	}
@@ -17,6 +17,8 @@ type firehoseMetadata struct {
 }
 
 func firehoseHandler(ctx context.Context, event events.KinesisFirehoseEvent) (events.KinesisFirehoseResponse, error) {
+	defer flushTraces(ctx)
+
 	var resp events.KinesisFirehoseResponse
 
 	// Retrieve and load configuration.
@@ -30,7 +32,7 @@ func firehoseHandler(ctx context.Context, event events.KinesisFirehoseEvent) (ev
 		return resp, err
 	}
 
-	sub, err := substation.New(ctx, cfg)
+	sub, err := newSubstation(ctx, cfg)
 	if err != nil {
 		return resp, err
 	}
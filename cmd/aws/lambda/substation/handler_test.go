@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// handlerTestConfig delays each message, so the batch cannot be processed
+// before the deadline.
+const handlerTestConfig = `{"concurrency":1,"transforms":[{"type":"utility_delay","settings":{"duration":"10ms"}}]}`
+
+func TestHandlerDeadline(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(handlerTestConfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("SUBSTATION_CONFIG", path)
+
+	var (
+		sqsEvent     events.SQSEvent
+		kinesisEvent events.KinesisEvent
+	)
+
+	for i := 0; i < 1000; i++ {
+		sqsEvent.Records = append(sqsEvent.Records, events.SQSMessage{
+			MessageId: strconv.Itoa(i),
+			Body:      `{"a":"b"}`,
+		})
+
+		kinesisEvent.Records = append(kinesisEvent.Records, events.KinesisEventRecord{
+			Kinesis: events.KinesisRecord{
+				SequenceNumber: strconv.Itoa(i),
+				Data:           []byte(`{"a":"b"}`),
+			},
+		})
+	}
+
+	tests := []struct {
+		name    string
+		handler func(context.Context) error
+	}{
+		{
+			"sqs",
+			func(ctx context.Context) error {
+				return sqsHandler(ctx, sqsEvent)
+			},
+		},
+		{
+			"kinesis",
+			func(ctx context.Context) error {
+				return kinesisStreamHandler(ctx, kinesisEvent)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+
+			// If the handler returns nil, then the unprocessed records
+			// are deleted from the event source.
+			if err := test.handler(ctx); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
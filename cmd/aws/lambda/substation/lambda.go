@@ -9,6 +9,8 @@ import (
 )
 
 func lambdaHandler(ctx context.Context, event json.RawMessage) ([]json.RawMessage, error) {
+	defer flushTraces(ctx)
+
 	evt, err := json.Marshal(event)
 	if err != nil {
 		return nil, err
@@ -25,7 +27,7 @@ func lambdaHandler(ctx context.Context, event json.RawMessage) ([]json.RawMessag
 		return nil, err
 	}
 
-	sub, err := substation.New(ctx, cfg)
+	sub, err := newSubstation(ctx, cfg)
 	if err != nil {
 		return nil, err
 	}
@@ -8,14 +8,20 @@ import (
 	"os"
 
 	"github.com/aws/aws-lambda-go/lambda"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 
 	"github.com/brexhq/substation/v2"
+
 	"github.com/brexhq/substation/v2/internal/file"
+	"github.com/brexhq/substation/v2/internal/tracing"
 )
 
 var (
 	handler string
 
+	// tracerProvider is set if OpenTelemetry tracing is enabled by the environment.
+	tracerProvider *sdktrace.TracerProvider
+
 	// errLambdaMissingHandler is returned when the Lambda is deployed without a configured handler.
 	errLambdaMissingHandler = fmt.Errorf("SUBSTATION_LAMBDA_HANDLER environment variable is missing")
 
@@ -61,7 +67,43 @@ func getConfig(ctx context.Context) (io.Reader, error) {
 	return buf, nil
 }
 
+// newSubstation returns a Substation instance with options that are enabled by
+// the environment.
+func newSubstation(ctx context.Context, cfg substation.Config) (*substation.Substation, error) {
+	var opts []func(*substation.Substation)
+	if tracerProvider != nil {
+		opts = append(opts, substation.WithTracerProvider(tracerProvider))
+	}
+
+	return substation.New(ctx, cfg, opts...)
+}
+
+// flushTraces exports buffered spans. This must be called before each
+// invocation returns because the execution environment may be frozen.
+func flushTraces(ctx context.Context) {
+	if tracerProvider != nil {
+		_ = tracerProvider.ForceFlush(context.WithoutCancel(ctx))
+	}
+}
+
 func main() {
+	if tracing.IsEnabled() {
+		tp, err := tracing.New(context.Background())
+		if err != nil {
+			panic(fmt.Errorf("main: %v", err))
+		}
+
+		tracerProvider = tp
+	}
+
+	// The handler is read in main instead of init so that the handlers can
+	// be tested.
+	var ok bool
+	handler, ok = os.LookupEnv("SUBSTATION_LAMBDA_HANDLER")
+	if !ok {
+		panic(fmt.Errorf("init handler %s: %v", handler, errLambdaMissingHandler))
+	}
+
 	switch h := handler; h {
 	case "AWS_API_GATEWAY":
 		lambda.Start(gatewayHandler)
@@ -86,10 +128,3 @@ func main() {
 	}
 }
 
-func init() {
-	var ok bool
-	handler, ok = os.LookupEnv("SUBSTATION_LAMBDA_HANDLER")
-	if !ok {
-		panic(fmt.Errorf("init handler %s: %v", handler, errLambdaMissingHandler))
-	}
-}
@@ -6,22 +6,13 @@ import (
 	"fmt"
 
 	"github.com/aws/aws-lambda-go/events"
-	"golang.org/x/sync/errgroup"
 
-	"github.com/brexhq/substation/v2"
-	"github.com/brexhq/substation/v2/message"
-
-	"github.com/brexhq/substation/v2/internal/channel"
+	"github.com/brexhq/substation/v2/source"
 )
 
-type sqsMetadata struct {
-	EventSourceArn string            `json:"eventSourceArn"`
-	MessageID      string            `json:"messageId"`
-	BodyMd5        string            `json:"bodyMd5"`
-	Attributes     map[string]string `json:"attributes"`
-}
-
 func sqsHandler(ctx context.Context, event events.SQSEvent) error {
+	defer flushTraces(ctx)
+
 	// Retrieve and load configuration.
 	conf, err := getConfig(ctx)
 	if err != nil {
@@ -33,83 +24,14 @@ func sqsHandler(ctx context.Context, event events.SQSEvent) error {
 		return fmt.Errorf("sqs handler: %v", err)
 	}
 
-	sub, err := substation.New(ctx, cfg.Config)
+	sub, err := newSubstation(ctx, cfg.Config)
 	if err != nil {
 		return fmt.Errorf("sqs handler: %v", err)
 	}
 
-	ch := channel.New[*message.Message]()
-	group, ctx := errgroup.WithContext(ctx)
-
-	// Data transformation. Transforms are executed concurrently using a worker pool
-	// managed by an errgroup. Each message is processed in a separate goroutine.
-	group.Go(func() error {
-		tfGroup, tfCtx := errgroup.WithContext(ctx)
-		tfGroup.SetLimit(cfg.Concurrency)
-
-		for message := range ch.Recv() {
-			select {
-			case <-ctx.Done():
-				return ctx.Err()
-			default:
-			}
-
-			msg := message
-			tfGroup.Go(func() error {
-				// Transformed messages are never returned to the caller because
-				// invocation is asynchronous.
-				if _, err := sub.Transform(tfCtx, msg); err != nil {
-					return err
-				}
-
-				return nil
-			})
-		}
-
-		if err := tfGroup.Wait(); err != nil {
-			return err
-		}
-
-		// CTRL messages flush the pipeline. This must be done
-		// after all messages have been processed.
-		ctrl := message.New().AsControl()
-		if _, err := sub.Transform(ctx, ctrl); err != nil {
-			return err
-		}
-
-		return nil
-	})
-
-	// Data ingest.
-	group.Go(func() error {
-		defer ch.Close()
-
-		// Create Message metadata.
-		m := sqsMetadata{
-			EventSourceArn: event.Records[0].EventSourceARN,
-			MessageID:      event.Records[0].MessageId,
-			BodyMd5:        event.Records[0].Md5OfBody,
-			Attributes:     event.Records[0].Attributes,
-		}
-
-		metadata, err := json.Marshal(m)
-		if err != nil {
-			return fmt.Errorf("sqs handler: %v", err)
-		}
-
-		for _, record := range event.Records {
-			b := []byte(record.Body)
-			msg := message.New().SetData(b).SetMetadata(metadata)
-
-			ch.Send(msg)
-		}
-
-		return nil
-	})
-
-	// Wait for all goroutines to complete. This includes the goroutines that are
-	// executing the transform functions.
-	if err := group.Wait(); err != nil {
+	// Transformed messages are never returned to the caller because
+	// invocation is asynchronous.
+	if err := sub.Run(ctx, source.FromAWSSQSEvent(event), cfg.Concurrency); err != nil {
 		return fmt.Errorf("sqs handler: %v", err)
 	}
 
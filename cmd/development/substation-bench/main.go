@@ -1,6 +1,16 @@
 // Benchmarks the performance of Substation by sending a configurable number of events
 // through the system and reporting the total time taken, the number of events sent, the
 // amount of data sent, and the rate of events and data sent per second.
+//
+// Two modes are supported:
+//
+//   - stream: each message is transformed in a separate goroutine, which is how
+//     the Lambda handlers and 'substation run' process data. Message order is
+//     not kept.
+//
+//   - batch: messages are transformed in batches using substation.WithConcurrency,
+//     which pipelines messages through the transforms and keeps message order.
+//     Use -concurrency 1 to measure sequential performance.
 package main
 
 import (
@@ -9,6 +19,7 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"runtime"
 	"runtime/pprof"
 	"time"
 
@@ -25,6 +36,8 @@ import (
 type options struct {
 	Count       int
 	Concurrency int
+	Mode        string
+	BatchSize   int
 	ConfigFile  string
 	DataFile    string
 	pprofCPU    bool
@@ -37,6 +50,8 @@ func main() {
 	flag.StringVar(&opts.DataFile, "file", "", "File to parse")
 	flag.IntVar(&opts.Count, "count", 100000, "Number of events to process (default: 100000)")
 	flag.IntVar(&opts.Concurrency, "concurrency", -1, "Number of concurrent data transformation functions to run (default: number of CPUs available)")
+	flag.StringVar(&opts.Mode, "mode", "stream", "Benchmark mode, either stream or batch (default: stream)")
+	flag.IntVar(&opts.BatchSize, "batch", 1000, "Number of messages transformed per call in batch mode (default: 1000)")
 	flag.StringVar(&opts.ConfigFile, "config", "", "Substation configuration file (default: empty config)")
 	flag.BoolVar(&opts.pprofCPU, "cpu", false, "Enable CPU profiling (default: false)")
 	flag.BoolVar(&opts.pprofMemory, "mem", false, "Enable memory profiling (default: false)")
@@ -47,6 +62,11 @@ func main() {
 		os.Exit(1)
 	}
 
+	if opts.Mode != "stream" && opts.Mode != "batch" {
+		fmt.Println("invalid flag -mode, must be stream or batch")
+		os.Exit(1)
+	}
+
 	ctx := context.Background()
 
 	fmt.Printf("%s: Configuring Substation\n", time.Now().Format(time.RFC3339Nano))
@@ -73,7 +93,17 @@ func main() {
 		panic(err)
 	}
 
-	sub, err := substation.New(ctx, cfg)
+	var subOpts []func(*substation.Substation)
+	if opts.Mode == "batch" {
+		concurrency := opts.Concurrency
+		if concurrency < 1 {
+			concurrency = runtime.NumCPU()
+		}
+
+		subOpts = append(subOpts, substation.WithConcurrency(concurrency))
+	}
+
+	sub, err := substation.New(ctx, cfg, subOpts...)
 	if err != nil {
 		panic(err)
 	}
@@ -126,14 +156,47 @@ func main() {
 		defer pprof.StopCPUProfile()
 	}
 
-	fmt.Printf("%s: Starting benchmark\n", time.Now().Format(time.RFC3339Nano))
+	fmt.Printf("%s: Starting benchmark (%s mode)\n", time.Now().Format(time.RFC3339Nano), opts.Mode)
 	start := time.Now()
+
+	if opts.Mode == "batch" {
+		if err := runBatch(ctx, sub, data, opts.BatchSize); err != nil {
+			panic(err)
+		}
+	} else if err := runStream(ctx, sub, data, opts.Concurrency); err != nil {
+		panic(err)
+	}
+
+	fmt.Printf("%s: Ending benchmark\n", time.Now().Format(time.RFC3339Nano))
+
+	// The benchmark reports the total time taken, the number of events sent, the
+	// amount of data sent, and the rate of events and data sent per second.
+	elapsed := time.Since(start)
+	fmt.Printf("\nBenchmark results:\n")
+	fmt.Printf("- %d events in %s\n", len(data), elapsed)
+	fmt.Printf("- %.2f events per second\n", float64(len(data))/elapsed.Seconds())
+	fmt.Printf("- %d MB in %s\n", dataBytes/1000/1000, elapsed)
+	fmt.Printf("- %.2f MB per second\n", float64(dataBytes)/1000/1000/elapsed.Seconds())
+
+	if opts.pprofMemory {
+		heap, err := os.Create("./heap.prof")
+		if err != nil {
+			panic(err)
+		}
+		if err := pprof.WriteHeapProfile(heap); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// runStream transforms each message in a separate goroutine.
+func runStream(ctx context.Context, sub *substation.Substation, data [][]byte, concurrency int) error {
 	ch := channel.New[*message.Message]()
 	group, ctx := errgroup.WithContext(ctx)
 
 	group.Go(func() error {
 		tfGroup, tfCtx := errgroup.WithContext(ctx)
-		tfGroup.SetLimit(opts.Concurrency)
+		tfGroup.SetLimit(concurrency)
 
 		for message := range ch.Recv() {
 			select {
@@ -179,28 +242,33 @@ func main() {
 
 	// Wait for all goroutines to complete. This includes the goroutines that are
 	// executing the transform functions.
-	if err := group.Wait(); err != nil {
-		panic(err)
-	}
-
-	fmt.Printf("%s: Ending benchmark\n", time.Now().Format(time.RFC3339Nano))
+	return group.Wait()
+}
 
-	// The benchmark reports the total time taken, the number of events sent, the
-	// amount of data sent, and the rate of events and data sent per second.
-	elapsed := time.Since(start)
-	fmt.Printf("\nBenchmark results:\n")
-	fmt.Printf("- %d events in %s\n", len(data), elapsed)
-	fmt.Printf("- %.2f events per second\n", float64(len(data))/elapsed.Seconds())
-	fmt.Printf("- %d MB in %s\n", dataBytes/1000/1000, elapsed)
-	fmt.Printf("- %.2f MB per second\n", float64(dataBytes)/1000/1000/elapsed.Seconds())
+// runBatch transforms messages in batches. Concurrency is managed by the
+// Substation instance.
+func runBatch(ctx context.Context, sub *substation.Substation, data [][]byte, size int) error {
+	if size < 1 {
+		size = 1
+	}
 
-	if opts.pprofMemory {
-		heap, err := os.Create("./heap.prof")
-		if err != nil {
-			panic(err)
+	for i := 0; i < len(data); i += size {
+		batch := make([]*message.Message, 0, size)
+		for _, b := range data[i:min(i+size, len(data))] {
+			batch = append(batch, message.New().SetData(b))
 		}
-		if err := pprof.WriteHeapProfile(heap); err != nil {
-			panic(err)
+
+		if _, err := sub.Transform(ctx, batch...); err != nil {
+			return err
 		}
 	}
+
+	// ctrl messages flush the pipeline. This must be done
+	// after all messages have been processed.
+	ctrl := message.New().AsControl()
+	if _, err := sub.Transform(ctx, ctrl); err != nil {
+		return err
+	}
+
+	return nil
 }
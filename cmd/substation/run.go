@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/spf13/cobra"
+
+	"github.com/brexhq/substation/v2"
+	"github.com/brexhq/substation/v2/source"
+
+	"github.com/brexhq/substation/v2/internal/tracing"
+)
+
+func init() {
+	rootCmd.AddCommand(runCmd)
+	runCmd.PersistentFlags().StringP("source", "s", "stdin", "source of data (stdin, file, http, kafka, syslog, tcp, udp, aws_kinesis_data_stream, aws_s3, aws_sqs)")
+	runCmd.PersistentFlags().String("file", "", "file path or glob pattern (file source)")
+	runCmd.PersistentFlags().String("addr", "", "address to listen on (http, syslog, tcp, udp sources) or comma-separated brokers (kafka source)")
+	runCmd.PersistentFlags().String("topic", "", "topic to consume (kafka source)")
+	runCmd.PersistentFlags().String("group", "", "consumer group that commits offsets (kafka source)")
+	runCmd.PersistentFlags().String("protocol", "udp", "transport to listen on (syslog source: udp, tcp, tls)")
+	runCmd.PersistentFlags().String("tls-cert", "", "path to the PEM encoded server certificate (syslog source)")
+	runCmd.PersistentFlags().String("tls-key", "", "path to the PEM encoded server private key (syslog source)")
+	runCmd.PersistentFlags().String("tls-client-ca", "", "path to PEM encoded CAs that verify client certificates (syslog source)")
+	runCmd.PersistentFlags().String("arn", "", "ARN of the stream, bucket, or queue to read (AWS sources)")
+	runCmd.PersistentFlags().IntP("concurrency", "c", runtime.NumCPU(), "maximum number of messages transformed concurrently")
+	runCmd.PersistentFlags().StringToString("ext-str", nil, "set external variables")
+}
+
+var runCmd = &cobra.Command{
+	Use:   "run [path to config]",
+	Short: "run configs",
+	Long: `'substation run' runs a config as a long-running process.
+
+Messages are read from a source and transformed by the config
+until the source is exhausted or the process is interrupted
+(SIGINT, SIGTERM). When the process stops, a control message
+is sent through the config to flush any buffered data (for
+example, batches held by send transforms).
+
+If the file is not already compiled, then it is compiled before
+running ('.jsonnet', '.libsonnet' files are compiled to JSON).
+
+Supported sources:
+  stdin   each line from standard input is a message (default)
+  file    each line from files matching --file is a message;
+          compressed files are decompressed and non-text
+          files are sent as a single message
+  http    each request body sent to --addr is a message
+  kafka   each record from --topic on the --addr brokers is a
+          message; the pipeline is flushed every 5 seconds and
+          then offsets are committed by the --group consumer
+          group
+  syslog  each syslog message received on --addr is a
+          message; --protocol is udp (default), tcp, or tls
+          (--tls-cert, --tls-key, and optionally
+          --tls-client-ca for client certificates)
+  tcp     each line (or octet-counted frame) received on
+          --addr is a message, compatible with syslog
+  udp     each datagram received on --addr is a message,
+          compatible with syslog
+  aws_kinesis_data_stream
+          each record from the --arn stream is a message;
+          checkpoints are not stored
+  aws_s3  each line from objects in the --arn bucket is a
+          message, with the same handling as the file source
+  aws_sqs each message from the --arn queue is a message;
+          messages are deleted after they are read
+
+If an OTLP endpoint is set by the standard OpenTelemetry
+environment variables (OTEL_EXPORTER_OTLP_ENDPOINT), then
+each message and transform is traced and spans are exported
+to the endpoint.
+`,
+	Example: `  substation run config.jsonnet < data.jsonl
+  substation run --source file --file 'data/*.jsonl.gz' config.json
+  substation run --source http --addr :8080 config.json
+  substation run --source kafka --addr localhost:9092 --topic logs --group substation config.json
+  substation run --source udp --addr :514 --concurrency 8 config.json
+  substation run --source syslog --addr :6514 --protocol tls --tls-cert cert.pem --tls-key key.pem config.json
+  substation run --source aws_sqs --arn arn:aws:sqs:us-east-1:123456789012:substation config.json
+`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		extStr, err := cmd.PersistentFlags().GetStringToString("ext-str")
+		if err != nil {
+			return err
+		}
+
+		srcType, err := cmd.PersistentFlags().GetString("source")
+		if err != nil {
+			return err
+		}
+
+		var opts runSourceOptions
+		if opts.File, err = cmd.PersistentFlags().GetString("file"); err != nil {
+			return err
+		}
+
+		if opts.Addr, err = cmd.PersistentFlags().GetString("addr"); err != nil {
+			return err
+		}
+
+		if opts.Topic, err = cmd.PersistentFlags().GetString("topic"); err != nil {
+			return err
+		}
+
+		if opts.Group, err = cmd.PersistentFlags().GetString("group"); err != nil {
+			return err
+		}
+
+		if opts.ARN, err = cmd.PersistentFlags().GetString("arn"); err != nil {
+			return err
+		}
+
+		if opts.Protocol, err = cmd.PersistentFlags().GetString("protocol"); err != nil {
+			return err
+		}
+
+		if opts.TLSCert, err = cmd.PersistentFlags().GetString("tls-cert"); err != nil {
+			return err
+		}
+
+		if opts.TLSKey, err = cmd.PersistentFlags().GetString("tls-key"); err != nil {
+			return err
+		}
+
+		if opts.TLSClientCA, err = cmd.PersistentFlags().GetString("tls-client-ca"); err != nil {
+			return err
+		}
+
+		concurrency, err := cmd.PersistentFlags().GetInt("concurrency")
+		if err != nil {
+			return err
+		}
+
+		ctx := context.Background()
+		src, err := newRunSource(ctx, srcType, opts)
+		if err != nil {
+			return err
+		}
+
+		cfg, err := runConfig(args[0], extStr)
+		if err != nil {
+			return err
+		}
+
+		return runPipeline(ctx, cfg, src, concurrency)
+	},
+}
+
+// runConfig returns a Substation config from a file path.
+func runConfig(arg string, extVars map[string]string) (substation.Config, error) {
+	var cfg substation.Config
+
+	switch filepath.Ext(arg) {
+	case ".jsonnet", ".libsonnet":
+		mem, err := compileFile(arg, extVars)
+		if err != nil {
+			return cfg, err
+		}
+
+		if err := json.Unmarshal([]byte(mem), &cfg); err != nil {
+			return cfg, err
+		}
+	default:
+		fi, err := os.Open(arg)
+		if err != nil {
+			return cfg, err
+		}
+		defer fi.Close()
+
+		if err := json.NewDecoder(fi).Decode(&cfg); err != nil {
+			return cfg, err
+		}
+	}
+
+	return cfg, nil
+}
+
+// runPipeline streams messages from the source through the config until the
+// source is exhausted or the process is interrupted.
+func runPipeline(ctx context.Context, cfg substation.Config, src source.Source, concurrency int) error {
+	if concurrency < 1 {
+		return fmt.Errorf("concurrency must be greater than 0")
+	}
+
+	var opts []func(*substation.Substation)
+
+	// Spans are exported if an OTLP endpoint is set by the environment
+	// (e.g., OTEL_EXPORTER_OTLP_ENDPOINT=http://localhost:4318).
+	if tracing.IsEnabled() {
+		tp, err := tracing.New(ctx)
+		if err != nil {
+			return err
+		}
+		defer tp.Shutdown(context.WithoutCancel(ctx)) //nolint:errcheck // spans are best effort.
+
+		opts = append(opts, substation.WithTracerProvider(tp))
+	}
+
+	sub, err := substation.New(ctx, cfg, opts...)
+	if err != nil {
+		return err
+	}
+
+	return sub.Run(ctx, src, concurrency)
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+	"github.com/brexhq/substation/v2/source"
+)
+
+// runSourceOptions contains the options that are used by sources.
+type runSourceOptions struct {
+	// File is a file path or glob pattern.
+	File string
+	// Addr is a network address. For Kafka, this is a comma-separated
+	// list of broker addresses.
+	Addr string
+	// Topic is the Kafka topic that is consumed.
+	Topic string
+	// Group is the Kafka consumer group that commits offsets.
+	Group string
+	// ARN is the AWS resource that is read by AWS sources.
+	ARN string
+	// Protocol is the transport used by the syslog source.
+	Protocol string
+	// TLSCert, TLSKey, and TLSClientCA are the certificate files used by the
+	// syslog source when the protocol is tls.
+	TLSCert     string
+	TLSKey      string
+	TLSClientCA string
+}
+
+// newRunSource returns a configured source. Options that do not apply to
+// the source are ignored.
+func newRunSource(ctx context.Context, typ string, opts runSourceOptions) (source.Source, error) {
+	src, err := source.New(ctx, runSourceConfig(typ, opts))
+	if err != nil {
+		return nil, err
+	}
+
+	return &runSourceInterruptible{src}, nil
+}
+
+// runSourceConfig converts the options to the config of a source.
+func runSourceConfig(typ string, opts runSourceOptions) config.Config {
+	settings := make(map[string]interface{})
+
+	switch typ {
+	case "file":
+		settings["path"] = opts.File
+	case "http", "tcp", "udp":
+		settings["address"] = opts.Addr
+	case "kafka":
+		if opts.Addr != "" {
+			settings["brokers"] = strings.Split(opts.Addr, ",")
+		}
+
+		settings["topic"] = opts.Topic
+		settings["group_id"] = opts.Group
+	case "syslog":
+		settings["address"] = opts.Addr
+		settings["protocol"] = opts.Protocol
+		settings["tls"] = map[string]interface{}{
+			"cert_file":      opts.TLSCert,
+			"key_file":       opts.TLSKey,
+			"client_ca_file": opts.TLSClientCA,
+		}
+	case "aws_kinesis_data_stream", "aws_s3", "aws_sqs":
+		settings["aws"] = map[string]interface{}{
+			"arn": opts.ARN,
+		}
+	}
+
+	return config.Config{
+		Type:     typ,
+		Settings: settings,
+	}
+}
+
+// runSourceInterruptible stops the source when the process is interrupted
+// (SIGINT, SIGTERM). Only the source is stopped, which allows in-flight
+// messages to finish and the pipeline to be flushed.
+type runSourceInterruptible struct {
+	source.Source
+}
+
+func (s *runSourceInterruptible) Read(ctx context.Context, ch chan<- *message.Message) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	return s.Source.Read(ctx, ch)
+}
+
+// Checkpoint checkpoints the source, if it supports checkpoints.
+func (s *runSourceInterruptible) Checkpoint(ctx context.Context) error {
+	if cp, ok := s.Source.(source.Checkpointer); ok {
+		return cp.Checkpoint(ctx)
+	}
+
+	return nil
+}
+
+// Close closes the source, if it supports checkpoints.
+func (s *runSourceInterruptible) Close() error {
+	if cp, ok := s.Source.(source.Checkpointer); ok {
+		return cp.Close()
+	}
+
+	return nil
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/brexhq/substation/v2/config"
+)
+
+var runSourceConfigTests = []struct {
+	name     string
+	typ      string
+	opts     runSourceOptions
+	expected config.Config
+}{
+	{
+		"stdin",
+		"stdin",
+		runSourceOptions{Addr: ":8080", File: "data.jsonl"},
+		config.Config{
+			Type:     "stdin",
+			Settings: map[string]interface{}{},
+		},
+	},
+	{
+		"file",
+		"file",
+		runSourceOptions{File: "data/*.jsonl.gz", Addr: ":8080"},
+		config.Config{
+			Type: "file",
+			Settings: map[string]interface{}{
+				"path": "data/*.jsonl.gz",
+			},
+		},
+	},
+	{
+		"tcp",
+		"tcp",
+		runSourceOptions{Addr: ":514", Topic: "logs"},
+		config.Config{
+			Type: "tcp",
+			Settings: map[string]interface{}{
+				"address": ":514",
+			},
+		},
+	},
+	{
+		"kafka",
+		"kafka",
+		runSourceOptions{Addr: "a:9092,b:9092", Topic: "logs", Group: "substation"},
+		config.Config{
+			Type: "kafka",
+			Settings: map[string]interface{}{
+				"brokers":  []string{"a:9092", "b:9092"},
+				"topic":    "logs",
+				"group_id": "substation",
+			},
+		},
+	},
+	{
+		"kafka no brokers",
+		"kafka",
+		runSourceOptions{Topic: "logs"},
+		config.Config{
+			Type: "kafka",
+			Settings: map[string]interface{}{
+				"topic":    "logs",
+				"group_id": "",
+			},
+		},
+	},
+	{
+		"syslog",
+		"syslog",
+		runSourceOptions{Addr: ":6514", Protocol: "tls", TLSCert: "cert.pem", TLSKey: "key.pem", TLSClientCA: "ca.pem"},
+		config.Config{
+			Type: "syslog",
+			Settings: map[string]interface{}{
+				"address":  ":6514",
+				"protocol": "tls",
+				"tls": map[string]interface{}{
+					"cert_file":      "cert.pem",
+					"key_file":       "key.pem",
+					"client_ca_file": "ca.pem",
+				},
+			},
+		},
+	},
+	{
+		"aws_sqs",
+		"aws_sqs",
+		runSourceOptions{ARN: "arn:aws:sqs:us-east-1:123456789012:substation", Addr: ":8080"},
+		config.Config{
+			Type: "aws_sqs",
+			Settings: map[string]interface{}{
+				"aws": map[string]interface{}{
+					"arn": "arn:aws:sqs:us-east-1:123456789012:substation",
+				},
+			},
+		},
+	},
+}
+
+func TestRunSourceConfig(t *testing.T) {
+	for _, test := range runSourceConfigTests {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := runSourceConfig(test.typ, test.opts)
+			if !reflect.DeepEqual(cfg, test.expected) {
+				t.Errorf("expected %+v, got %+v", test.expected, cfg)
+			}
+		})
+	}
+}
+
+func TestNewRunSource(t *testing.T) {
+	ctx := context.TODO()
+
+	src, err := newRunSource(ctx, "stdin", runSourceOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := src.(*runSourceInterruptible); !ok {
+		t.Errorf("expected *runSourceInterruptible, got %T", src)
+	}
+
+	if _, err := newRunSource(ctx, "example", runSourceOptions{}); err == nil {
+		t.Error("expected error for unknown source")
+	}
+}
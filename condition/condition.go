@@ -21,16 +21,24 @@ func New(ctx context.Context, cfg config.Config) (Conditioner, error) { //nolint
 		return newMetaAll(ctx, cfg)
 	case "any", "meta_any":
 		return newMetaAny(ctx, cfg)
+	case "meta_expression":
+		return newMetaExpression(ctx, cfg)
 	case "none", "meta_none":
 		return newMetaNone(ctx, cfg)
+	case "meta_sigma":
+		return newMetaSigma(ctx, cfg)
 	// Format inspectors.
 	case "format_mime":
 		return newFormatMIME(ctx, cfg)
 	case "format_json":
 		return newFormatJSON(ctx, cfg)
+	case "format_json_schema":
+		return newFormatJSONSchema(ctx, cfg)
 	// Network inspectors.
 	case "network_ip_global_unicast":
 		return newNetworkIPGlobalUnicast(ctx, cfg)
+	case "network_ip_in_cidr":
+		return newNetworkIPInCIDR(ctx, cfg)
 	case "network_ip_link_local_multicast":
 		return newNetworkIPLinkLocalMulticast(ctx, cfg)
 	case "network_ip_link_local_unicast":
@@ -0,0 +1,185 @@
+package condition
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+
+	iconfig "github.com/brexhq/substation/v2/internal/config"
+	"github.com/brexhq/substation/v2/internal/file"
+)
+
+type formatJSONSchemaConfig struct {
+	// Schema is the location of the JSON Schema that is used during
+	// inspection. The schema can be a local file, an HTTP(S) URL, or an AWS
+	// S3 object, and schemas that it references ($ref) are retrieved the same
+	// way.
+	Schema string `json:"schema"`
+	// MetadataKey is the metadata key that validation errors are written to
+	// (e.g., "schema_errors" is written to "meta schema_errors"). Errors are
+	// written as an array of strings and the key is deleted if the message is
+	// valid.
+	//
+	// This is optional and defaults to not writing errors.
+	MetadataKey string `json:"metadata_key"`
+
+	Object iconfig.Object `json:"object"`
+}
+
+func (c *formatJSONSchemaConfig) Decode(in interface{}) error {
+	return iconfig.Decode(in, c)
+}
+
+func (c *formatJSONSchemaConfig) Validate() error {
+	if c.Schema == "" {
+		return fmt.Errorf("schema: %v", iconfig.ErrMissingRequiredOption)
+	}
+
+	return nil
+}
+
+func newFormatJSONSchema(ctx context.Context, cfg config.Config) (*formatJSONSchema, error) {
+	conf := formatJSONSchemaConfig{}
+	if err := conf.Decode(cfg.Settings); err != nil {
+		return nil, err
+	}
+
+	if err := conf.Validate(); err != nil {
+		return nil, err
+	}
+
+	c := jsonschema.NewCompiler()
+	c.UseLoader(formatJSONSchemaLoader{ctx: ctx})
+
+	schema, err := c.Compile(conf.Schema)
+	if err != nil {
+		return nil, fmt.Errorf("schema: %v", err)
+	}
+
+	insp := formatJSONSchema{
+		conf:   conf,
+		schema: schema,
+	}
+
+	return &insp, nil
+}
+
+type formatJSONSchema struct {
+	conf   formatJSONSchemaConfig
+	schema *jsonschema.Schema
+}
+
+func (insp *formatJSONSchema) Condition(ctx context.Context, msg *message.Message) (bool, error) {
+	if msg.IsControl() {
+		return false, nil
+	}
+
+	b := msg.Data()
+	if insp.conf.Object.SourceKey != "" {
+		value := msg.GetValue(insp.conf.Object.SourceKey)
+		if !value.Exists() {
+			return false, insp.setErrors(msg, []string{fmt.Sprintf("%s: value does not exist", insp.conf.Object.SourceKey)})
+		}
+
+		var err error
+		if b, err = json.Marshal(value.Value()); err != nil {
+			return false, err
+		}
+	}
+
+	// Data that is not JSON cannot be validated.
+	inst, err := jsonschema.UnmarshalJSON(bytes.NewReader(b))
+	if err != nil {
+		return false, insp.setErrors(msg, []string{err.Error()})
+	}
+
+	err = insp.schema.Validate(inst)
+
+	var vErr *jsonschema.ValidationError
+	switch {
+	case err == nil:
+		return true, insp.setErrors(msg, nil)
+	case errors.As(err, &vErr):
+		return false, insp.setErrors(msg, formatJSONSchemaErrors(vErr))
+	default:
+		return false, err
+	}
+}
+
+func (insp *formatJSONSchema) String() string {
+	b, _ := json.Marshal(insp.conf)
+	return string(b)
+}
+
+// setErrors writes the validation errors to the message metadata. If there
+// are no errors, then the metadata key is deleted.
+func (insp *formatJSONSchema) setErrors(msg *message.Message, errs []string) error {
+	if insp.conf.MetadataKey == "" {
+		return nil
+	}
+
+	key := "meta " + insp.conf.MetadataKey
+	if len(errs) == 0 {
+		return msg.DeleteValue(key)
+	}
+
+	return msg.SetValue(key, errs)
+}
+
+// formatJSONSchemaErrors returns the validation errors as strings that
+// contain the location of the invalid value (e.g., "/a/b: got string, want
+// number"). Only the causes of errors are returned.
+func formatJSONSchemaErrors(err *jsonschema.ValidationError) []string {
+	if len(err.Causes) == 0 {
+		out := err.BasicOutput()
+
+		loc := out.InstanceLocation
+		if loc == "" {
+			loc = "/"
+		}
+
+		return []string{fmt.Sprintf("%s: %s", loc, out.Error)}
+	}
+
+	var errs []string
+	for _, cause := range err.Causes {
+		errs = append(errs, formatJSONSchemaErrors(cause)...)
+	}
+
+	return errs
+}
+
+// formatJSONSchemaLoader retrieves schemas with the internal/file package.
+type formatJSONSchemaLoader struct {
+	ctx context.Context
+}
+
+func (l formatJSONSchemaLoader) Load(url string) (any, error) {
+	// Local files are converted to file URLs by the compiler.
+	location := url
+	if path, err := (jsonschema.FileLoader{}).ToFile(url); err == nil {
+		location = path
+	}
+
+	path, err := file.Get(l.ctx, location)
+	defer os.Remove(path)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return jsonschema.UnmarshalJSON(f)
+}
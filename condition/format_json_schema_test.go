@@ -0,0 +1,246 @@
+package condition
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+)
+
+var _ Conditioner = &formatJSONSchema{}
+
+// formatJSONSchemaTestSchema requires an object with a string "id" and an
+// optional "user" that is defined in a separate schema.
+var formatJSONSchemaTestSchema = []byte(`{
+	"$schema": "https://json-schema.org/draft/2020-12/schema",
+	"type": "object",
+	"properties": {
+		"id": {"type": "string"},
+		"user": {"$ref": "user.json"}
+	},
+	"required": ["id"]
+}`)
+
+var formatJSONSchemaTestUserSchema = []byte(`{
+	"type": "object",
+	"properties": {
+		"age": {"type": "integer", "minimum": 0}
+	}
+}`)
+
+// writeFormatJSONSchemaTestSchema writes the test schemas to a temporary
+// directory and returns the location of the main schema.
+func writeFormatJSONSchemaTestSchema(t testing.TB) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "schema.json"), formatJSONSchemaTestSchema, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "user.json"), formatJSONSchemaTestUserSchema, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return filepath.Join(dir, "schema.json")
+}
+
+var formatJSONSchemaTests = []struct {
+	name     string
+	cfg      config.Config
+	test     []byte
+	expected bool
+	errors   string
+}{
+	{
+		"pass",
+		config.Config{
+			Settings: map[string]interface{}{
+				"metadata_key": "errors",
+			},
+		},
+		[]byte(`{"id":"a","user":{"age":30}}`),
+		true,
+		``,
+	},
+	{
+		"fail",
+		config.Config{
+			Settings: map[string]interface{}{
+				"metadata_key": "errors",
+			},
+		},
+		[]byte(`{"user":{"age":-1}}`),
+		false,
+		`["/: missing property 'id'","/user/age: minimum: got -1, want 0"]`,
+	},
+	{
+		"fail",
+		config.Config{
+			Settings: map[string]interface{}{
+				"metadata_key": "errors",
+			},
+		},
+		[]byte(`a`),
+		false,
+		`["invalid character 'a' looking for beginning of value"]`,
+	},
+	{
+		"fail",
+		config.Config{},
+		[]byte(`{"id":1}`),
+		false,
+		``,
+	},
+	{
+		"pass",
+		config.Config{
+			Settings: map[string]interface{}{
+				"object": map[string]interface{}{
+					"source_key": "event",
+				},
+			},
+		},
+		[]byte(`{"event":{"id":"a"}}`),
+		true,
+		``,
+	},
+	{
+		"fail",
+		config.Config{
+			Settings: map[string]interface{}{
+				"object": map[string]interface{}{
+					"source_key": "event",
+				},
+				"metadata_key": "errors",
+			},
+		},
+		[]byte(`{"id":"a"}`),
+		false,
+		`["event: value does not exist"]`,
+	},
+}
+
+func TestFormatJSONSchema(t *testing.T) {
+	ctx := context.TODO()
+	schema := writeFormatJSONSchemaTestSchema(t)
+
+	for _, test := range formatJSONSchemaTests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.cfg.Settings == nil {
+				test.cfg.Settings = make(map[string]interface{})
+			}
+			test.cfg.Settings["schema"] = schema
+
+			insp, err := newFormatJSONSchema(ctx, test.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Errors from a previous inspection are removed if the
+			// message is valid.
+			msg := message.New().SetData(test.test).SetMetadata([]byte(`{"errors":["stale"]}`))
+			check, err := insp.Condition(ctx, msg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if test.expected != check {
+				t.Errorf("expected %v, got %v, %v", test.expected, check, string(test.test))
+			}
+
+			if test.cfg.Settings["metadata_key"] == nil {
+				return
+			}
+
+			errs := msg.GetValue("meta errors")
+			if !reflect.DeepEqual(errs.String(), test.errors) {
+				t.Errorf("expected %s, got %s", test.errors, errs)
+			}
+		})
+	}
+}
+
+func TestFormatJSONSchemaInvalid(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "schema.json")
+	if err := os.WriteFile(path, []byte(`{"type": 1}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := newFormatJSONSchema(context.TODO(), config.Config{
+		Settings: map[string]interface{}{
+			"schema": path,
+		},
+	}); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func benchmarkFormatJSONSchema(b *testing.B, insp *formatJSONSchema, message *message.Message) {
+	ctx := context.TODO()
+	for i := 0; i < b.N; i++ {
+		_, _ = insp.Condition(ctx, message)
+	}
+}
+
+func BenchmarkFormatJSONSchema(b *testing.B) {
+	schema := writeFormatJSONSchemaTestSchema(b)
+
+	for _, test := range formatJSONSchemaTests {
+		cfg := config.Config{Settings: map[string]interface{}{"schema": schema}}
+		for k, v := range test.cfg.Settings {
+			cfg.Settings[k] = v
+		}
+
+		insp, err := newFormatJSONSchema(context.TODO(), cfg)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(test.name,
+			func(b *testing.B) {
+				message := message.New().SetData(test.test)
+				benchmarkFormatJSONSchema(b, insp, message)
+			},
+		)
+	}
+}
+
+func FuzzTestFormatJSONSchema(f *testing.F) {
+	testcases := [][]byte{
+		[]byte(`{"id":"a","user":{"age":30}}`),
+		[]byte(`{"user":{"age":-1}}`),
+		[]byte(`["a"]`),
+		[]byte(`a`),
+	}
+
+	for _, tc := range testcases {
+		f.Add(tc)
+	}
+
+	schema := writeFormatJSONSchemaTestSchema(f)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ctx := context.TODO()
+		message := message.New().SetData(data)
+		insp, err := newFormatJSONSchema(ctx, config.Config{
+			Settings: map[string]interface{}{
+				"schema":       schema,
+				"metadata_key": "errors",
+			},
+		})
+		if err != nil {
+			return
+		}
+
+		_, err = insp.Condition(ctx, message)
+		if err != nil {
+			return
+		}
+	})
+}
@@ -0,0 +1,137 @@
+package condition
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/ext"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+
+	iconfig "github.com/brexhq/substation/v2/internal/config"
+)
+
+type metaExpressionConfig struct {
+	// Expression is a Common Expression Language (CEL) expression that returns
+	// a boolean (e.g., `data.action == "login" && meta.source in ["okta",
+	// "duo"]`). CEL is described here: https://cel.dev.
+	//
+	// The message data is available as the variable "data" and the metadata
+	// is available as the variable "meta". JSON values are converted to CEL
+	// maps, lists, strings, numbers, and booleans. If the data is not JSON,
+	// then "data" is a string.
+	//
+	// In addition to the standard definitions, the string (e.g.,
+	// data.user.lowerAscii()) and list (e.g., data.tags.distinct()) extensions
+	// are available.
+	Expression string `json:"expression"`
+}
+
+func (c *metaExpressionConfig) Decode(in interface{}) error {
+	return iconfig.Decode(in, c)
+}
+
+func (c *metaExpressionConfig) Validate() error {
+	if c.Expression == "" {
+		return fmt.Errorf("expression: %v", iconfig.ErrMissingRequiredOption)
+	}
+
+	return nil
+}
+
+func newMetaExpression(_ context.Context, cfg config.Config) (*metaExpression, error) {
+	conf := metaExpressionConfig{}
+	if err := conf.Decode(cfg.Settings); err != nil {
+		return nil, err
+	}
+
+	if err := conf.Validate(); err != nil {
+		return nil, err
+	}
+
+	env, err := cel.NewEnv(
+		cel.Variable("data", cel.DynType),
+		cel.Variable("meta", cel.DynType),
+		ext.Strings(),
+		ext.Lists(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	ast, iss := env.Compile(conf.Expression)
+	if iss.Err() != nil {
+		return nil, fmt.Errorf("expression: %v", iss.Err())
+	}
+
+	// Expressions that use dynamic values are checked when they are evaluated.
+	if t := ast.OutputType(); !t.IsExactType(cel.BoolType) && !t.IsExactType(cel.DynType) {
+		return nil, fmt.Errorf("expression: returns %s, must return bool", t)
+	}
+
+	prg, err := env.Program(ast, cel.EvalOptions(cel.OptOptimize))
+	if err != nil {
+		return nil, fmt.Errorf("expression: %v", err)
+	}
+
+	insp := metaExpression{
+		conf: conf,
+		prg:  prg,
+	}
+
+	return &insp, nil
+}
+
+type metaExpression struct {
+	conf metaExpressionConfig
+
+	prg cel.Program
+}
+
+func (c *metaExpression) Condition(ctx context.Context, msg *message.Message) (bool, error) {
+	if msg.IsControl() {
+		return false, nil
+	}
+
+	out, _, err := c.prg.ContextEval(ctx, map[string]interface{}{
+		"data": metaExpressionValue(msg.Data()),
+		"meta": metaExpressionValue(msg.Metadata()),
+	})
+	// Evaluation errors are caused by the message (e.g., a field does not
+	// exist or has the wrong type), so the message does not match. The has()
+	// macro can be used to check if a field exists.
+	if err != nil {
+		return false, nil
+	}
+
+	b, ok := out.Value().(bool)
+	if !ok {
+		return false, fmt.Errorf("expression: returned %s, must return bool", out.Type().TypeName())
+	}
+
+	return b, nil
+}
+
+func (c *metaExpression) String() string {
+	b, _ := json.Marshal(c.conf)
+	return string(b)
+}
+
+// metaExpressionValue converts JSON text to a value that can be used in an
+// expression. Empty values are converted to an empty map and all other values
+// that are not JSON are converted to a string.
+func metaExpressionValue(b []byte) interface{} {
+	if len(b) == 0 {
+		return map[string]interface{}{}
+	}
+
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return string(b)
+	}
+
+	return v
+}
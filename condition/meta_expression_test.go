@@ -0,0 +1,203 @@
+package condition
+
+import (
+	"context"
+	"testing"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+)
+
+var _ Conditioner = &metaExpression{}
+
+var metaExpressionTests = []struct {
+	name     string
+	cfg      config.Config
+	data     []byte
+	metadata []byte
+	expected bool
+}{
+	{
+		"nested",
+		config.Config{
+			Settings: map[string]interface{}{
+				"expression": `data.user.name == "alice" && data.bytes > 1000`,
+			},
+		},
+		[]byte(`{"user":{"name":"alice"},"bytes":1024}`),
+		nil,
+		true,
+	},
+	{
+		"list",
+		config.Config{
+			Settings: map[string]interface{}{
+				"expression": `data.tags.exists(t, t.startsWith("prod")) && size(data.tags.distinct()) == 2`,
+			},
+		},
+		[]byte(`{"tags":["dev","production","dev"]}`),
+		nil,
+		true,
+	},
+	{
+		"string",
+		config.Config{
+			Settings: map[string]interface{}{
+				"expression": `data.action.lowerAscii() in ["login", "logout"]`,
+			},
+		},
+		[]byte(`{"action":"LOGIN"}`),
+		nil,
+		true,
+	},
+	{
+		"metadata",
+		config.Config{
+			Settings: map[string]interface{}{
+				"expression": `meta.source == "okta" && data.outcome != "success"`,
+			},
+		},
+		[]byte(`{"outcome":"failure"}`),
+		[]byte(`{"source":"okta"}`),
+		true,
+	},
+	{
+		"text",
+		config.Config{
+			Settings: map[string]interface{}{
+				"expression": `data.contains("error")`,
+			},
+		},
+		[]byte(`an error occurred`),
+		nil,
+		true,
+	},
+	{
+		"missing",
+		config.Config{
+			Settings: map[string]interface{}{
+				"expression": `data.a.b == "c"`,
+			},
+		},
+		[]byte(`{"a":{}}`),
+		nil,
+		false,
+	},
+	{
+		"has",
+		config.Config{
+			Settings: map[string]interface{}{
+				"expression": `!has(data.a.b) || data.a.b == "c"`,
+			},
+		},
+		[]byte(`{"a":{}}`),
+		nil,
+		true,
+	},
+}
+
+func TestMetaExpression(t *testing.T) {
+	ctx := context.TODO()
+
+	for _, test := range metaExpressionTests {
+		t.Run(test.name, func(t *testing.T) {
+			message := message.New().SetData(test.data).SetMetadata(test.metadata)
+
+			insp, err := newMetaExpression(ctx, test.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			check, err := insp.Condition(ctx, message)
+			if err != nil {
+				t.Error(err)
+			}
+
+			if test.expected != check {
+				t.Errorf("expected %v, got %v, %v", test.expected, check, string(test.data))
+			}
+		})
+	}
+}
+
+func TestMetaExpressionInvalid(t *testing.T) {
+	ctx := context.TODO()
+
+	for _, expr := range []string{``, `data.a ==`, `1 + 1`, `"a"`} {
+		if _, err := newMetaExpression(ctx, config.Config{
+			Settings: map[string]interface{}{
+				"expression": expr,
+			},
+		}); err == nil {
+			t.Errorf("%q: expected error", expr)
+		}
+	}
+
+	// Dynamic values are checked when the expression is evaluated.
+	insp, err := newMetaExpression(ctx, config.Config{
+		Settings: map[string]interface{}{
+			"expression": `data.a`,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := insp.Condition(ctx, message.New().SetData([]byte(`{"a":"b"}`))); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func benchmarkMetaExpression(b *testing.B, insp *metaExpression, message *message.Message) {
+	ctx := context.TODO()
+	for i := 0; i < b.N; i++ {
+		_, _ = insp.Condition(ctx, message)
+	}
+}
+
+func BenchmarkMetaExpression(b *testing.B) {
+	for _, test := range metaExpressionTests {
+		insp, err := newMetaExpression(context.TODO(), test.cfg)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(test.name,
+			func(b *testing.B) {
+				message := message.New().SetData(test.data).SetMetadata(test.metadata)
+				benchmarkMetaExpression(b, insp, message)
+			},
+		)
+	}
+}
+
+func FuzzTestMetaExpression(f *testing.F) {
+	testcases := [][]byte{
+		[]byte(`{"user":{"name":"alice"},"bytes":1024}`),
+		[]byte(`{"tags":["dev","production"]}`),
+		[]byte(`an error occurred`),
+		[]byte(``),
+	}
+
+	for _, tc := range testcases {
+		f.Add(tc)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ctx := context.TODO()
+		message := message.New().SetData(data)
+		insp, err := newMetaExpression(ctx, config.Config{
+			Settings: map[string]interface{}{
+				"expression": `has(data.user) && data.user.name.startsWith("a")`,
+			},
+		})
+		if err != nil {
+			return
+		}
+
+		_, err = insp.Condition(ctx, message)
+		if err != nil {
+			return
+		}
+	})
+}
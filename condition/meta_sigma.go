@@ -0,0 +1,632 @@
+package condition
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+
+	iconfig "github.com/brexhq/substation/v2/internal/config"
+	"github.com/brexhq/substation/v2/internal/file"
+)
+
+type metaSigmaConfig struct {
+	// Rule is the location of a Sigma rule (https://sigmahq.io) that is used
+	// during inspection. The rule can be a local file, an HTTP(S) URL, or an
+	// AWS S3 object.
+	//
+	// The detection of the rule is compiled into conditions when the
+	// condition is created. Selections, keywords, the "1 of" and "all of"
+	// quantifiers, and the contains, startswith, endswith, re, cidr, all,
+	// and cased modifiers are supported. The logsource of the rule is ignored
+	// and aggregations are not supported.
+	Rule string `json:"rule"`
+	// FieldMappings maps fields used in the rule to keys in the message
+	// (e.g., {"CommandLine": "process.command_line"}). Fields that are not
+	// mapped are used as keys without changes.
+	//
+	// This is optional and defaults to no mappings.
+	FieldMappings map[string]string `json:"field_mappings"`
+}
+
+func (c *metaSigmaConfig) Decode(in interface{}) error {
+	return iconfig.Decode(in, c)
+}
+
+func (c *metaSigmaConfig) Validate() error {
+	if c.Rule == "" {
+		return fmt.Errorf("rule: %v", iconfig.ErrMissingRequiredOption)
+	}
+
+	return nil
+}
+
+func newMetaSigma(ctx context.Context, cfg config.Config) (*metaSigma, error) {
+	conf := metaSigmaConfig{}
+	if err := conf.Decode(cfg.Settings); err != nil {
+		return nil, err
+	}
+
+	if err := conf.Validate(); err != nil {
+		return nil, err
+	}
+
+	path, err := file.Get(ctx, conf.Rule)
+	defer os.Remove(path)
+	if err != nil {
+		return nil, fmt.Errorf("rule: %v", err)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("rule: %v", err)
+	}
+
+	c, err := metaSigmaCompile(b, conf.FieldMappings)
+	if err != nil {
+		return nil, fmt.Errorf("rule %s: %v", conf.Rule, err)
+	}
+
+	cnd, err := New(ctx, c)
+	if err != nil {
+		return nil, fmt.Errorf("rule %s: %v", conf.Rule, err)
+	}
+
+	insp := metaSigma{
+		conf: conf,
+		cnd:  cnd,
+	}
+
+	return &insp, nil
+}
+
+type metaSigma struct {
+	conf metaSigmaConfig
+
+	cnd Conditioner
+}
+
+func (c *metaSigma) Condition(ctx context.Context, msg *message.Message) (bool, error) {
+	if msg.IsControl() {
+		return false, nil
+	}
+
+	return c.cnd.Condition(ctx, msg)
+}
+
+func (c *metaSigma) String() string {
+	b, _ := json.Marshal(c.conf)
+	return string(b)
+}
+
+type metaSigmaRule struct {
+	Detection map[string]interface{} `yaml:"detection"`
+}
+
+// metaSigmaCompile converts the detection of a Sigma rule into a condition
+// that is made of the meta, string, and network conditions.
+func metaSigmaCompile(rule []byte, mappings map[string]string) (config.Config, error) {
+	var r metaSigmaRule
+	if err := yaml.Unmarshal(rule, &r); err != nil {
+		return config.Config{}, err
+	}
+
+	if len(r.Detection) == 0 {
+		return config.Config{}, fmt.Errorf("detection: %v", iconfig.ErrMissingRequiredOption)
+	}
+
+	c := metaSigmaCompiler{
+		mappings:   mappings,
+		selections: make(map[string]config.Config),
+	}
+
+	var conditions []string
+	for name, v := range r.Detection {
+		if name == "condition" {
+			switch v := v.(type) {
+			case string:
+				conditions = []string{v}
+			case []interface{}:
+				for _, s := range v {
+					conditions = append(conditions, fmt.Sprint(s))
+				}
+			}
+
+			continue
+		}
+
+		sel, err := c.selection(v)
+		if err != nil {
+			return config.Config{}, fmt.Errorf("detection %s: %v", name, err)
+		}
+
+		c.selections[name] = sel
+		c.names = append(c.names, name)
+	}
+
+	if len(conditions) == 0 {
+		return config.Config{}, fmt.Errorf("detection.condition: %v", iconfig.ErrMissingRequiredOption)
+	}
+
+	sort.Strings(c.names)
+
+	// Multiple conditions are combined with "or".
+	out := make([]config.Config, 0, len(conditions))
+	for _, s := range conditions {
+		cnd, err := c.condition(s)
+		if err != nil {
+			return config.Config{}, fmt.Errorf("detection.condition %q: %v", s, err)
+		}
+
+		out = append(out, cnd)
+	}
+
+	return metaSigmaAny(out), nil
+}
+
+type metaSigmaCompiler struct {
+	mappings   map[string]string
+	selections map[string]config.Config
+	names      []string
+}
+
+// selection compiles a search identifier. Maps are combined with "and", lists
+// of maps are combined with "or", and all other values are keywords.
+func (c *metaSigmaCompiler) selection(v interface{}) (config.Config, error) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return c.fields(v)
+	case []interface{}:
+		if len(v) == 0 {
+			return config.Config{}, fmt.Errorf("empty selection: %v", iconfig.ErrInvalidOption)
+		}
+
+		if _, ok := v[0].(map[string]interface{}); !ok {
+			return metaSigmaKeywords(v)
+		}
+
+		out := make([]config.Config, 0, len(v))
+		for _, m := range v {
+			m, ok := m.(map[string]interface{})
+			if !ok {
+				return config.Config{}, fmt.Errorf("mixed selection: %v", iconfig.ErrInvalidOption)
+			}
+
+			cnd, err := c.fields(m)
+			if err != nil {
+				return config.Config{}, err
+			}
+
+			out = append(out, cnd)
+		}
+
+		return metaSigmaAny(out), nil
+	default:
+		return metaSigmaKeywords([]interface{}{v})
+	}
+}
+
+// fields compiles a map of fields to values. All fields must match.
+func (c *metaSigmaCompiler) fields(m map[string]interface{}) (config.Config, error) {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	out := make([]config.Config, 0, len(keys))
+	for _, k := range keys {
+		cnd, err := c.field(k, m[k])
+		if err != nil {
+			return config.Config{}, fmt.Errorf("%s: %v", k, err)
+		}
+
+		out = append(out, cnd)
+	}
+
+	return metaSigmaAll(out), nil
+}
+
+// field compiles a field and its modifiers (e.g., "CommandLine|contains|all").
+// If the value is a list, then any value can match unless the "all" modifier
+// is used.
+func (c *metaSigmaCompiler) field(key string, v interface{}) (config.Config, error) {
+	parts := strings.Split(key, "|")
+	field := parts[0]
+	if k, ok := c.mappings[field]; ok {
+		field = k
+	}
+
+	if field == "" {
+		// Fields without a name are keywords.
+		return metaSigmaKeywords(metaSigmaValues(v))
+	}
+
+	var mods metaSigmaModifiers
+	for _, m := range parts[1:] {
+		switch m {
+		case "contains", "startswith", "endswith", "re", "cidr":
+			if mods.op != "" {
+				return config.Config{}, fmt.Errorf("modifier %s: %v", m, iconfig.ErrInvalidOption)
+			}
+
+			mods.op = m
+		case "i", "m", "s":
+			mods.flags += m
+		case "all":
+			mods.all = true
+		case "cased":
+			mods.cased = true
+		default:
+			return config.Config{}, fmt.Errorf("modifier %s: %v", m, iconfig.ErrInvalidOption)
+		}
+	}
+
+	values := metaSigmaValues(v)
+	if len(values) == 0 {
+		return config.Config{}, fmt.Errorf("empty value: %v", iconfig.ErrInvalidOption)
+	}
+
+	leaves := make([]config.Config, 0, len(values))
+	for _, v := range values {
+		// Null values match fields that do not exist or are empty.
+		if v == nil {
+			leaves = append(leaves, config.Config{
+				Type: "string_match",
+				Settings: map[string]interface{}{
+					"object":  map[string]interface{}{"source_key": field},
+					"pattern": "^$",
+				},
+			})
+
+			continue
+		}
+
+		leaf, err := mods.leaf(fmt.Sprint(v))
+		if err != nil {
+			return config.Config{}, err
+		}
+
+		// The value is checked by meta_any so that the leaf is applied to
+		// each element if the field is an array.
+		leaves = append(leaves, config.Config{
+			Type: "meta_any",
+			Settings: map[string]interface{}{
+				"object":     map[string]interface{}{"source_key": field},
+				"conditions": []config.Config{leaf},
+			},
+		})
+	}
+
+	if mods.all {
+		return metaSigmaAll(leaves), nil
+	}
+
+	return metaSigmaAny(leaves), nil
+}
+
+// condition compiles a condition expression. "not" has the highest
+// precedence, followed by "and", then "or".
+func (c *metaSigmaCompiler) condition(s string) (config.Config, error) {
+	if strings.Contains(s, "|") {
+		return config.Config{}, fmt.Errorf("aggregations are not supported")
+	}
+
+	p := metaSigmaParser{
+		c:      c,
+		tokens: metaSigmaTokenize(s),
+	}
+
+	cnd, err := p.or()
+	if err != nil {
+		return config.Config{}, err
+	}
+
+	if p.pos != len(p.tokens) {
+		return config.Config{}, fmt.Errorf("unexpected token %q", p.tokens[p.pos])
+	}
+
+	return cnd, nil
+}
+
+// match returns the selections that match a pattern that may contain
+// wildcards (e.g., "selection_*"). "them" matches all selections that do not
+// begin with an underscore.
+func (c *metaSigmaCompiler) match(pattern string) ([]config.Config, error) {
+	var out []config.Config
+	for _, name := range c.names {
+		if pattern == "them" {
+			if !strings.HasPrefix(name, "_") {
+				out = append(out, c.selections[name])
+			}
+
+			continue
+		}
+
+		if ok, _ := path.Match(pattern, name); ok {
+			out = append(out, c.selections[name])
+		}
+	}
+
+	if len(out) == 0 {
+		return nil, fmt.Errorf("%s: no matching selections", pattern)
+	}
+
+	return out, nil
+}
+
+type metaSigmaParser struct {
+	c      *metaSigmaCompiler
+	tokens []string
+	pos    int
+}
+
+func (p *metaSigmaParser) peek() string {
+	if p.pos >= len(p.tokens) {
+		return ""
+	}
+
+	return strings.ToLower(p.tokens[p.pos])
+}
+
+func (p *metaSigmaParser) next() string {
+	t := p.tokens[p.pos]
+	p.pos++
+
+	return t
+}
+
+func (p *metaSigmaParser) or() (config.Config, error) {
+	return p.binary("or", p.and, metaSigmaAny)
+}
+
+func (p *metaSigmaParser) and() (config.Config, error) {
+	return p.binary("and", p.not, metaSigmaAll)
+}
+
+func (p *metaSigmaParser) binary(op string, operand func() (config.Config, error), combine func([]config.Config) config.Config) (config.Config, error) {
+	cnd, err := operand()
+	if err != nil {
+		return config.Config{}, err
+	}
+
+	out := []config.Config{cnd}
+	for p.peek() == op {
+		p.next()
+
+		cnd, err := operand()
+		if err != nil {
+			return config.Config{}, err
+		}
+
+		out = append(out, cnd)
+	}
+
+	return combine(out), nil
+}
+
+func (p *metaSigmaParser) not() (config.Config, error) {
+	if p.peek() != "not" {
+		return p.primary()
+	}
+
+	p.next()
+
+	cnd, err := p.not()
+	if err != nil {
+		return config.Config{}, err
+	}
+
+	return config.Config{
+		Type: "meta_none",
+		Settings: map[string]interface{}{
+			"conditions": []config.Config{cnd},
+		},
+	}, nil
+}
+
+func (p *metaSigmaParser) primary() (config.Config, error) {
+	switch t := p.peek(); t {
+	case "":
+		return config.Config{}, fmt.Errorf("unexpected end of condition")
+	case "(":
+		p.next()
+
+		cnd, err := p.or()
+		if err != nil {
+			return config.Config{}, err
+		}
+
+		if p.peek() != ")" {
+			return config.Config{}, fmt.Errorf("missing closing parenthesis")
+		}
+
+		p.next()
+		return cnd, nil
+	case "1", "any", "all":
+		p.next()
+		if p.peek() != "of" {
+			return config.Config{}, fmt.Errorf("expected \"of\" after %q", t)
+		}
+
+		p.next()
+		if p.peek() == "" {
+			return config.Config{}, fmt.Errorf("unexpected end of condition")
+		}
+
+		sels, err := p.c.match(p.next())
+		if err != nil {
+			return config.Config{}, err
+		}
+
+		if t == "all" {
+			return metaSigmaAll(sels), nil
+		}
+
+		return metaSigmaAny(sels), nil
+	case ")", "and", "or", "of":
+		return config.Config{}, fmt.Errorf("unexpected token %q", p.next())
+	default:
+		name := p.next()
+
+		sel, ok := p.c.selections[name]
+		if !ok {
+			return config.Config{}, fmt.Errorf("%s: selection does not exist", name)
+		}
+
+		return sel, nil
+	}
+}
+
+func metaSigmaTokenize(s string) []string {
+	s = strings.NewReplacer("(", " ( ", ")", " ) ").Replace(s)
+	return strings.Fields(s)
+}
+
+type metaSigmaModifiers struct {
+	op    string
+	flags string
+	all   bool
+	cased bool
+}
+
+// leaf returns the condition that checks a single value. Values are compared
+// without case sensitivity unless the "cased" modifier is used, and "*" and
+// "?" are wildcards unless the "re" or "cidr" modifiers are used.
+func (m metaSigmaModifiers) leaf(v string) (config.Config, error) {
+	switch m.op {
+	case "re":
+		pattern := v
+		if m.flags != "" {
+			pattern = "(?" + m.flags + ")" + v
+		}
+
+		if _, err := regexp.Compile(pattern); err != nil {
+			return config.Config{}, err
+		}
+
+		return metaSigmaCondition("string_match", "pattern", pattern), nil
+	case "cidr":
+		return metaSigmaCondition("network_ip_in_cidr", "cidrs", []string{v}), nil
+	}
+
+	pattern, wildcard := metaSigmaPattern(v)
+	if m.cased && !wildcard {
+		unescaped := metaSigmaUnescape(v)
+
+		switch m.op {
+		case "contains":
+			return metaSigmaCondition("string_contains", "value", unescaped), nil
+		case "startswith":
+			return metaSigmaCondition("string_starts_with", "value", unescaped), nil
+		case "endswith":
+			return metaSigmaCondition("string_ends_with", "value", unescaped), nil
+		default:
+			return metaSigmaCondition("string_equal_to", "value", unescaped), nil
+		}
+	}
+
+	switch m.op {
+	case "contains":
+	case "startswith":
+		pattern = "^" + pattern
+	case "endswith":
+		pattern += "$"
+	default:
+		pattern = "^" + pattern + "$"
+	}
+
+	if m.cased {
+		return metaSigmaCondition("string_match", "pattern", "(?s)"+pattern), nil
+	}
+
+	return metaSigmaCondition("string_match", "pattern", "(?is)"+pattern), nil
+}
+
+func metaSigmaCondition(typ, key string, value interface{}) config.Config {
+	return config.Config{
+		Type:     typ,
+		Settings: map[string]interface{}{key: value},
+	}
+}
+
+// metaSigmaKeywords returns a condition that matches if any of the values
+// are in the message data.
+func metaSigmaKeywords(values []interface{}) (config.Config, error) {
+	out := make([]config.Config, 0, len(values))
+	for _, v := range values {
+		if _, ok := v.(map[string]interface{}); ok {
+			return config.Config{}, fmt.Errorf("mixed selection: %v", iconfig.ErrInvalidOption)
+		}
+
+		pattern, _ := metaSigmaPattern(fmt.Sprint(v))
+		out = append(out, metaSigmaCondition("string_match", "pattern", "(?is)"+pattern))
+	}
+
+	return metaSigmaAny(out), nil
+}
+
+// metaSigmaPattern converts a value that may contain wildcards to a regular
+// expression. Wildcards are escaped with a backslash (e.g., "\*").
+func metaSigmaPattern(v string) (string, bool) {
+	var sb strings.Builder
+	var wildcard bool
+
+	for i := 0; i < len(v); i++ {
+		switch ch := v[i]; {
+		case ch == '\\' && i+1 < len(v) && strings.IndexByte(`*?\`, v[i+1]) != -1:
+			i++
+			sb.WriteString(regexp.QuoteMeta(v[i : i+1]))
+		case ch == '*':
+			wildcard = true
+			sb.WriteString(".*")
+		case ch == '?':
+			wildcard = true
+			sb.WriteString(".")
+		default:
+			sb.WriteString(regexp.QuoteMeta(v[i : i+1]))
+		}
+	}
+
+	return sb.String(), wildcard
+}
+
+func metaSigmaUnescape(v string) string {
+	return strings.NewReplacer(`\*`, `*`, `\?`, `?`, `\\`, `\`).Replace(v)
+}
+
+func metaSigmaValues(v interface{}) []interface{} {
+	if l, ok := v.([]interface{}); ok {
+		return l
+	}
+
+	return []interface{}{v}
+}
+
+// metaSigmaAll and metaSigmaAny combine conditions. Single conditions are not
+// combined.
+func metaSigmaAll(cnds []config.Config) config.Config {
+	if len(cnds) == 1 {
+		return cnds[0]
+	}
+
+	return metaSigmaCondition("meta_all", "conditions", cnds)
+}
+
+func metaSigmaAny(cnds []config.Config) config.Config {
+	if len(cnds) == 1 {
+		return cnds[0]
+	}
+
+	return metaSigmaCondition("meta_any", "conditions", cnds)
+}
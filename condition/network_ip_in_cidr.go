@@ -0,0 +1,147 @@
+package condition
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"os"
+	"strings"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+
+	iconfig "github.com/brexhq/substation/v2/internal/config"
+	"github.com/brexhq/substation/v2/internal/file"
+)
+
+type networkIPInCIDRConfig struct {
+	// CIDRs are the network ranges used for comparison during inspection.
+	// Ranges can be CIDRs (e.g., "10.0.0.0/8"), IP addresses, or ranges of IP
+	// addresses (e.g., "10.0.0.1-10.0.0.50").
+	//
+	// This is optional if CIDRFiles is set.
+	CIDRs []string `json:"cidrs"`
+	// CIDRFiles are the locations of text files that contain network ranges,
+	// one per line. Empty lines and lines that begin with "#" are ignored. The
+	// files can be stored locally, in an HTTP(S) URL, or in AWS S3.
+	//
+	// This is optional if CIDRs is set.
+	CIDRFiles []string `json:"cidr_files"`
+
+	Object iconfig.Object `json:"object"`
+}
+
+func (c *networkIPInCIDRConfig) Decode(in interface{}) error {
+	return iconfig.Decode(in, c)
+}
+
+func (c *networkIPInCIDRConfig) Validate() error {
+	if len(c.CIDRs) == 0 && len(c.CIDRFiles) == 0 {
+		return fmt.Errorf("cidrs: %v", iconfig.ErrMissingRequiredOption)
+	}
+
+	return nil
+}
+
+func newNetworkIPInCIDR(ctx context.Context, cfg config.Config) (*networkIPInCIDR, error) {
+	conf := networkIPInCIDRConfig{}
+	if err := conf.Decode(cfg.Settings); err != nil {
+		return nil, err
+	}
+
+	if err := conf.Validate(); err != nil {
+		return nil, err
+	}
+
+	insp := networkIPInCIDR{
+		conf: conf,
+	}
+
+	for _, c := range conf.CIDRs {
+		if err := insp.insert(c); err != nil {
+			return nil, fmt.Errorf("cidrs: %v", err)
+		}
+	}
+
+	for _, location := range conf.CIDRFiles {
+		if err := insp.insertFile(ctx, location); err != nil {
+			return nil, fmt.Errorf("cidr_files: %v", err)
+		}
+	}
+
+	return &insp, nil
+}
+
+type networkIPInCIDR struct {
+	conf networkIPInCIDRConfig
+
+	trie networkPrefixTrie
+}
+
+func (insp *networkIPInCIDR) Condition(ctx context.Context, msg *message.Message) (bool, error) {
+	if msg.IsControl() {
+		return false, nil
+	}
+
+	var str string
+	if insp.conf.Object.SourceKey == "" {
+		str = string(msg.Data())
+	} else {
+		str = msg.GetValue(insp.conf.Object.SourceKey).String()
+	}
+
+	addr, err := netip.ParseAddr(str)
+	if err != nil {
+		return false, nil
+	}
+
+	return insp.trie.Contains(addr), nil
+}
+
+func (insp *networkIPInCIDR) String() string {
+	b, _ := json.Marshal(insp.conf)
+	return string(b)
+}
+
+func (insp *networkIPInCIDR) insert(s string) error {
+	prefixes, err := networkParsePrefixes(s)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range prefixes {
+		insp.trie.Insert(p)
+	}
+
+	return nil
+}
+
+func (insp *networkIPInCIDR) insertFile(ctx context.Context, location string) error {
+	path, err := file.Get(ctx, location)
+	defer os.Remove(path)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if err := insp.insert(line); err != nil {
+			return fmt.Errorf("%s: %v", location, err)
+		}
+	}
+
+	return scanner.Err()
+}
@@ -0,0 +1,300 @@
+package condition
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+)
+
+var _ Conditioner = &networkIPInCIDR{}
+
+var networkIPInCIDRTests = []struct {
+	name     string
+	cfg      config.Config
+	test     []byte
+	expected bool
+}{
+	{
+		"pass",
+		config.Config{
+			Settings: map[string]interface{}{
+				"cidrs": []string{"10.0.0.0/8", "172.16.0.0/12"},
+			},
+		},
+		[]byte("172.20.1.1"),
+		true,
+	},
+	{
+		"fail",
+		config.Config{
+			Settings: map[string]interface{}{
+				"cidrs": []string{"10.0.0.0/8", "172.16.0.0/12"},
+			},
+		},
+		[]byte("172.32.0.1"),
+		false,
+	},
+	{
+		"pass",
+		config.Config{
+			Settings: map[string]interface{}{
+				"object": map[string]interface{}{
+					"source_key": "ip_address",
+				},
+				"cidrs": []string{"2001:db8::/32"},
+			},
+		},
+		[]byte(`{"ip_address":"2001:db8::1"}`),
+		true,
+	},
+	{
+		"pass",
+		config.Config{
+			Settings: map[string]interface{}{
+				"cidrs": []string{"192.0.2.10-192.0.2.20"},
+			},
+		},
+		[]byte("192.0.2.17"),
+		true,
+	},
+	{
+		"fail",
+		config.Config{
+			Settings: map[string]interface{}{
+				"cidrs": []string{"192.0.2.10-192.0.2.20"},
+			},
+		},
+		[]byte("192.0.2.21"),
+		false,
+	},
+	{
+		"pass",
+		config.Config{
+			Settings: map[string]interface{}{
+				"cidrs": []string{"198.51.100.7"},
+			},
+		},
+		[]byte("::ffff:198.51.100.7"),
+		true,
+	},
+	{
+		"fail",
+		config.Config{
+			Settings: map[string]interface{}{
+				"cidrs": []string{"0.0.0.0/0"},
+			},
+		},
+		[]byte("not an ip"),
+		false,
+	},
+}
+
+func TestNetworkIPInCIDR(t *testing.T) {
+	ctx := context.TODO()
+
+	for _, test := range networkIPInCIDRTests {
+		t.Run(test.name, func(t *testing.T) {
+			message := message.New().SetData(test.test)
+			insp, err := newNetworkIPInCIDR(ctx, test.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			check, err := insp.Condition(ctx, message)
+			if err != nil {
+				t.Error(err)
+			}
+
+			if test.expected != check {
+				t.Errorf("expected %v, got %v, %v", test.expected, check, string(test.test))
+			}
+		})
+	}
+}
+
+func TestNetworkIPInCIDRFiles(t *testing.T) {
+	ctx := context.TODO()
+
+	path := filepath.Join(t.TempDir(), "cidrs.txt")
+	if err := os.WriteFile(path, []byte("# VPC ranges\n10.1.0.0/16\n\n10.2.0.0/16\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	insp, err := newNetworkIPInCIDR(ctx, config.Config{
+		Settings: map[string]interface{}{
+			"cidr_files": []string{path},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for ip, expected := range map[string]bool{"10.1.2.3": true, "10.2.0.1": true, "10.3.0.1": false} {
+		check, err := insp.Condition(ctx, message.New().SetData([]byte(ip)))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if check != expected {
+			t.Errorf("%s: expected %v, got %v", ip, expected, check)
+		}
+	}
+}
+
+var networkParsePrefixesTests = []struct {
+	name     string
+	test     string
+	expected []string
+}{
+	{
+		"cidr",
+		"10.0.0.1/8",
+		[]string{"10.0.0.1/8"},
+	},
+	{
+		"ip",
+		"2001:db8::1",
+		[]string{"2001:db8::1/128"},
+	},
+	{
+		"range",
+		"10.0.0.1-10.0.0.10",
+		[]string{"10.0.0.1/32", "10.0.0.2/31", "10.0.0.4/30", "10.0.0.8/31", "10.0.0.10/32"},
+	},
+	{
+		"range aligned",
+		"10.0.0.0-10.0.255.255",
+		[]string{"10.0.0.0/16"},
+	},
+	{
+		"range all",
+		"0.0.0.0-255.255.255.255",
+		[]string{"0.0.0.0/0"},
+	},
+}
+
+func TestNetworkParsePrefixes(t *testing.T) {
+	for _, test := range networkParsePrefixesTests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := networkParsePrefixes(test.test)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if fmt.Sprint(result) != fmt.Sprint(test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, result)
+			}
+		})
+	}
+
+	for _, s := range []string{"10.0.0.10-10.0.0.1", "10.0.0.1-::1", "10.0.0.0/33", "a"} {
+		if _, err := networkParsePrefixes(s); err == nil {
+			t.Errorf("%s: expected error", s)
+		}
+	}
+}
+
+func TestNetworkPrefixTrie(t *testing.T) {
+	var trie networkPrefixTrie
+
+	// Longer prefixes that are inserted first are covered by shorter
+	// prefixes that are inserted later.
+	trie.Insert(netip.MustParsePrefix("10.1.1.0/24"))
+	trie.Insert(netip.MustParsePrefix("10.0.0.0/8"))
+	trie.Insert(netip.MustParsePrefix("10.0.0.0/16"))
+	trie.Insert(netip.MustParsePrefix("2001:db8::/32"))
+
+	tests := map[string]bool{
+		"10.200.0.1":  true,
+		"11.0.0.1":    false,
+		"2001:db8::5": true,
+		"2001:db9::5": false,
+		"::a00:1":     false,
+	}
+
+	for ip, expected := range tests {
+		if trie.Contains(netip.MustParseAddr(ip)) != expected {
+			t.Errorf("%s: expected %v", ip, expected)
+		}
+	}
+}
+
+func benchmarkNetworkIPInCIDR(b *testing.B, insp *networkIPInCIDR, message *message.Message) {
+	ctx := context.TODO()
+	for i := 0; i < b.N; i++ {
+		_, _ = insp.Condition(ctx, message)
+	}
+}
+
+func BenchmarkNetworkIPInCIDR(b *testing.B) {
+	for _, test := range networkIPInCIDRTests {
+		insp, err := newNetworkIPInCIDR(context.TODO(), test.cfg)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(test.name,
+			func(b *testing.B) {
+				message := message.New().SetData(test.test)
+				benchmarkNetworkIPInCIDR(b, insp, message)
+			},
+		)
+	}
+}
+
+func BenchmarkNetworkIPInCIDRLarge(b *testing.B) {
+	// 65,536 /24 ranges.
+	cidrs := make([]string, 0, 1<<16)
+	for i := 0; i < 1<<16; i++ {
+		cidrs = append(cidrs, fmt.Sprintf("%d.%d.%d.0/24", 10+i>>14, i>>8&0x3f, i&0xff))
+	}
+
+	insp, err := newNetworkIPInCIDR(context.TODO(), config.Config{
+		Settings: map[string]interface{}{
+			"cidrs": cidrs,
+		},
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	benchmarkNetworkIPInCIDR(b, insp, message.New().SetData([]byte("12.34.56.78")))
+}
+
+func FuzzTestNetworkIPInCIDR(f *testing.F) {
+	testcases := [][]byte{
+		[]byte("10.0.0.1"),
+		[]byte("2001:db8::1"),
+		[]byte("::ffff:10.0.0.1"),
+		[]byte("invalid"),
+		[]byte(""),
+	}
+
+	for _, tc := range testcases {
+		f.Add(tc)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ctx := context.TODO()
+		message := message.New().SetData(data)
+		insp, err := newNetworkIPInCIDR(ctx, config.Config{
+			Settings: map[string]interface{}{
+				"cidrs": []string{"10.0.0.0/8", "2001:db8::/32", "192.0.2.10-192.0.2.20"},
+			},
+		})
+		if err != nil {
+			return
+		}
+
+		_, err = insp.Condition(ctx, message)
+		if err != nil {
+			return
+		}
+	})
+}
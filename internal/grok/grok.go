@@ -0,0 +1,232 @@
+// Package grok provides a compiler for Grok expressions, which are regular
+// expressions that reference a library of reusable, named patterns.
+//
+// Patterns are referenced with the syntax %{NAME}, %{NAME:field}, or
+// %{NAME:field:type}. If a field is provided, then the value matched by the
+// pattern is captured into the field. Supported types are int, float, and
+// string (the default).
+package grok
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxDepth is the maximum number of nested pattern references, which
+// protects against recursive patterns.
+const maxDepth = 64
+
+// groupPrefix is used in the names of capture groups that are created by the
+// compiler. Field names are not valid group names (e.g., they can contain
+// periods), so groups are mapped to fields.
+const groupPrefix = "grok"
+
+var (
+	// errPatternNotFound is returned when an expression references a pattern
+	// that is not defined.
+	errPatternNotFound = fmt.Errorf("pattern not found")
+	// errPatternRecursive is returned when pattern references are nested
+	// deeper than maxDepth.
+	errPatternRecursive = fmt.Errorf("pattern is recursive")
+	// errInvalidType is returned when a field has an unsupported type.
+	errInvalidType = fmt.Errorf("invalid type")
+	// errInvalidDefinition is returned when a pattern file contains a line
+	// that is not a pattern definition.
+	errInvalidDefinition = fmt.Errorf("invalid pattern definition")
+
+	reference = regexp.MustCompile(`%\{(\w+)(?::([\w.@\-\[\]]+))?(?::(\w+))?\}`)
+)
+
+// Grok compiles expressions using a library of patterns.
+type Grok struct {
+	patterns map[string]string
+}
+
+// New returns a Grok compiler that contains the built-in pattern library
+// (Patterns). Additional patterns can be added with AddPattern and
+// AddPatterns, and they replace built-in patterns that have the same name.
+func New() *Grok {
+	g := &Grok{
+		patterns: make(map[string]string, len(Patterns)),
+	}
+
+	for k, v := range Patterns {
+		g.patterns[k] = v
+	}
+
+	return g
+}
+
+// AddPattern adds a named pattern to the library.
+func (g *Grok) AddPattern(name, pattern string) {
+	g.patterns[name] = pattern
+}
+
+// AddPatterns reads pattern definitions and adds them to the library. Each
+// line contains a name and a pattern separated by whitespace (e.g., "PORT
+// %{POSINT}"). Empty lines and lines that begin with "#" are ignored.
+func (g *Grok) AddPatterns(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		name, pattern, ok := strings.Cut(line, " ")
+		if !ok {
+			name, pattern, ok = strings.Cut(line, "\t")
+		}
+
+		if !ok {
+			return fmt.Errorf("%s: %w", line, errInvalidDefinition)
+		}
+
+		g.AddPattern(name, strings.TrimSpace(pattern))
+	}
+
+	return scanner.Err()
+}
+
+// Compile returns a Pattern from a Grok expression.
+func (g *Grok) Compile(expr string) (*Pattern, error) {
+	p := &Pattern{
+		fields: make(map[string]field),
+	}
+
+	expanded, err := g.expand(expr, p, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	re, err := regexp.Compile(expanded)
+	if err != nil {
+		return nil, err
+	}
+
+	p.re = re
+	return p, nil
+}
+
+// expand replaces pattern references in the expression with the regular
+// expressions that they refer to.
+func (g *Grok) expand(expr string, p *Pattern, depth int) (string, error) {
+	if depth > maxDepth {
+		return "", errPatternRecursive
+	}
+
+	var err error
+	out := reference.ReplaceAllStringFunc(expr, func(ref string) string {
+		if err != nil {
+			return ""
+		}
+
+		m := reference.FindStringSubmatch(ref)
+		name, fieldName, typ := m[1], m[2], m[3]
+
+		pattern, ok := g.patterns[name]
+		if !ok {
+			err = fmt.Errorf("%s: %w", name, errPatternNotFound)
+			return ""
+		}
+
+		var inner string
+		inner, err = g.expand(pattern, p, depth+1)
+		if err != nil {
+			return ""
+		}
+
+		if fieldName == "" {
+			return "(?:" + inner + ")"
+		}
+
+		switch typ {
+		case "", "string", "int", "float":
+		default:
+			err = fmt.Errorf("%s: %w", typ, errInvalidType)
+			return ""
+		}
+
+		group := groupPrefix + strconv.Itoa(len(p.fields))
+		p.fields[group] = field{name: fieldName, typ: typ}
+
+		return "(?P<" + group + ">" + inner + ")"
+	})
+
+	return out, err
+}
+
+type field struct {
+	name string
+	typ  string
+}
+
+// Capture is a value that was captured into a field.
+type Capture struct {
+	// Name is the name of the field.
+	Name string
+	// Value is a string, int64, or float64.
+	Value interface{}
+}
+
+// Pattern is a compiled Grok expression.
+type Pattern struct {
+	re     *regexp.Regexp
+	fields map[string]field
+}
+
+// Match returns the values captured by the pattern and whether the pattern
+// matched the string. Fields that do not match any text are not returned, and
+// values that cannot be converted to the type of their field are returned as
+// strings.
+func (p *Pattern) Match(s string) ([]Capture, bool) {
+	matches := p.re.FindStringSubmatchIndex(s)
+	if matches == nil {
+		return nil, false
+	}
+
+	var captures []Capture
+	for i, group := range p.re.SubexpNames() {
+		if i == 0 || group == "" || matches[2*i] < 0 {
+			continue
+		}
+
+		value := s[matches[2*i]:matches[2*i+1]]
+
+		// Named groups that are in the expression (e.g., (?P<name>...)) are
+		// captured as strings.
+		f, ok := p.fields[group]
+		if !ok {
+			captures = append(captures, Capture{Name: group, Value: value})
+			continue
+		}
+
+		captures = append(captures, Capture{Name: f.name, Value: convert(value, f.typ)})
+	}
+
+	return captures, true
+}
+
+// String returns the expanded regular expression.
+func (p *Pattern) String() string {
+	return p.re.String()
+}
+
+func convert(value, typ string) interface{} {
+	switch typ {
+	case "int":
+		if i, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return i
+		}
+	case "float":
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	}
+
+	return value
+}
@@ -0,0 +1,222 @@
+package grok
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var matchTests = []struct {
+	name     string
+	expr     string
+	test     string
+	expected []Capture
+	match    bool
+}{
+	{
+		name: "ip",
+		expr: `%{IP:client} %{WORD:method}`,
+		test: `10.0.0.1 GET`,
+		expected: []Capture{
+			{Name: "client", Value: "10.0.0.1"},
+			{Name: "method", Value: "GET"},
+		},
+		match: true,
+	},
+	{
+		name: "ipv6",
+		expr: `%{IP:client}`,
+		test: `2001:db8::ff00:42:8329`,
+		expected: []Capture{
+			{Name: "client", Value: "2001:db8::ff00:42:8329"},
+		},
+		match: true,
+	},
+	{
+		name: "types",
+		expr: `%{INT:a:int} %{NUMBER:b:float} %{INT:c:string}`,
+		test: `42 3.14 7`,
+		expected: []Capture{
+			{Name: "a", Value: int64(42)},
+			{Name: "b", Value: 3.14},
+			{Name: "c", Value: "7"},
+		},
+		match: true,
+	},
+	{
+		name: "nested field",
+		expr: `%{WORD:user.name}`,
+		test: `alice`,
+		expected: []Capture{
+			{Name: "user.name", Value: "alice"},
+		},
+		match: true,
+	},
+	{
+		name: "regexp group",
+		expr: `(?P<key>\w+)=%{INT:value:int}`,
+		test: `count=5`,
+		expected: []Capture{
+			{Name: "key", Value: "count"},
+			{Name: "value", Value: int64(5)},
+		},
+		match: true,
+	},
+	{
+		name: "optional",
+		expr: `%{WORD:a}(?: %{WORD:b})?`,
+		test: `foo`,
+		expected: []Capture{
+			{Name: "a", Value: "foo"},
+		},
+		match: true,
+	},
+	{
+		name: "syslog",
+		expr: `%{SYSLOGLINE}`,
+		test: `Oct 11 22:14:15 mymachine su[123]: 'su root' failed for lonvick on /dev/pts/8`,
+		expected: []Capture{
+			{Name: "timestamp", Value: "Oct 11 22:14:15"},
+			{Name: "logsource", Value: "mymachine"},
+			{Name: "program", Value: "su"},
+			{Name: "pid", Value: int64(123)},
+			{Name: "message", Value: "'su root' failed for lonvick on /dev/pts/8"},
+		},
+		match: true,
+	},
+	{
+		name: "apache",
+		expr: `%{COMMONAPACHELOG}`,
+		test: `127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif HTTP/1.0" 200 2326`,
+		expected: []Capture{
+			{Name: "clientip", Value: "127.0.0.1"},
+			{Name: "ident", Value: "-"},
+			{Name: "auth", Value: "frank"},
+			{Name: "timestamp", Value: "10/Oct/2000:13:55:36 -0700"},
+			{Name: "verb", Value: "GET"},
+			{Name: "request", Value: "/apache_pb.gif"},
+			{Name: "httpversion", Value: "1.0"},
+			{Name: "response", Value: int64(200)},
+			{Name: "bytes", Value: int64(2326)},
+		},
+		match: true,
+	},
+	{
+		name:  "no match",
+		expr:  `^%{INT:a}$`,
+		test:  `foo`,
+		match: false,
+	},
+}
+
+func TestMatch(t *testing.T) {
+	g := New()
+	for _, test := range matchTests {
+		t.Run(test.name, func(t *testing.T) {
+			p, err := g.Compile(test.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result, ok := p.Match(test.test)
+			if ok != test.match {
+				t.Fatalf("expected match %v, got %v", test.match, ok)
+			}
+
+			if !reflect.DeepEqual(result, test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestPatterns(t *testing.T) {
+	g := New()
+	for name := range Patterns {
+		if _, err := g.Compile("%{" + name + "}"); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+}
+
+func TestAddPatterns(t *testing.T) {
+	g := New()
+
+	r := strings.NewReader("# comment\n\nPORT %{POSINT}\nENDPOINT\t%{IPORHOST:host}:%{PORT:port:int}\n")
+	if err := g.AddPatterns(r); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := g.Compile(`%{ENDPOINT}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, _ := p.Match(`example.com:443`)
+	expected := []Capture{
+		{Name: "host", Value: "example.com"},
+		{Name: "port", Value: int64(443)},
+	}
+
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("expected %v, got %v", expected, result)
+	}
+
+	if err := g.AddPatterns(strings.NewReader("INVALID")); !errors.Is(err, errInvalidDefinition) {
+		t.Errorf("expected %v, got %v", errInvalidDefinition, err)
+	}
+}
+
+var compileErrorTests = []struct {
+	name     string
+	patterns map[string]string
+	expr     string
+	expected error
+}{
+	{
+		name:     "not found",
+		expr:     `%{MISSING}`,
+		expected: errPatternNotFound,
+	},
+	{
+		name:     "invalid type",
+		expr:     `%{INT:a:bool}`,
+		expected: errInvalidType,
+	},
+	{
+		name: "recursive",
+		patterns: map[string]string{
+			"LOOP": `%{LOOP}`,
+		},
+		expr:     `%{LOOP}`,
+		expected: errPatternRecursive,
+	},
+}
+
+func TestCompileError(t *testing.T) {
+	for _, test := range compileErrorTests {
+		t.Run(test.name, func(t *testing.T) {
+			g := New()
+			for k, v := range test.patterns {
+				g.AddPattern(k, v)
+			}
+
+			if _, err := g.Compile(test.expr); !errors.Is(err, test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, err)
+			}
+		})
+	}
+}
+
+func BenchmarkMatch(b *testing.B) {
+	p, err := New().Compile(`%{COMMONAPACHELOG}`)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	s := `127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif HTTP/1.0" 200 2326`
+	for i := 0; i < b.N; i++ {
+		_, _ = p.Match(s)
+	}
+}
@@ -7,6 +7,9 @@ import (
 
 	"github.com/aws/aws-xray-sdk-go/xray"
 	"github.com/hashicorp/go-retryablehttp"
+	"go.opentelemetry.io/otel/propagation"
+
+	"github.com/brexhq/substation/v2/internal/tracing"
 )
 
 // errHTTPInvalidPayload is returned by Post when it receives an unexpected payload interface.
@@ -49,6 +52,9 @@ func (h *HTTP) Get(ctx context.Context, url string, headers ...Header) (*http.Re
 		req.Header.Add(h.Key, h.Value)
 	}
 
+	// If the context contains a span, then the trace context is propagated.
+	tracing.Propagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
+
 	resp, err := h.Client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("http get URL %s: %v", url, err)
@@ -79,6 +85,9 @@ func (h *HTTP) Post(ctx context.Context, url string, payload interface{}, header
 		req.Header.Add(h.Key, h.Value)
 	}
 
+	// If the context contains a span, then the trace context is propagated.
+	tracing.Propagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
+
 	resp, err = h.Client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("http post URL %s: %v", url, err)
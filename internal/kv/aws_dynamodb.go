@@ -334,6 +334,58 @@ func (store *kvAWSDynamoDB) SetAddWithTTL(ctx context.Context, key string, val i
 	return nil
 }
 
+// IncrementWithTTL atomically adds a value to a number in the DynamoDB table and returns
+// the new value. If the item doesn't exist, then it is created. If a TTL attribute is
+// configured, then it is updated with the new value.
+func (store *kvAWSDynamoDB) IncrementWithTTL(ctx context.Context, key string, val int64, ttl int64) (int64, error) {
+	if store.Attributes.Value == "" {
+		return 0, iconfig.ErrMissingRequiredOption
+	}
+
+	updateEx := expression.Add(expression.Name(store.Attributes.Value), expression.Value(val))
+	if store.Attributes.TTL != "" {
+		updateEx = updateEx.Set(expression.Name(store.Attributes.TTL), expression.Value(ttl))
+	}
+
+	m := map[string]interface{}{
+		store.Attributes.PartitionKey: key,
+	}
+
+	if store.Attributes.SortKey != "" {
+		m[store.Attributes.SortKey] = "substation:kv_store"
+	}
+
+	item, err := attributevalue.MarshalMap(m)
+	if err != nil {
+		return 0, err
+	}
+
+	expr, err := expression.NewBuilder().WithUpdate(updateEx).Build()
+	if err != nil {
+		return 0, err
+	}
+
+	ctx = context.WithoutCancel(ctx)
+	resp, err := store.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(store.AWS.ARN),
+		Key:                       item,
+		UpdateExpression:          expr.Update(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		ReturnValues:              types.ReturnValueUpdatedNew,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var n int64
+	if err := attributevalue.Unmarshal(resp.Attributes[store.Attributes.Value], &n); err != nil {
+		return 0, err
+	}
+
+	return n, nil
+}
+
 // IsEnabled returns true if the DynamoDB client is ready for use.
 func (store *kvAWSDynamoDB) IsEnabled() bool {
 	return store.client != nil
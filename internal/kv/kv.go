@@ -13,8 +13,9 @@ import (
 
 var (
 	mu   sync.Mutex
-	m    map[string]Storer
-	lock map[string]Locker
+	m       map[string]Storer
+	lock    map[string]Locker
+	counter map[string]Counter
 	// errSetNotSupported is returned when the KV set action is not supported.
 	errSetNotSupported = fmt.Errorf("set not supported")
 	// ErrNoLock is returned when a lock cannot be acquired.
@@ -124,7 +125,51 @@ func NewLocker(cfg config.Config) (Locker, error) {
 	}
 }
 
+// Counter provides atomic counters that can be shared by many processes.
+type Counter interface {
+	// Get returns the value of a counter.
+	Get(context.Context, string) (interface{}, error)
+	// IncrementWithTTL adds a value to a counter and returns the new value.
+	// If the counter does not exist, then it is created.
+	IncrementWithTTL(context.Context, string, int64, int64) (int64, error)
+	Setup(context.Context) error
+	IsEnabled() bool
+}
+
+// GetCounter returns a pointer to a Counter that is stored as a package level global variable.
+// This function and each Counter are safe for concurrent access.
+func GetCounter(cfg config.Config) (Counter, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	sig := fmt.Sprint(cfg)
+	c, ok := counter[sig]
+	if ok {
+		return c, nil
+	}
+
+	c, err := NewCounter(cfg)
+	if err != nil {
+		return nil, err
+	}
+	counter[sig] = c
+
+	return counter[sig], nil
+}
+
+func NewCounter(cfg config.Config) (Counter, error) {
+	switch t := cfg.Type; t {
+	case "aws_dynamodb":
+		return newKVAWSDynamoDB(cfg)
+	case "memory":
+		return newKVMemory(cfg)
+	default:
+		return nil, fmt.Errorf("kv_store counter: %s: %v", t, iconfig.ErrInvalidFactoryInput)
+	}
+}
+
 func init() {
 	m = make(map[string]Storer)
 	lock = make(map[string]Locker)
+	counter = make(map[string]Counter)
 }
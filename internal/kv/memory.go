@@ -115,15 +115,13 @@ func (store *kvMemory) Lock(ctx context.Context, key string, ttl int64) error {
 	store.lockMu.Lock()
 	defer store.lockMu.Unlock()
 
-	if node, ok := store.items[key]; ok {
-		ttl := node.Value.(kvMemoryElement).ttl
-		if ttl <= time.Now().Unix() {
-			delete(store.items, key)
-			store.lru.Remove(node)
-		}
-
+	// Expired items are replaced, which matches the behavior of the DynamoDB store.
+	store.mu.Lock()
+	if node, ok := store.items[key]; ok && node.Value.(kvMemoryElement).ttl > time.Now().Unix() {
+		store.mu.Unlock()
 		return ErrNoLock
 	}
+	store.mu.Unlock()
 
 	return store.SetWithTTL(ctx, key, nil, ttl)
 }
@@ -170,11 +168,47 @@ func (store *kvMemory) SetAddWithTTL(ctx context.Context, key string, val interf
 	return nil
 }
 
+// IncrementWithTTL adds a value to a counter in the store and returns the new value.
+// If the counter does not exist or has expired, then it is created.
+func (store *kvMemory) IncrementWithTTL(ctx context.Context, key string, val int64, ttl int64) (int64, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if node, ok := store.items[key]; ok {
+		// Resetting the position of the node prevents recently accessed items from being evicted
+		store.lru.MoveToFront(node)
+
+		elem := node.Value.(kvMemoryElement)
+		if n, ok := elem.value.(int64); ok && (elem.ttl == 0 || elem.ttl > time.Now().Unix()) {
+			val += n
+		}
+
+		node.Value = kvMemoryElement{key, val, ttl}
+
+		return val, nil
+	}
+
+	store.lru.PushFront(kvMemoryElement{key, val, ttl})
+	store.items[key] = store.lru.Front()
+
+	if store.lru.Len() > store.Capacity {
+		node := store.lru.Back()
+
+		store.lru.Remove(node)
+		delete(store.items, node.Value.(kvMemoryElement).key)
+	}
+
+	return val, nil
+}
+
 // Unlock removes an item from the store.
 func (store *kvMemory) Unlock(ctx context.Context, key string) error {
 	store.lockMu.Lock()
 	defer store.lockMu.Unlock()
 
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
 	if node, ok := store.items[key]; ok {
 		store.lru.Remove(node)
 		delete(store.items, key)
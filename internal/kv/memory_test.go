@@ -0,0 +1,126 @@
+package kv
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/brexhq/substation/v2/config"
+)
+
+func TestMemoryLock(t *testing.T) {
+	ctx := context.TODO()
+
+	tests := []struct {
+		name     string
+		ttl      int64
+		unlock   bool
+		expected error
+	}{
+		{
+			"locked",
+			time.Now().Add(time.Hour).Unix(),
+			false,
+			ErrNoLock,
+		},
+		{
+			"expired",
+			time.Now().Add(-time.Second).Unix(),
+			false,
+			nil,
+		},
+		{
+			"unlocked",
+			time.Now().Add(time.Hour).Unix(),
+			true,
+			nil,
+		},
+		{
+			"no ttl",
+			0,
+			false,
+			nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store, err := newKVMemory(config.Config{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := store.Setup(ctx); err != nil {
+				t.Fatal(err)
+			}
+			defer store.Close()
+
+			if err := store.Lock(ctx, "a", test.ttl); err != nil {
+				t.Fatal(err)
+			}
+
+			if test.unlock {
+				if err := store.Unlock(ctx, "a"); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err = store.Lock(ctx, "a", time.Now().Add(time.Hour).Unix())
+			if !errors.Is(err, test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, err)
+			}
+
+			// The lock is held after it is reacquired.
+			if err == nil {
+				if err := store.Lock(ctx, "a", time.Now().Add(time.Hour).Unix()); !errors.Is(err, ErrNoLock) {
+					t.Errorf("expected %v, got %v", ErrNoLock, err)
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryIncrementWithTTL(t *testing.T) {
+	ctx := context.TODO()
+
+	store, err := newKVMemory(config.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Setup(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	ttl := time.Now().Add(time.Hour).Unix()
+	for i := int64(1); i <= 3; i++ {
+		n, err := store.IncrementWithTTL(ctx, "a", 1, ttl)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if n != i {
+			t.Errorf("expected %d, got %d", i, n)
+		}
+	}
+
+	if v, _ := store.Get(ctx, "a"); v != int64(3) {
+		t.Errorf("expected 3, got %v", v)
+	}
+
+	// Expired counters are replaced.
+	if _, err := store.IncrementWithTTL(ctx, "b", 5, time.Now().Add(-time.Second).Unix()); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := store.IncrementWithTTL(ctx, "b", 1, ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 1 {
+		t.Errorf("expected 1, got %d", n)
+	}
+}
@@ -40,10 +40,28 @@ type Generator interface {
 	Generate(context.Context, Data) error
 }
 
+// Flusher is implemented by generators that may delay sending metrics.
+type Flusher interface {
+	Flush(context.Context) error
+}
+
+// Flush sends any metrics that were delayed by the generator. This should be
+// called when a control message is received so that the final metrics of a
+// process are not lost.
+func Flush(ctx context.Context, g Generator) error {
+	if f, ok := g.(Flusher); ok {
+		return f.Flush(ctx)
+	}
+
+	return nil
+}
+
 func New(ctx context.Context, cfg config.Config) (Generator, error) {
 	switch cfg.Type {
 	case "aws_cloudwatch_embedded_metrics":
 		return newAWSCloudWatchEmbeddedMetrics(ctx, cfg)
+	case "prometheus":
+		return newPrometheus(ctx, cfg)
 	default:
 		return nil, fmt.Errorf("metrics: new: type %q settings %+v: %v", cfg.Type, cfg.Settings, iconfig.ErrInvalidFactoryInput)
 	}
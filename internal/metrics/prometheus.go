@@ -0,0 +1,420 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/client_golang/prometheus/push"
+
+	"github.com/brexhq/substation/v2/config"
+
+	iconfig "github.com/brexhq/substation/v2/internal/config"
+)
+
+// Metrics from all Prometheus generators are stored in a single registry, so
+// every metric is available from any configured endpoint or push gateway.
+var (
+	prometheusRegistry = prometheus.NewRegistry()
+	prometheusMetrics  = &prometheusStore{families: make(map[string]*prometheusFamily)}
+
+	// prometheusServers contains the addresses that are serving metrics.
+	prometheusServersMu sync.Mutex
+	prometheusServers   = make(map[string]struct{})
+)
+
+func init() {
+	prometheusRegistry.MustRegister(prometheusMetrics)
+}
+
+type prometheusPushGatewayConfig struct {
+	// URL is the address of the Prometheus Pushgateway (e.g., "http://localhost:9091").
+	URL string `json:"url"`
+	// Job is the job label that metrics are grouped by in the Pushgateway.
+	//
+	// This is optional and defaults to "substation".
+	Job string `json:"job"`
+	// Interval is the minimum amount of time between pushes. Metrics that are
+	// generated between pushes are sent by the next push or when the metrics
+	// are flushed by a control message.
+	//
+	// This is optional and defaults to 10s.
+	Interval string `json:"interval"`
+}
+
+type prometheusConfig struct {
+	// Address is the address that serves metrics on the /metrics endpoint
+	// (e.g., ":9090"). Generators that use the same address share the endpoint.
+	Address string `json:"address"`
+	// Gauges are the names of metrics that are stored as gauges. The value of a
+	// gauge is replaced by each data point.
+	//
+	// This is optional and defaults to storing all metrics as counters. The
+	// value of a counter is increased by each data point.
+	Gauges []string `json:"gauges"`
+	// PushGateway sends all metrics to a Prometheus Pushgateway after metrics
+	// are generated, at most once per interval, and when metrics are flushed.
+	// This should be used by short-lived processes that cannot be scraped.
+	PushGateway prometheusPushGatewayConfig `json:"push_gateway"`
+}
+
+func (c *prometheusConfig) Validate() error {
+	if c.Address == "" && c.PushGateway.URL == "" {
+		return fmt.Errorf("address or push_gateway.url: %v", iconfig.ErrMissingRequiredOption)
+	}
+
+	return nil
+}
+
+// prometheusGenerator stores metrics in-process as Prometheus counters and gauges.
+// Metric names are converted to snake case and prefixed with the application
+// name (e.g., "MessageCount" becomes "substation_message_count"), and
+// attributes are converted to labels.
+//
+// Metrics are exposed in the Prometheus text format on an HTTP endpoint
+// (/metrics), pushed to a Prometheus Pushgateway, or both.
+type prometheusGenerator struct {
+	conf   prometheusConfig
+	pusher *prometheusPusher
+}
+
+func newPrometheus(_ context.Context, cfg config.Config) (*prometheusGenerator, error) {
+	conf := prometheusConfig{}
+	if err := iconfig.Decode(cfg.Settings, &conf); err != nil {
+		return nil, fmt.Errorf("metrics prometheus: %v", err)
+	}
+
+	if err := conf.Validate(); err != nil {
+		return nil, fmt.Errorf("metrics prometheus: %v", err)
+	}
+
+	m := prometheusGenerator{
+		conf: conf,
+	}
+
+	if conf.Address != "" {
+		if err := prometheusServe(conf.Address); err != nil {
+			return nil, fmt.Errorf("metrics prometheus: %v", err)
+		}
+	}
+
+	if conf.PushGateway.URL != "" {
+		job := conf.PushGateway.Job
+		if job == "" {
+			job = strings.ToLower(metricsApplication)
+		}
+
+		interval := conf.PushGateway.Interval
+		if interval == "" {
+			interval = "10s"
+		}
+
+		dur, err := time.ParseDuration(interval)
+		if err != nil {
+			return nil, fmt.Errorf("metrics prometheus: push_gateway.interval: %v", err)
+		}
+
+		m.pusher = &prometheusPusher{
+			pusher:   push.New(conf.PushGateway.URL, job).Gatherer(prometheusRegistry),
+			interval: dur,
+		}
+	}
+
+	return &m, nil
+}
+
+func (m *prometheusGenerator) Generate(ctx context.Context, data Data) error {
+	value, err := prometheusValue(data.Value)
+	if err != nil {
+		return fmt.Errorf("metrics prometheus: %v", err)
+	}
+
+	gauge := slices.Contains(m.conf.Gauges, data.Name)
+	if err := prometheusMetrics.add(prometheusName(data.Name), data.Attributes, value, gauge); err != nil {
+		return fmt.Errorf("metrics prometheus: %v", err)
+	}
+
+	if m.pusher != nil {
+		if err := m.pusher.Push(ctx); err != nil {
+			return fmt.Errorf("metrics prometheus: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// Flush sends metrics to the Pushgateway if any were not sent because of the
+// push interval.
+func (m *prometheusGenerator) Flush(ctx context.Context) error {
+	if m.pusher == nil {
+		return nil
+	}
+
+	if err := m.pusher.Flush(ctx); err != nil {
+		return fmt.Errorf("metrics prometheus: %v", err)
+	}
+
+	return nil
+}
+
+// prometheusPusher limits how often metrics are sent to a Pushgateway.
+type prometheusPusher struct {
+	pusher   *push.Pusher
+	interval time.Duration
+
+	mu   sync.Mutex
+	last time.Time
+	// pending is true if metrics were generated since the last push.
+	pending bool
+}
+
+// Push sends metrics if the interval has passed since the last push.
+// Otherwise, the push is skipped and the metrics are sent by the next push.
+func (p *prometheusPusher) Push(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if time.Since(p.last) < p.interval {
+		p.pending = true
+		return nil
+	}
+
+	return p.push(ctx)
+}
+
+// Flush sends metrics if any push was skipped since the last push.
+func (p *prometheusPusher) Flush(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.pending {
+		return nil
+	}
+
+	return p.push(ctx)
+}
+
+func (p *prometheusPusher) push(ctx context.Context) error {
+	p.last = time.Now()
+
+	// If the push fails, then the metrics are still pending.
+	p.pending = true
+	if err := p.pusher.PushContext(ctx); err != nil {
+		return err
+	}
+
+	p.pending = false
+	return nil
+}
+
+// prometheusServe starts an HTTP server that serves metrics on the address.
+// The server is only started once for each address.
+func prometheusServe(addr string) error {
+	prometheusServersMu.Lock()
+	defer prometheusServersMu.Unlock()
+
+	if _, ok := prometheusServers[addr]; ok {
+		return nil
+	}
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(prometheusRegistry, promhttp.HandlerOpts{
+		EnableOpenMetrics: true,
+	}))
+
+	srv := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	// The server runs for the lifetime of the process.
+	go srv.Serve(ln) //nolint:errcheck // errors cannot be returned to the caller.
+
+	prometheusServers[addr] = struct{}{}
+
+	return nil
+}
+
+// prometheusName converts a metric name to a valid Prometheus metric name.
+func prometheusName(name string) string {
+	return strings.ToLower(metricsApplication) + "_" + prometheusSnakeCase(name)
+}
+
+// prometheusSnakeCase converts an UpperCamelCase name to snake case and
+// replaces characters that are not allowed in Prometheus names.
+func prometheusSnakeCase(s string) string {
+	var b strings.Builder
+
+	r := []rune(s)
+	for i, c := range r {
+		if unicode.IsUpper(c) && i > 0 {
+			prev := r[i-1]
+			// Acronyms are kept together (e.g., "HTTPRequests" becomes "http_requests").
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && i+1 < len(r) && unicode.IsLower(r[i+1])) {
+				b.WriteByte('_')
+			}
+		}
+
+		switch {
+		case c < unicode.MaxASCII && (unicode.IsLetter(c) || unicode.IsDigit(c)):
+			b.WriteRune(unicode.ToLower(c))
+		default:
+			b.WriteByte('_')
+		}
+	}
+
+	return b.String()
+}
+
+// prometheusValue converts a metric value to a float.
+func prometheusValue(v interface{}) (float64, error) {
+	switch v := v.(type) {
+	case time.Duration:
+		return float64(v.Nanoseconds()), nil
+	case int:
+		return float64(v), nil
+	case int32:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case uint:
+		return float64(v), nil
+	case uint32:
+		return float64(v), nil
+	case uint64:
+		return float64(v), nil
+	case float32:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	default:
+		return 0, fmt.Errorf("value %v: unsupported type %T", v, v)
+	}
+}
+
+type prometheusSeries struct {
+	labels map[string]string
+	value  float64
+}
+
+type prometheusFamily struct {
+	gauge bool
+	// labels contains every label name used by the family. Series that do not
+	// have a label use an empty value, which Prometheus treats as missing.
+	labels map[string]struct{}
+	series map[string]*prometheusSeries
+}
+
+// prometheusStore is a Prometheus collector for metrics with label names that
+// are not known in advance.
+type prometheusStore struct {
+	mu       sync.Mutex
+	families map[string]*prometheusFamily
+}
+
+func (s *prometheusStore) add(name string, attr map[string]string, value float64, gauge bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, ok := s.families[name]
+	if !ok {
+		f = &prometheusFamily{
+			gauge:  gauge,
+			labels: make(map[string]struct{}),
+			series: make(map[string]*prometheusSeries),
+		}
+
+		s.families[name] = f
+	}
+
+	if f.gauge != gauge {
+		return fmt.Errorf("%s: metric is already stored as a different type", name)
+	}
+
+	// Attributes are copied because callers may modify the map.
+	labels := make(map[string]string, len(attr))
+	keys := make([]string, 0, len(attr))
+	for k, v := range attr {
+		k = prometheusSnakeCase(k)
+
+		labels[k] = v
+		keys = append(keys, k)
+		f.labels[k] = struct{}{}
+	}
+
+	sort.Strings(keys)
+
+	var id strings.Builder
+	for _, k := range keys {
+		id.WriteString(k)
+		id.WriteByte(0)
+		id.WriteString(labels[k])
+		id.WriteByte(0)
+	}
+
+	series, ok := f.series[id.String()]
+	if !ok {
+		series = &prometheusSeries{labels: labels}
+		f.series[id.String()] = series
+	}
+
+	if gauge {
+		series.value = value
+	} else {
+		series.value += value
+	}
+
+	return nil
+}
+
+// Describe sends no descriptors, which makes this an unchecked collector.
+func (s *prometheusStore) Describe(chan<- *prometheus.Desc) {}
+
+func (s *prometheusStore) Collect(ch chan<- prometheus.Metric) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for name, f := range s.families {
+		labels := make([]string, 0, len(f.labels))
+		for l := range f.labels {
+			labels = append(labels, l)
+		}
+
+		sort.Strings(labels)
+
+		vt := prometheus.CounterValue
+		if f.gauge {
+			vt = prometheus.GaugeValue
+		}
+
+		desc := prometheus.NewDesc(name, fmt.Sprintf("%s metric %s.", metricsApplication, name), labels, nil)
+		for _, series := range f.series {
+			values := make([]string, len(labels))
+			for i, l := range labels {
+				values[i] = series.labels[l]
+			}
+
+			m, err := prometheus.NewConstMetric(desc, vt, series.value, values...)
+			if err != nil {
+				ch <- prometheus.NewInvalidMetric(desc, err)
+				continue
+			}
+
+			ch <- m
+		}
+	}
+}
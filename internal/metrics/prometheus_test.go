@@ -0,0 +1,212 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/push"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+var prometheusSnakeCaseTests = []struct {
+	name     string
+	test     string
+	expected string
+}{
+	{"upper camel case", "MessagesReceived", "messages_received"},
+	{"acronym", "HTTPRequests", "http_requests"},
+	{"acronym suffix", "RequestsHTTP", "requests_http"},
+	{"digits", "Status2XXCount", "status2_xx_count"},
+	{"invalid characters", "App-Name.v1", "app_name_v1"},
+	{"snake case", "app_name", "app_name"},
+	{"non-ascii", "Größe", "gr__e"},
+}
+
+func TestPrometheusSnakeCase(t *testing.T) {
+	for _, test := range prometheusSnakeCaseTests {
+		t.Run(test.name, func(t *testing.T) {
+			if result := prometheusSnakeCase(test.test); result != test.expected {
+				t.Errorf("expected %s, got %s", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestPrometheusName(t *testing.T) {
+	if result := prometheusName("MessagesReceived"); result != "substation_messages_received" {
+		t.Errorf("expected substation_messages_received, got %s", result)
+	}
+}
+
+var prometheusValueTests = []struct {
+	name     string
+	test     interface{}
+	expected float64
+	err      bool
+}{
+	{"duration", 2 * time.Second, 2e9, false},
+	{"int", 1, 1, false},
+	{"uint32", uint32(2), 2, false},
+	{"float64", 1.5, 1.5, false},
+	{"string", "1", 0, true},
+}
+
+func TestPrometheusValue(t *testing.T) {
+	for _, test := range prometheusValueTests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := prometheusValue(test.test)
+			if (err != nil) != test.err {
+				t.Fatalf("expected error %v, got %v", test.err, err)
+			}
+
+			if result != test.expected {
+				t.Errorf("expected %v, got %v", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestPrometheusStore(t *testing.T) {
+	s := &prometheusStore{families: make(map[string]*prometheusFamily)}
+
+	// Counters are increased by each data point and gauges are replaced.
+	adds := []struct {
+		name  string
+		attr  map[string]string
+		value float64
+		gauge bool
+	}{
+		{"substation_messages", map[string]string{"AppName": "a"}, 1, false},
+		{"substation_messages", map[string]string{"AppName": "a"}, 2, false},
+		{"substation_messages", map[string]string{"AppName": "b"}, 5, false},
+		// Series that do not have a label use an empty value.
+		{"substation_messages", map[string]string{"AppName": "a", "Region": "us"}, 1, false},
+		{"substation_queue", nil, 10, true},
+		{"substation_queue", nil, 3, true},
+	}
+
+	for _, a := range adds {
+		if err := s.add(a.name, a.attr, a.value, a.gauge); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := s.add("substation_queue", nil, 1, false); err == nil {
+		t.Error("expected error for a metric with a different type")
+	}
+
+	expected := `
+# HELP substation_messages Substation metric substation_messages.
+# TYPE substation_messages counter
+substation_messages{app_name="a",region=""} 3
+substation_messages{app_name="a",region="us"} 1
+substation_messages{app_name="b",region=""} 5
+# HELP substation_queue Substation metric substation_queue.
+# TYPE substation_queue gauge
+substation_queue 3
+`
+	if err := testutil.CollectAndCompare(s, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPrometheusStoreCopy(t *testing.T) {
+	s := &prometheusStore{families: make(map[string]*prometheusFamily)}
+
+	// Callers may modify the attributes after the metric is added.
+	attr := map[string]string{"Type": "success"}
+	if err := s.add("substation_freshness", attr, 1, false); err != nil {
+		t.Fatal(err)
+	}
+
+	attr["Type"] = "failure"
+	if err := s.add("substation_freshness", attr, 2, false); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `
+# HELP substation_freshness Substation metric substation_freshness.
+# TYPE substation_freshness counter
+substation_freshness{type="failure"} 2
+substation_freshness{type="success"} 1
+`
+	if err := testutil.CollectAndCompare(s, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPrometheusPusher(t *testing.T) {
+	var pushes int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		atomic.AddInt32(&pushes, 1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	p := &prometheusPusher{
+		pusher:   push.New(srv.URL, "substation").Gatherer(prometheusRegistry),
+		interval: time.Hour,
+	}
+
+	// The first push is immediate and the rest are skipped until the
+	// metrics are flushed.
+	ctx := context.TODO()
+	for i := 0; i < 100; i++ {
+		if err := p.Push(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if n := atomic.LoadInt32(&pushes); n != 1 {
+		t.Errorf("expected 1 push, got %d", n)
+	}
+
+	if err := p.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := atomic.LoadInt32(&pushes); n != 2 {
+		t.Errorf("expected 2 pushes, got %d", n)
+	}
+
+	// Nothing is pushed if no metrics are pending.
+	if err := p.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := atomic.LoadInt32(&pushes); n != 2 {
+		t.Errorf("expected 2 pushes, got %d", n)
+	}
+}
+
+func TestPrometheusPusherError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	p := &prometheusPusher{
+		pusher:   push.New(srv.URL, "substation").Gatherer(prometheusRegistry),
+		interval: time.Hour,
+	}
+
+	ctx := context.TODO()
+	if err := p.Push(ctx); err == nil {
+		t.Fatal("expected error")
+	}
+
+	// Metrics from a failed push are sent by the flush, which returns its
+	// error.
+	if err := p.Push(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.Flush(ctx); err == nil {
+		t.Error("expected error from the flush")
+	}
+}
@@ -0,0 +1,114 @@
+// Package tracing provides functions for tracing messages with OpenTelemetry.
+package tracing
+
+import (
+	"context"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+
+	"github.com/brexhq/substation/v2/message"
+)
+
+// Name is the instrumentation name used by tracers.
+const Name = "github.com/brexhq/substation/v2"
+
+// Propagator propagates W3C trace context (traceparent, tracestate).
+var Propagator = propagation.TraceContext{}
+
+// IsEnabled identifies if spans should be exported. This is true if an OTLP
+// endpoint is set by the standard OpenTelemetry environment variables
+// ("OTEL_EXPORTER_OTLP_ENDPOINT", "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT").
+func IsEnabled() bool {
+	if _, ok := os.LookupEnv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"); ok {
+		return true
+	}
+
+	_, ok := os.LookupEnv("OTEL_EXPORTER_OTLP_ENDPOINT")
+	return ok
+}
+
+// New returns a tracer provider that exports spans to an OTLP collector
+// over HTTP. The exporter is configured by the standard OpenTelemetry
+// environment variables (e.g., "OTEL_EXPORTER_OTLP_ENDPOINT=http://localhost:4318",
+// "OTEL_SERVICE_NAME").
+//
+// The provider is also set as the global provider and the W3C trace context
+// is set as the global propagator. Callers must call Shutdown on the provider
+// before the process exits to export buffered spans.
+func New(ctx context.Context) (*sdktrace.TracerProvider, error) {
+	exp, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(
+		resource.Default(),
+		resource.NewSchemaless(semconv.ServiceName("substation")),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Resources from the environment take precedence over the default service name.
+	env, err := resource.New(ctx, resource.WithFromEnv())
+	if err != nil {
+		return nil, err
+	}
+
+	if res, err = resource.Merge(res, env); err != nil {
+		return nil, err
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exp),
+		sdktrace.WithResource(res),
+	)
+
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(Propagator)
+
+	return tp, nil
+}
+
+// Extract returns a context that contains the trace context from the
+// message metadata. If the metadata does not contain a trace context, then
+// the context is returned unchanged.
+func Extract(ctx context.Context, msg *message.Message) context.Context {
+	return Propagator.Extract(ctx, carrier{msg})
+}
+
+// Inject adds the trace context from the context to the message metadata
+// ("meta traceparent", "meta tracestate").
+func Inject(ctx context.Context, msg *message.Message) {
+	Propagator.Inject(ctx, carrier{msg})
+}
+
+// HasContext identifies if the message metadata contains a trace context.
+func HasContext(msg *message.Message) bool {
+	return msg.GetValue("meta traceparent").Exists()
+}
+
+// carrier adapts message metadata to a propagation.TextMapCarrier.
+type carrier struct {
+	msg *message.Message
+}
+
+func (c carrier) Get(key string) string {
+	return c.msg.GetValue("meta " + key).String()
+}
+
+// Set ignores errors because metadata that is not a JSON object cannot
+// carry a trace context.
+func (c carrier) Set(key, value string) {
+	_ = c.msg.SetValue("meta "+key, value)
+}
+
+func (c carrier) Keys() []string {
+	return Propagator.Fields()
+}
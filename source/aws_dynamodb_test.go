@@ -0,0 +1,93 @@
+package source
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+var awsDynamoDBEventTests = []struct {
+	name     string
+	record   events.DynamoDBEventRecord
+	expected map[string]string
+}{
+	{
+		"insert",
+		events.DynamoDBEventRecord{
+			EventName:      "INSERT",
+			EventSourceArn: "arn:aws:dynamodb:us-east-1:123456789012:table/substation/stream/2024-01-01T00:00:00.000",
+			Change: events.DynamoDBStreamRecord{
+				StreamViewType: "NEW_AND_OLD_IMAGES",
+				NewImage: map[string]events.DynamoDBAttributeValue{
+					"pk": events.NewStringAttribute("foo"),
+					"n":  events.NewNumberAttribute("1"),
+				},
+			},
+		},
+		map[string]string{
+			"source.table":     "substation",
+			"source.connector": "dynamodb",
+			"op":               "c",
+			"before":           "",
+			"after.pk":         "foo",
+			"after.n":          "1",
+		},
+	},
+	{
+		"remove",
+		events.DynamoDBEventRecord{
+			EventName:      "REMOVE",
+			EventSourceArn: "arn:aws:dynamodb:us-east-1:123456789012:table/substation/stream/2024-01-01T00:00:00.000",
+			Change: events.DynamoDBStreamRecord{
+				StreamViewType: "NEW_AND_OLD_IMAGES",
+				OldImage: map[string]events.DynamoDBAttributeValue{
+					"pk": events.NewStringAttribute("foo"),
+				},
+			},
+		},
+		map[string]string{
+			"op":        "d",
+			"before.pk": "foo",
+			"after":     "",
+		},
+	},
+}
+
+func TestFromAWSDynamoDBEvent(t *testing.T) {
+	for _, test := range awsDynamoDBEventTests {
+		t.Run(test.name, func(t *testing.T) {
+			src := FromAWSDynamoDBEvent(events.DynamoDBEvent{
+				Records: []events.DynamoDBEventRecord{test.record},
+			})
+
+			msgs := readAll(t, src, 5*time.Second)
+			if len(msgs) != 1 {
+				t.Fatalf("expected 1 message, got %d", len(msgs))
+			}
+
+			for k, v := range test.expected {
+				if msgs[0].GetValue(k).String() != v {
+					t.Errorf("%s: expected %s, got %s", k, v, msgs[0].GetValue(k))
+				}
+			}
+		})
+	}
+}
+
+func TestFromAWSDynamoDBEventKeysOnly(t *testing.T) {
+	src := FromAWSDynamoDBEvent(events.DynamoDBEvent{
+		Records: []events.DynamoDBEventRecord{
+			{
+				EventName: "INSERT",
+				Change: events.DynamoDBStreamRecord{
+					StreamViewType: "KEYS_ONLY",
+				},
+			},
+		},
+	})
+
+	if msgs := readAll(t, src, 5*time.Second); len(msgs) != 0 {
+		t.Errorf("expected 0 messages, got %d", len(msgs))
+	}
+}
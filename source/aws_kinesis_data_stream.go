@@ -0,0 +1,281 @@
+package source
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis/types"
+	"github.com/awslabs/kinesis-aggregation/go/v2/deaggregator"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+
+	iconfig "github.com/brexhq/substation/v2/internal/config"
+)
+
+type awsKinesisDataStreamMetadata struct {
+	ApproximateArrivalTimestamp time.Time `json:"approximateArrivalTimestamp"`
+	Stream                      string    `json:"stream"`
+	PartitionKey                string    `json:"partitionKey"`
+	SequenceNumber              string    `json:"sequenceNumber"`
+}
+
+type awsKinesisDataStreamConfig struct {
+	// ShardIteratorType determines where each shard is read from. Must be one
+	// of:
+	//
+	// - LATEST: Records that are put into the stream after the source starts.
+	//
+	// - TRIM_HORIZON: All records that are available in the stream.
+	//
+	// This is optional and defaults to LATEST.
+	ShardIteratorType string `json:"shard_iterator_type"`
+	// Delay is the amount of time to wait before polling a shard that
+	// returned no records.
+	//
+	// This is optional and defaults to 1s.
+	Delay string `json:"delay"`
+
+	AWS iconfig.AWS `json:"aws"`
+}
+
+func (c *awsKinesisDataStreamConfig) Decode(in interface{}) error {
+	return iconfig.Decode(in, c)
+}
+
+func (c *awsKinesisDataStreamConfig) Validate() error {
+	if c.AWS.ARN == "" {
+		return fmt.Errorf("aws.arn: %v", iconfig.ErrMissingRequiredOption)
+	}
+
+	switch types.ShardIteratorType(c.ShardIteratorType) {
+	case types.ShardIteratorTypeLatest, types.ShardIteratorTypeTrimHorizon:
+	default:
+		return fmt.Errorf("shard_iterator_type %s: %v", c.ShardIteratorType, iconfig.ErrInvalidOption)
+	}
+
+	return nil
+}
+
+func newAWSKinesisDataStream(ctx context.Context, cfg config.Config) (*awsKinesisDataStream, error) {
+	conf := awsKinesisDataStreamConfig{}
+	if err := conf.Decode(cfg.Settings); err != nil {
+		return nil, fmt.Errorf("source aws_kinesis_data_stream: %v", err)
+	}
+
+	if conf.ShardIteratorType == "" {
+		conf.ShardIteratorType = string(types.ShardIteratorTypeLatest)
+	}
+
+	if conf.Delay == "" {
+		conf.Delay = "1s"
+	}
+
+	if err := conf.Validate(); err != nil {
+		return nil, fmt.Errorf("source aws_kinesis_data_stream: %v", err)
+	}
+
+	delay, err := time.ParseDuration(conf.Delay)
+	if err != nil {
+		return nil, fmt.Errorf("source aws_kinesis_data_stream: delay: %v", err)
+	}
+
+	src := awsKinesisDataStream{
+		conf:  conf,
+		delay: delay,
+	}
+
+	awsCfg, err := iconfig.NewAWS(ctx, conf.AWS)
+	if err != nil {
+		return nil, fmt.Errorf("source aws_kinesis_data_stream: %v", err)
+	}
+
+	src.client = kinesis.NewFromConfig(awsCfg)
+
+	return &src, nil
+}
+
+// awsKinesisDataStream reads each record from the open shards of a Kinesis
+// Data Stream as a message. Aggregated records (KPL) are deaggregated.
+//
+// Shards are read concurrently and checkpoints are not stored, so this is best
+// used for development and replaying data. Shards created by resharding the
+// stream after the source starts are not read.
+type awsKinesisDataStream struct {
+	conf   awsKinesisDataStreamConfig
+	client *kinesis.Client
+	delay  time.Duration
+}
+
+func (src *awsKinesisDataStream) Read(ctx context.Context, ch chan<- *message.Message) error {
+	var shards []types.Shard
+
+	input := &kinesis.ListShardsInput{
+		StreamARN: &src.conf.AWS.ARN,
+		ShardFilter: &types.ShardFilter{
+			Type: types.ShardFilterTypeAtLatest,
+		},
+	}
+
+	for {
+		resp, err := src.client.ListShards(ctx, input)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			return fmt.Errorf("source aws_kinesis_data_stream: %v", err)
+		}
+
+		shards = append(shards, resp.Shards...)
+		if resp.NextToken == nil {
+			break
+		}
+
+		// NextToken cannot be combined with the stream or filter options.
+		input = &kinesis.ListShardsInput{NextToken: resp.NextToken}
+	}
+
+	group, ctx := errgroup.WithContext(ctx)
+	for _, shard := range shards {
+		id := shard.ShardId
+		group.Go(func() error {
+			return src.readShard(ctx, id, ch)
+		})
+	}
+
+	if err := group.Wait(); err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		return fmt.Errorf("source aws_kinesis_data_stream: %v", err)
+	}
+
+	return nil
+}
+
+func (src *awsKinesisDataStream) readShard(ctx context.Context, shardID *string, ch chan<- *message.Message) error {
+	resp, err := src.client.GetShardIterator(ctx, &kinesis.GetShardIteratorInput{
+		StreamARN:         &src.conf.AWS.ARN,
+		ShardId:           shardID,
+		ShardIteratorType: types.ShardIteratorType(src.conf.ShardIteratorType),
+	})
+	if err != nil {
+		return err
+	}
+
+	iterator := resp.ShardIterator
+
+	// A nil iterator means the shard is closed and all records were read.
+	for iterator != nil {
+		out, err := src.client.GetRecords(ctx, &kinesis.GetRecordsInput{
+			StreamARN:     &src.conf.AWS.ARN,
+			ShardIterator: iterator,
+		})
+		if err != nil {
+			return err
+		}
+
+		iterator = out.NextShardIterator
+
+		if err := sendAWSKinesisRecords(ctx, src.conf.AWS.ARN, out.Records, ch); err != nil {
+			return err
+		}
+
+		if len(out.Records) == 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(src.delay):
+			}
+		}
+	}
+
+	return nil
+}
+
+// FromAWSKinesisEvent returns a Source that reads each record in a Kinesis
+// Data Stream event. Aggregated records (KPL) are deaggregated.
+func FromAWSKinesisEvent(event events.KinesisEvent) Source {
+	return &awsKinesisEvent{event: event}
+}
+
+type awsKinesisEvent struct {
+	event events.KinesisEvent
+}
+
+func (src *awsKinesisEvent) Read(ctx context.Context, ch chan<- *message.Message) error {
+	if len(src.event.Records) == 0 {
+		return nil
+	}
+
+	eventSourceArn := src.event.Records[len(src.event.Records)-1].EventSourceArn
+	converted := convertEventsRecords(src.event.Records)
+
+	if err := sendAWSKinesisRecords(ctx, eventSourceArn, converted, ch); err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		return fmt.Errorf("source aws_kinesis_data_stream: %v", err)
+	}
+
+	return nil
+}
+
+// sendAWSKinesisRecords deaggregates the records and sends them to the channel.
+func sendAWSKinesisRecords(ctx context.Context, stream string, records []types.Record, ch chan<- *message.Message) error {
+	deaggregated, err := deaggregator.DeaggregateRecords(records)
+	if err != nil {
+		return err
+	}
+
+	for _, record := range deaggregated {
+		m := awsKinesisDataStreamMetadata{
+			Stream:         stream,
+			PartitionKey:   aws.ToString(record.PartitionKey),
+			SequenceNumber: aws.ToString(record.SequenceNumber),
+		}
+
+		if record.ApproximateArrivalTimestamp != nil {
+			m.ApproximateArrivalTimestamp = *record.ApproximateArrivalTimestamp
+		}
+
+		metadata, err := json.Marshal(m)
+		if err != nil {
+			return err
+		}
+
+		msg := message.New().SetData(record.Data).SetMetadata(metadata)
+		if err := send(ctx, ch, msg); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func convertEventsRecords(records []events.KinesisEventRecord) []types.Record {
+	output := make([]types.Record, 0)
+
+	for _, r := range records {
+		// ApproximateArrivalTimestamp is events.SecondsEpochTime which serializes time.Time
+		ts := r.Kinesis.ApproximateArrivalTimestamp.UTC()
+		output = append(output, types.Record{
+			ApproximateArrivalTimestamp: &ts,
+			Data:                        r.Kinesis.Data,
+			EncryptionType:              types.EncryptionType(r.Kinesis.EncryptionType),
+			PartitionKey:                &r.Kinesis.PartitionKey,
+			SequenceNumber:              &r.Kinesis.SequenceNumber,
+		})
+	}
+
+	return output
+}
@@ -0,0 +1,63 @@
+package source
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+var _ Source = &awsKinesisDataStream{}
+
+func TestFromAWSKinesisEvent(t *testing.T) {
+	arn := "arn:aws:kinesis:us-east-1:123456789012:stream/substation"
+	src := FromAWSKinesisEvent(events.KinesisEvent{
+		Records: []events.KinesisEventRecord{
+			{
+				EventSourceArn: arn,
+				Kinesis: events.KinesisRecord{
+					ApproximateArrivalTimestamp: events.SecondsEpochTime{Time: time.Unix(1, 0)},
+					Data:                        []byte(`{"a":"b"}`),
+					PartitionKey:                "foo",
+					SequenceNumber:              "1",
+				},
+			},
+			{
+				EventSourceArn: arn,
+				Kinesis: events.KinesisRecord{
+					ApproximateArrivalTimestamp: events.SecondsEpochTime{Time: time.Unix(2, 0)},
+					Data:                        []byte(`{"c":"d"}`),
+					PartitionKey:                "bar",
+					SequenceNumber:              "2",
+				},
+			},
+		},
+	})
+
+	msgs := readAll(t, src, 5*time.Second)
+	expected := []struct {
+		data string
+		key  string
+	}{
+		{`{"a":"b"}`, "foo"},
+		{`{"c":"d"}`, "bar"},
+	}
+
+	if len(msgs) != len(expected) {
+		t.Fatalf("expected %d messages, got %d", len(expected), len(msgs))
+	}
+
+	for i, msg := range msgs {
+		if string(msg.Data()) != expected[i].data {
+			t.Errorf("expected %s, got %s", expected[i].data, msg.Data())
+		}
+
+		if msg.GetValue("meta partitionKey").String() != expected[i].key {
+			t.Errorf("expected %s, got %s", expected[i].key, msg.GetValue("meta partitionKey"))
+		}
+
+		if msg.GetValue("meta stream").String() != arn {
+			t.Errorf("expected %s, got %s", arn, msg.GetValue("meta stream"))
+		}
+	}
+}
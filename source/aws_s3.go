@@ -0,0 +1,250 @@
+package source
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go/aws/arn"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+
+	iconfig "github.com/brexhq/substation/v2/internal/config"
+)
+
+type awsS3Metadata struct {
+	EventTime  time.Time `json:"eventTime"`
+	BucketArn  string    `json:"bucketArn"`
+	BucketName string    `json:"bucketName"`
+	ObjectKey  string    `json:"objectKey"`
+	ObjectSize int64     `json:"objectSize"`
+}
+
+type awsS3Config struct {
+	// Prefix limits the objects that are read to keys that begin with the prefix.
+	//
+	// This is optional and defaults to reading all objects in the bucket.
+	Prefix string `json:"prefix"`
+
+	AWS iconfig.AWS `json:"aws"`
+}
+
+func (c *awsS3Config) Decode(in interface{}) error {
+	return iconfig.Decode(in, c)
+}
+
+func (c *awsS3Config) Validate() error {
+	if c.AWS.ARN == "" {
+		return fmt.Errorf("aws.arn: %v", iconfig.ErrMissingRequiredOption)
+	}
+
+	return nil
+}
+
+func newAWSS3(ctx context.Context, cfg config.Config) (*awsS3, error) {
+	conf := awsS3Config{}
+	if err := conf.Decode(cfg.Settings); err != nil {
+		return nil, fmt.Errorf("source aws_s3: %v", err)
+	}
+
+	if err := conf.Validate(); err != nil {
+		return nil, fmt.Errorf("source aws_s3: %v", err)
+	}
+
+	// S3 bucket ARNs use the format arn:aws:s3:::bucket.
+	a, err := arn.Parse(conf.AWS.ARN)
+	if err != nil {
+		return nil, fmt.Errorf("source aws_s3: %v", err)
+	}
+
+	src := awsS3{
+		conf:   conf,
+		bucket: a.Resource,
+	}
+
+	awsCfg, err := iconfig.NewAWS(ctx, conf.AWS)
+	if err != nil {
+		return nil, fmt.Errorf("source aws_s3: %v", err)
+	}
+
+	src.client = s3.NewFromConfig(awsCfg)
+
+	return &src, nil
+}
+
+// awsS3 reads objects from an S3 bucket. Text objects are decompressed (if
+// necessary) and each line is sent as a message, all other objects are sent
+// as a single message.
+type awsS3 struct {
+	conf   awsS3Config
+	client *s3.Client
+	bucket string
+}
+
+func (src *awsS3) Read(ctx context.Context, ch chan<- *message.Message) error {
+	var objects []awsS3Metadata
+
+	paginator := s3.NewListObjectsV2Paginator(src.client, &s3.ListObjectsV2Input{
+		Bucket: &src.bucket,
+		Prefix: &src.conf.Prefix,
+	})
+
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			return fmt.Errorf("source aws_s3: %v", err)
+		}
+
+		for _, o := range page.Contents {
+			m := awsS3Metadata{
+				BucketArn:  src.conf.AWS.ARN,
+				BucketName: src.bucket,
+				ObjectKey:  *o.Key,
+			}
+
+			if o.LastModified != nil {
+				m.EventTime = *o.LastModified
+			}
+
+			if o.Size != nil {
+				m.ObjectSize = *o.Size
+			}
+
+			objects = append(objects, m)
+		}
+	}
+
+	if err := readAWSS3Objects(ctx, src.client, objects, ch); err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		return fmt.Errorf("source aws_s3: %v", err)
+	}
+
+	return nil
+}
+
+// FromAWSS3Event returns a Source that reads the objects referenced in an S3
+// event notification.
+func FromAWSS3Event(event events.S3Event) (Source, error) {
+	src := awsS3Event{}
+
+	for _, record := range event.Records {
+		// The S3 object key is URL encoded.
+		//
+		// https://docs.aws.amazon.com/AmazonS3/latest/userguide/notification-content-structure.html
+		objectKey, err := url.QueryUnescape(record.S3.Object.Key)
+		if err != nil {
+			return nil, fmt.Errorf("source aws_s3: %v", err)
+		}
+
+		src.objects = append(src.objects, awsS3Metadata{
+			EventTime:  record.EventTime,
+			BucketArn:  record.S3.Bucket.Arn,
+			BucketName: record.S3.Bucket.Name,
+			ObjectKey:  objectKey,
+			ObjectSize: record.S3.Object.Size,
+		})
+	}
+
+	return &src, nil
+}
+
+// FromAWSSNSS3Event returns a Source that reads the objects referenced in S3
+// event notifications that are delivered by SNS.
+func FromAWSSNSS3Event(event events.SNSEvent) (Source, error) {
+	src := awsS3Event{}
+
+	for _, record := range event.Records {
+		var s3Event events.S3Event
+		if err := json.Unmarshal([]byte(record.SNS.Message), &s3Event); err != nil {
+			return nil, fmt.Errorf("source aws_s3: %v", err)
+		}
+
+		s, err := FromAWSS3Event(s3Event)
+		if err != nil {
+			return nil, err
+		}
+
+		src.objects = append(src.objects, s.(*awsS3Event).objects...)
+	}
+
+	return &src, nil
+}
+
+type awsS3Event struct {
+	objects []awsS3Metadata
+}
+
+func (src *awsS3Event) Read(ctx context.Context, ch chan<- *message.Message) error {
+	awsCfg, err := iconfig.NewAWS(ctx, iconfig.AWS{})
+	if err != nil {
+		return fmt.Errorf("source aws_s3: %v", err)
+	}
+
+	client := s3.NewFromConfig(awsCfg)
+	if err := readAWSS3Objects(ctx, client, src.objects, ch); err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		return fmt.Errorf("source aws_s3: %v", err)
+	}
+
+	return nil
+}
+
+// readAWSS3Objects downloads each object to a temporary file and sends its
+// contents to the channel.
+func readAWSS3Objects(ctx context.Context, client *s3.Client, objects []awsS3Metadata, ch chan<- *message.Message) error {
+	downloader := manager.NewDownloader(client)
+
+	for _, o := range objects {
+		// Directory placeholders do not contain data.
+		if strings.HasSuffix(o.ObjectKey, "/") && o.ObjectSize == 0 {
+			continue
+		}
+
+		if err := readAWSS3Object(ctx, downloader, o, ch); err != nil {
+			return fmt.Errorf("%s/%s: %v", o.BucketName, o.ObjectKey, err)
+		}
+	}
+
+	return nil
+}
+
+func readAWSS3Object(ctx context.Context, downloader *manager.Downloader, o awsS3Metadata, ch chan<- *message.Message) error {
+	metadata, err := json.Marshal(o)
+	if err != nil {
+		return err
+	}
+
+	dst, err := os.CreateTemp("", "substation")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(dst.Name())
+	defer dst.Close()
+
+	if _, err := downloader.Download(ctx, dst, &s3.GetObjectInput{
+		Bucket: &o.BucketName,
+		Key:    &o.ObjectKey,
+	}); err != nil {
+		return err
+	}
+
+	return readFile(ctx, dst, metadata, ch)
+}
@@ -0,0 +1,51 @@
+package source
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"github.com/brexhq/substation/v2/message"
+)
+
+type awsSNSMetadata struct {
+	Timestamp            time.Time `json:"timestamp"`
+	EventSubscriptionArn string    `json:"eventSubscriptionArn"`
+	MessageID            string    `json:"messageId"`
+	Subject              string    `json:"subject"`
+}
+
+// FromAWSSNSEvent returns a Source that reads each record in an SNS event.
+func FromAWSSNSEvent(event events.SNSEvent) Source {
+	return &awsSNSEvent{event: event}
+}
+
+type awsSNSEvent struct {
+	event events.SNSEvent
+}
+
+func (src *awsSNSEvent) Read(ctx context.Context, ch chan<- *message.Message) error {
+	for _, record := range src.event.Records {
+		m := awsSNSMetadata{
+			Timestamp:            record.SNS.Timestamp,
+			EventSubscriptionArn: record.EventSubscriptionArn,
+			MessageID:            record.SNS.MessageID,
+			Subject:              record.SNS.Subject,
+		}
+
+		metadata, err := json.Marshal(m)
+		if err != nil {
+			return fmt.Errorf("source aws_sns: %v", err)
+		}
+
+		msg := message.New().SetData([]byte(record.SNS.Message)).SetMetadata(metadata)
+		if err := send(ctx, ch, msg); err != nil {
+			return nil
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,36 @@
+package source
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestFromAWSSNSEvent(t *testing.T) {
+	src := FromAWSSNSEvent(events.SNSEvent{
+		Records: []events.SNSEventRecord{
+			{
+				EventSubscriptionArn: "arn:aws:sns:us-east-1:123456789012:substation",
+				SNS: events.SNSEntity{
+					MessageID: "a",
+					Message:   `{"a":"b"}`,
+					Subject:   "foo",
+				},
+			},
+		},
+	})
+
+	msgs := readAll(t, src, 5*time.Second)
+	if len(msgs) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(msgs))
+	}
+
+	if string(msgs[0].Data()) != `{"a":"b"}` {
+		t.Errorf("expected %s, got %s", `{"a":"b"}`, msgs[0].Data())
+	}
+
+	if msgs[0].GetValue("meta subject").String() != "foo" {
+		t.Errorf("expected foo, got %s", msgs[0].GetValue("meta subject"))
+	}
+}
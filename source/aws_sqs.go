@@ -0,0 +1,174 @@
+package source
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/aws/aws-sdk-go/aws/arn"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+
+	iconfig "github.com/brexhq/substation/v2/internal/config"
+)
+
+type awsSQSMetadata struct {
+	EventSourceArn string            `json:"eventSourceArn"`
+	MessageID      string            `json:"messageId"`
+	BodyMd5        string            `json:"bodyMd5"`
+	Attributes     map[string]string `json:"attributes"`
+}
+
+type awsSQSConfig struct {
+	AWS iconfig.AWS `json:"aws"`
+}
+
+func (c *awsSQSConfig) Decode(in interface{}) error {
+	return iconfig.Decode(in, c)
+}
+
+func (c *awsSQSConfig) Validate() error {
+	if c.AWS.ARN == "" {
+		return fmt.Errorf("aws.arn: %v", iconfig.ErrMissingRequiredOption)
+	}
+
+	return nil
+}
+
+func newAWSSQS(ctx context.Context, cfg config.Config) (*awsSQS, error) {
+	conf := awsSQSConfig{}
+	if err := conf.Decode(cfg.Settings); err != nil {
+		return nil, fmt.Errorf("source aws_sqs: %v", err)
+	}
+
+	if err := conf.Validate(); err != nil {
+		return nil, fmt.Errorf("source aws_sqs: %v", err)
+	}
+
+	a, err := arn.Parse(conf.AWS.ARN)
+	if err != nil {
+		return nil, fmt.Errorf("source aws_sqs: %v", err)
+	}
+
+	src := awsSQS{
+		conf: conf,
+		queueURL: fmt.Sprintf(
+			"https://sqs.%s.amazonaws.com/%s/%s",
+			a.Region,
+			a.AccountID,
+			a.Resource,
+		),
+	}
+
+	awsCfg, err := iconfig.NewAWS(ctx, conf.AWS)
+	if err != nil {
+		return nil, fmt.Errorf("source aws_sqs: %v", err)
+	}
+
+	src.client = sqs.NewFromConfig(awsCfg)
+
+	return &src, nil
+}
+
+// awsSQS continuously polls an SQS queue and reads the body of each SQS
+// message as a message. SQS messages are deleted from the queue after they
+// are received by the pipeline.
+type awsSQS struct {
+	conf     awsSQSConfig
+	client   *sqs.Client
+	queueURL string
+}
+
+func (src *awsSQS) Read(ctx context.Context, ch chan<- *message.Message) error {
+	for {
+		resp, err := src.client.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
+			QueueUrl:            &src.queueURL,
+			MaxNumberOfMessages: 10,
+			// Long polling reduces the number of empty responses.
+			WaitTimeSeconds:             20,
+			MessageSystemAttributeNames: []types.MessageSystemAttributeName{types.MessageSystemAttributeNameAll},
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			return fmt.Errorf("source aws_sqs: %v", err)
+		}
+
+		if len(resp.Messages) == 0 {
+			continue
+		}
+
+		var entries []types.DeleteMessageBatchRequestEntry
+		for _, m := range resp.Messages {
+			attr := make(map[string]string)
+			for k, v := range m.Attributes {
+				attr[k] = v
+			}
+
+			metadata, err := json.Marshal(awsSQSMetadata{
+				EventSourceArn: src.conf.AWS.ARN,
+				MessageID:      aws.ToString(m.MessageId),
+				BodyMd5:        aws.ToString(m.MD5OfBody),
+				Attributes:     attr,
+			})
+			if err != nil {
+				return fmt.Errorf("source aws_sqs: %v", err)
+			}
+
+			msg := message.New().SetData([]byte(aws.ToString(m.Body))).SetMetadata(metadata)
+			if err := send(ctx, ch, msg); err != nil {
+				return nil
+			}
+
+			entries = append(entries, types.DeleteMessageBatchRequestEntry{
+				Id:            m.MessageId,
+				ReceiptHandle: m.ReceiptHandle,
+			})
+		}
+
+		if _, err := src.client.DeleteMessageBatch(context.WithoutCancel(ctx), &sqs.DeleteMessageBatchInput{
+			QueueUrl: &src.queueURL,
+			Entries:  entries,
+		}); err != nil {
+			return fmt.Errorf("source aws_sqs: %v", err)
+		}
+	}
+}
+
+// FromAWSSQSEvent returns a Source that reads the body of each SQS message
+// in an SQS event.
+func FromAWSSQSEvent(event events.SQSEvent) Source {
+	return &awsSQSEvent{event: event}
+}
+
+type awsSQSEvent struct {
+	event events.SQSEvent
+}
+
+func (src *awsSQSEvent) Read(ctx context.Context, ch chan<- *message.Message) error {
+	for _, record := range src.event.Records {
+		metadata, err := json.Marshal(awsSQSMetadata{
+			EventSourceArn: record.EventSourceARN,
+			MessageID:      record.MessageId,
+			BodyMd5:        record.Md5OfBody,
+			Attributes:     record.Attributes,
+		})
+		if err != nil {
+			return fmt.Errorf("source aws_sqs: %v", err)
+		}
+
+		msg := message.New().SetData([]byte(record.Body)).SetMetadata(metadata)
+		if err := send(ctx, ch, msg); err != nil {
+			return nil
+		}
+	}
+
+	return nil
+}
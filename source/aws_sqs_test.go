@@ -0,0 +1,42 @@
+package source
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+var _ Source = &awsSQS{}
+
+func TestFromAWSSQSEvent(t *testing.T) {
+	src := FromAWSSQSEvent(events.SQSEvent{
+		Records: []events.SQSMessage{
+			{MessageId: "a", Body: `{"a":"b"}`, EventSourceARN: "arn:aws:sqs:us-east-1:123456789012:substation"},
+			{MessageId: "b", Body: `{"c":"d"}`, EventSourceARN: "arn:aws:sqs:us-east-1:123456789012:substation"},
+		},
+	})
+
+	msgs := readAll(t, src, 5*time.Second)
+	expected := []struct {
+		data string
+		id   string
+	}{
+		{`{"a":"b"}`, "a"},
+		{`{"c":"d"}`, "b"},
+	}
+
+	if len(msgs) != len(expected) {
+		t.Fatalf("expected %d messages, got %d", len(expected), len(msgs))
+	}
+
+	for i, msg := range msgs {
+		if string(msg.Data()) != expected[i].data {
+			t.Errorf("expected %s, got %s", expected[i].data, msg.Data())
+		}
+
+		if msg.GetValue("meta messageId").String() != expected[i].id {
+			t.Errorf("expected %s, got %s", expected[i].id, msg.GetValue("meta messageId"))
+		}
+	}
+}
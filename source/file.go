@@ -0,0 +1,111 @@
+package source
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+
+	iconfig "github.com/brexhq/substation/v2/internal/config"
+)
+
+type fileMetadata struct {
+	FilePath string `json:"filePath"`
+	FileSize int64  `json:"fileSize"`
+}
+
+type fileConfig struct {
+	// Path is a file path or glob pattern (e.g., "data/*.jsonl.gz"). Files are
+	// read in lexical order.
+	Path string `json:"path"`
+}
+
+func (c *fileConfig) Decode(in interface{}) error {
+	return iconfig.Decode(in, c)
+}
+
+func (c *fileConfig) Validate() error {
+	if c.Path == "" {
+		return fmt.Errorf("path: %v", iconfig.ErrMissingRequiredOption)
+	}
+
+	return nil
+}
+
+func newFile(_ context.Context, cfg config.Config) (*file, error) {
+	conf := fileConfig{}
+	if err := conf.Decode(cfg.Settings); err != nil {
+		return nil, fmt.Errorf("source file: %v", err)
+	}
+
+	if err := conf.Validate(); err != nil {
+		return nil, fmt.Errorf("source file: %v", err)
+	}
+
+	src := file{
+		conf: conf,
+	}
+
+	return &src, nil
+}
+
+// file reads data from local files. Text files are decompressed (if necessary)
+// and each line is sent as a message, all other files are sent as a single
+// message.
+type file struct {
+	conf fileConfig
+}
+
+func (src *file) Read(ctx context.Context, ch chan<- *message.Message) error {
+	matches, err := filepath.Glob(src.conf.Path)
+	if err != nil {
+		return fmt.Errorf("source file: %v", err)
+	}
+
+	if len(matches) == 0 {
+		return fmt.Errorf("source file: %q matched no files", src.conf.Path)
+	}
+
+	for _, m := range matches {
+		if err := src.readFile(ctx, m, ch); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			return fmt.Errorf("source file: %s: %v", m, err)
+		}
+	}
+
+	return nil
+}
+
+func (src *file) readFile(ctx context.Context, path string, ch chan<- *message.Message) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	if info.IsDir() {
+		return nil
+	}
+
+	metadata, err := json.Marshal(fileMetadata{
+		FilePath: path,
+		FileSize: info.Size(),
+	})
+	if err != nil {
+		return err
+	}
+
+	return readFile(ctx, f, metadata, ch)
+}
@@ -0,0 +1,79 @@
+package source
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/brexhq/substation/v2/config"
+)
+
+var _ Source = &file{}
+
+var fileTests = []struct {
+	name     string
+	data     func() []byte
+	expected []string
+}{
+	{
+		"text",
+		func() []byte {
+			return []byte("{\"a\":\"b\"}\n{\"c\":\"d\"}\n")
+		},
+		[]string{`{"a":"b"}`, `{"c":"d"}`},
+	},
+	{
+		"gzip",
+		func() []byte {
+			var buf bytes.Buffer
+			gz := gzip.NewWriter(&buf)
+			_, _ = gz.Write([]byte("foo\nbar\nbaz\n"))
+			_ = gz.Close()
+
+			return buf.Bytes()
+		},
+		[]string{"foo", "bar", "baz"},
+	},
+}
+
+func TestFile(t *testing.T) {
+	ctx := context.TODO()
+
+	for _, test := range fileTests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "data")
+			if err := os.WriteFile(path, test.data(), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			src, err := New(ctx, config.Config{
+				Type: "file",
+				Settings: map[string]interface{}{
+					"path": filepath.Join(filepath.Dir(path), "*"),
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			msgs := readAll(t, src, 5*time.Second)
+			if len(msgs) != len(test.expected) {
+				t.Fatalf("expected %d messages, got %d", len(test.expected), len(msgs))
+			}
+
+			for i, msg := range msgs {
+				if string(msg.Data()) != test.expected[i] {
+					t.Errorf("expected %s, got %s", test.expected[i], msg.Data())
+				}
+
+				if msg.GetValue("meta filePath").String() != path {
+					t.Errorf("expected %s, got %s", path, msg.GetValue("meta filePath"))
+				}
+			}
+		})
+	}
+}
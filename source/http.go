@@ -0,0 +1,130 @@
+package source
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+
+	iconfig "github.com/brexhq/substation/v2/internal/config"
+)
+
+type httpServerMetadata struct {
+	Path       string            `json:"path"`
+	RemoteAddr string            `json:"remoteAddr"`
+	Headers    map[string]string `json:"headers"`
+	// TraceParent and TraceState contain the W3C trace context from the
+	// request, which is used by tracing to continue the trace.
+	TraceParent string `json:"traceparent,omitempty"`
+	TraceState  string `json:"tracestate,omitempty"`
+}
+
+type httpServerConfig struct {
+	// Address is the network address that the server listens on (e.g., ":8080").
+	Address string `json:"address"`
+}
+
+func (c *httpServerConfig) Decode(in interface{}) error {
+	return iconfig.Decode(in, c)
+}
+
+func (c *httpServerConfig) Validate() error {
+	if c.Address == "" {
+		return fmt.Errorf("address: %v", iconfig.ErrMissingRequiredOption)
+	}
+
+	return nil
+}
+
+func newHTTPServer(_ context.Context, cfg config.Config) (*httpServer, error) {
+	conf := httpServerConfig{}
+	if err := conf.Decode(cfg.Settings); err != nil {
+		return nil, fmt.Errorf("source http: %v", err)
+	}
+
+	if err := conf.Validate(); err != nil {
+		return nil, fmt.Errorf("source http: %v", err)
+	}
+
+	src := httpServer{
+		conf: conf,
+	}
+
+	return &src, nil
+}
+
+// httpServer reads the body of each POST or PUT request as a message.
+type httpServer struct {
+	conf httpServerConfig
+}
+
+func (src *httpServer) Read(ctx context.Context, ch chan<- *message.Message) error {
+	srv := &http.Server{
+		Addr:              src.conf.Address,
+		Handler:           src.handler(ctx, ch),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.ListenAndServe()
+	}()
+
+	select {
+	case <-ctx.Done():
+		// In-flight requests are allowed to complete before the source stops.
+		shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 30*time.Second)
+		defer cancel()
+
+		return srv.Shutdown(shutdownCtx)
+	case err := <-errCh:
+		return fmt.Errorf("source http: %v", err)
+	}
+}
+
+func (src *httpServer) handler(ctx context.Context, ch chan<- *message.Message) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost && r.Method != http.MethodPut {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		b, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		m := httpServerMetadata{
+			Path:       r.URL.Path,
+			RemoteAddr: r.RemoteAddr,
+			Headers:    make(map[string]string),
+			// Header names are canonicalized by the http package.
+			TraceParent: r.Header.Get("Traceparent"),
+			TraceState:  r.Header.Get("Tracestate"),
+		}
+
+		for k := range r.Header {
+			m.Headers[k] = r.Header.Get(k)
+		}
+
+		metadata, err := json.Marshal(m)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		msg := message.New().SetData(b).SetMetadata(metadata)
+		if err := send(ctx, ch, msg); err != nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+	})
+}
@@ -0,0 +1,71 @@
+package source
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/brexhq/substation/v2/message"
+)
+
+var _ Source = &httpServer{}
+
+var httpServerTests = []struct {
+	name     string
+	method   string
+	body     string
+	expected int
+}{
+	{
+		"post",
+		http.MethodPost,
+		`{"a":"b"}`,
+		http.StatusAccepted,
+	},
+	{
+		"put",
+		http.MethodPut,
+		`{"a":"b"}`,
+		http.StatusAccepted,
+	},
+	{
+		"get",
+		http.MethodGet,
+		"",
+		http.StatusMethodNotAllowed,
+	},
+}
+
+func TestHTTPServer(t *testing.T) {
+	ctx := context.TODO()
+
+	for _, test := range httpServerTests {
+		t.Run(test.name, func(t *testing.T) {
+			ch := make(chan *message.Message, 1)
+			src := &httpServer{}
+
+			req := httptest.NewRequest(test.method, "/foo", strings.NewReader(test.body))
+			rec := httptest.NewRecorder()
+			src.handler(ctx, ch).ServeHTTP(rec, req)
+
+			if rec.Code != test.expected {
+				t.Fatalf("expected %d, got %d", test.expected, rec.Code)
+			}
+
+			if test.expected != http.StatusAccepted {
+				return
+			}
+
+			msg := <-ch
+			if string(msg.Data()) != test.body {
+				t.Errorf("expected %s, got %s", test.body, msg.Data())
+			}
+
+			if msg.GetValue("meta path").String() != "/foo" {
+				t.Errorf("expected /foo, got %s", msg.GetValue("meta path"))
+			}
+		})
+	}
+}
@@ -0,0 +1,319 @@
+package source
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	kafkago "github.com/segmentio/kafka-go"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+
+	iconfig "github.com/brexhq/substation/v2/internal/config"
+)
+
+type kafkaConsumerMetadata struct {
+	Topic     string    `json:"topic"`
+	Partition int       `json:"partition"`
+	Offset    int64     `json:"offset"`
+	Key       string    `json:"key"`
+	Time      time.Time `json:"time"`
+}
+
+type kafkaConsumerConfig struct {
+	// Brokers are the addresses of the Kafka brokers that are used to
+	// discover the cluster (e.g., "localhost:9092").
+	Brokers []string `json:"brokers"`
+	// Topic is the Kafka topic that data is read from.
+	Topic string `json:"topic"`
+	// GroupID is the consumer group that offsets are committed to.
+	GroupID string `json:"group_id"`
+	// Delivery determines when offsets are committed. Must be one of:
+	//
+	// - at_least_once: The source flushes the pipeline every CommitInterval
+	// and offsets are committed after the flush. Records are not lost if the
+	// process crashes, but records that were read after the last commit are
+	// read again when the process restarts.
+	//
+	// - at_most_once: Offsets are committed after each record is received by
+	// the pipeline. Records that are buffered by transforms (e.g., batches in
+	// send transforms) are lost if the process crashes.
+	//
+	// This is optional and defaults to at_least_once.
+	Delivery string `json:"delivery"`
+	// CommitInterval is how often the pipeline is flushed and offsets are
+	// committed if Delivery is at_least_once. Flushing the pipeline also
+	// flushes aggregate transforms, so this limits the size of batches.
+	//
+	// This is optional and defaults to 5s.
+	CommitInterval string `json:"commit_interval"`
+}
+
+func (c *kafkaConsumerConfig) Decode(in interface{}) error {
+	return iconfig.Decode(in, c)
+}
+
+func (c *kafkaConsumerConfig) Validate() error {
+	if len(c.Brokers) == 0 {
+		return fmt.Errorf("brokers: %v", iconfig.ErrMissingRequiredOption)
+	}
+
+	if c.Topic == "" {
+		return fmt.Errorf("topic: %v", iconfig.ErrMissingRequiredOption)
+	}
+
+	if c.GroupID == "" {
+		return fmt.Errorf("group_id: %v", iconfig.ErrMissingRequiredOption)
+	}
+
+	switch c.Delivery {
+	case "at_least_once", "at_most_once":
+	default:
+		return fmt.Errorf("delivery %s: %v", c.Delivery, iconfig.ErrInvalidOption)
+	}
+
+	return nil
+}
+
+func newKafkaConsumer(_ context.Context, cfg config.Config) (*kafkaConsumer, error) {
+	conf := kafkaConsumerConfig{}
+	if err := conf.Decode(cfg.Settings); err != nil {
+		return nil, fmt.Errorf("source kafka: %v", err)
+	}
+
+	if conf.Delivery == "" {
+		conf.Delivery = "at_least_once"
+	}
+
+	if conf.CommitInterval == "" {
+		conf.CommitInterval = "5s"
+	}
+
+	if err := conf.Validate(); err != nil {
+		return nil, fmt.Errorf("source kafka: %v", err)
+	}
+
+	interval, err := time.ParseDuration(conf.CommitInterval)
+	if err != nil {
+		return nil, fmt.Errorf("source kafka: commit_interval: %v", err)
+	}
+
+	src := kafkaConsumer{
+		conf:     conf,
+		interval: interval,
+	}
+
+	return &src, nil
+}
+
+// kafkaConsumer reads each record from a Kafka topic as a message.
+//
+// If delivery is at_least_once, then the source sends a control message to
+// the pipeline every commit interval. The offsets of the records that were
+// sent before the control message are committed when the pipeline calls
+// Checkpoint, after the pipeline is flushed.
+type kafkaConsumer struct {
+	conf     kafkaConsumerConfig
+	interval time.Duration
+
+	// sendMu ensures that records and control messages are sent in the same
+	// order that they are added to checkpoints.
+	sendMu  sync.Mutex
+	pending []kafkago.Message
+
+	mu     sync.Mutex
+	reader *kafkago.Reader
+	// checkpoints contains the records that are committed by each call to
+	// Checkpoint, in the order that control messages were sent.
+	checkpoints [][]kafkago.Message
+}
+
+func (src *kafkaConsumer) Read(ctx context.Context, ch chan<- *message.Message) error {
+	r := kafkago.NewReader(kafkago.ReaderConfig{
+		Brokers: src.conf.Brokers,
+		Topic:   src.conf.Topic,
+		GroupID: src.conf.GroupID,
+		// Offsets are committed to the consumer group in the background.
+		CommitInterval: time.Second,
+	})
+
+	if src.conf.Delivery == "at_most_once" {
+		// Closing the reader commits any pending offsets.
+		defer r.Close()
+
+		return src.read(ctx, r, ch)
+	}
+
+	// The reader is closed by Close, after the final checkpoint.
+	src.mu.Lock()
+	src.reader = r
+	src.mu.Unlock()
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		ticker := time.NewTicker(src.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := src.flush(ctx, ch); err != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	err := src.read(ctx, r, ch)
+	close(done)
+	wg.Wait()
+
+	// Records that were sent after the last control message are committed
+	// by the final checkpoint, which happens after the pipeline is flushed.
+	src.sendMu.Lock()
+	defer src.sendMu.Unlock()
+
+	if len(src.pending) > 0 {
+		src.mu.Lock()
+		src.checkpoints = append(src.checkpoints, src.pending)
+		src.mu.Unlock()
+
+		src.pending = nil
+	}
+
+	return err
+}
+
+func (src *kafkaConsumer) read(ctx context.Context, r *kafkago.Reader, ch chan<- *message.Message) error {
+	for {
+		record, err := r.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			return fmt.Errorf("source kafka: %v", err)
+		}
+
+		metadata, err := json.Marshal(kafkaConsumerMetadata{
+			Topic:     record.Topic,
+			Partition: record.Partition,
+			Offset:    record.Offset,
+			Key:       string(record.Key),
+			Time:      record.Time,
+		})
+		if err != nil {
+			return fmt.Errorf("source kafka: %v", err)
+		}
+
+		msg := message.New().SetData(record.Value).SetMetadata(metadata)
+
+		src.sendMu.Lock()
+		err = send(ctx, ch, msg)
+		if err == nil && src.conf.Delivery == "at_least_once" {
+			src.pending = append(src.pending, record)
+		}
+		src.sendMu.Unlock()
+
+		if err != nil {
+			return nil
+		}
+
+		if src.conf.Delivery == "at_least_once" {
+			continue
+		}
+
+		// Offsets are committed after the message is received by the
+		// pipeline, so data that is buffered by transforms is lost if the
+		// process crashes.
+		if err := r.CommitMessages(context.WithoutCancel(ctx), record); err != nil {
+			return fmt.Errorf("source kafka: %v", err)
+		}
+	}
+}
+
+// flush sends a control message that flushes the pipeline. The records that
+// were sent before it are committed by the next call to Checkpoint.
+func (src *kafkaConsumer) flush(ctx context.Context, ch chan<- *message.Message) error {
+	src.sendMu.Lock()
+	defer src.sendMu.Unlock()
+
+	if len(src.pending) == 0 {
+		return nil
+	}
+
+	// The records are added before the control message is sent, otherwise
+	// the pipeline can call Checkpoint before they are added.
+	src.mu.Lock()
+	src.checkpoints = append(src.checkpoints, src.pending)
+	src.mu.Unlock()
+
+	if err := send(ctx, ch, message.New().AsControl()); err != nil {
+		// The pipeline did not receive the control message, so the records
+		// are committed by a later checkpoint.
+		src.mu.Lock()
+		src.checkpoints = src.checkpoints[:len(src.checkpoints)-1]
+		src.mu.Unlock()
+
+		return err
+	}
+
+	src.pending = nil
+
+	return nil
+}
+
+// Checkpoint commits the offsets of the records that were sent before the
+// oldest control message that was not checkpointed.
+func (src *kafkaConsumer) Checkpoint(ctx context.Context) error {
+	src.mu.Lock()
+	defer src.mu.Unlock()
+
+	if len(src.checkpoints) == 0 {
+		return nil
+	}
+
+	records := src.checkpoints[0]
+	src.checkpoints = src.checkpoints[1:]
+
+	if src.reader == nil {
+		return nil
+	}
+
+	if err := src.reader.CommitMessages(context.WithoutCancel(ctx), records...); err != nil {
+		return fmt.Errorf("source kafka: %v", err)
+	}
+
+	return nil
+}
+
+// Close closes the reader, which commits any offsets that are pending.
+func (src *kafkaConsumer) Close() error {
+	src.mu.Lock()
+	defer src.mu.Unlock()
+
+	if src.reader == nil {
+		return nil
+	}
+
+	err := src.reader.Close()
+	src.reader = nil
+
+	if err != nil {
+		return fmt.Errorf("source kafka: %v", err)
+	}
+
+	return nil
+}
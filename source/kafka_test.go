@@ -0,0 +1,79 @@
+package source
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	kafkago "github.com/segmentio/kafka-go"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+)
+
+var _ Checkpointer = &kafkaConsumer{}
+
+func TestKafkaConsumerFlush(t *testing.T) {
+	ctx := context.TODO()
+
+	src, err := newKafkaConsumer(ctx, config.Config{
+		Settings: map[string]interface{}{
+			"brokers":  []string{"localhost:9092"},
+			"topic":    "logs",
+			"group_id": "substation",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ch := make(chan *message.Message, 1)
+
+	// Nothing is flushed if no records were sent.
+	if err := src.flush(ctx, ch); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ch) != 0 {
+		t.Fatalf("expected no control message, got %d", len(ch))
+	}
+
+	first := []kafkago.Message{{Offset: 1}, {Offset: 2}}
+	src.pending = first
+
+	if err := src.flush(ctx, ch); err != nil {
+		t.Fatal(err)
+	}
+
+	if msg := <-ch; !msg.IsControl() {
+		t.Error("expected control message")
+	}
+
+	// If the control message is not sent, then the records stay pending.
+	second := []kafkago.Message{{Offset: 3}}
+	src.pending = second
+
+	cancelCtx, cancel := context.WithCancel(ctx)
+	cancel()
+
+	if err := src.flush(cancelCtx, make(chan *message.Message)); err == nil {
+		t.Error("expected error")
+	}
+
+	if !reflect.DeepEqual(src.pending, second) {
+		t.Errorf("expected pending %v, got %v", second, src.pending)
+	}
+
+	if !reflect.DeepEqual(src.checkpoints, [][]kafkago.Message{first}) {
+		t.Errorf("expected checkpoints %v, got %v", [][]kafkago.Message{first}, src.checkpoints)
+	}
+
+	// Checkpoints are removed in the order that they were added.
+	if err := src.Checkpoint(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(src.checkpoints) != 0 {
+		t.Errorf("expected no checkpoints, got %d", len(src.checkpoints))
+	}
+}
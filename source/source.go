@@ -0,0 +1,130 @@
+// Package source provides functions for reading messages from external systems.
+package source
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+
+	"github.com/brexhq/substation/v2/internal/bufio"
+	iconfig "github.com/brexhq/substation/v2/internal/config"
+	"github.com/brexhq/substation/v2/internal/media"
+)
+
+// Source is the interface implemented by all sources and provides the
+// ability to read messages from an external system.
+//
+// Read sends messages to the channel until the source is exhausted or the
+// context is canceled. Canceling the context is not an error, and sources
+// must stop sending messages when it happens. The caller owns the channel
+// and closes it after Read returns.
+type Source interface {
+	Read(context.Context, chan<- *message.Message) error
+}
+
+// Checkpointer is implemented by sources that acknowledge messages after
+// they are durable (e.g., by committing Kafka offsets).
+//
+// These sources send control messages to the channel. Each time Substation.Run
+// receives a control message, it waits for all messages that were sent before
+// it to be transformed, flushes the pipeline, and calls Checkpoint. Run also
+// calls Checkpoint after the final flush, when the source is exhausted, and
+// calls Close before it returns.
+type Checkpointer interface {
+	Source
+	Checkpoint(context.Context) error
+	Close() error
+}
+
+// Factory can be used to implement custom source factory functions.
+type Factory func(context.Context, config.Config) (Source, error)
+
+// New is a factory function for returning a configured Source.
+func New(ctx context.Context, cfg config.Config) (Source, error) {
+	switch cfg.Type {
+	// AWS sources.
+	case "aws_kinesis_data_stream":
+		return newAWSKinesisDataStream(ctx, cfg)
+	case "aws_s3":
+		return newAWSS3(ctx, cfg)
+	case "aws_sqs":
+		return newAWSSQS(ctx, cfg)
+	// File sources.
+	case "file":
+		return newFile(ctx, cfg)
+	case "stdin":
+		return newStdin(ctx, cfg)
+	// Network sources.
+	case "http":
+		return newHTTPServer(ctx, cfg)
+	case "kafka":
+		return newKafkaConsumer(ctx, cfg)
+	case "syslog":
+		return newSyslogServer(ctx, cfg)
+	case "tcp":
+		return newTCPServer(ctx, cfg)
+	case "udp":
+		return newUDPServer(ctx, cfg)
+	default:
+		return nil, fmt.Errorf("source %s: %w", cfg.Type, iconfig.ErrInvalidFactoryInput)
+	}
+}
+
+// send sends a message to the channel. If the context is canceled before the
+// message is received, then the context error is returned.
+func send(ctx context.Context, ch chan<- *message.Message, msg *message.Message) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case ch <- msg:
+		return nil
+	}
+}
+
+// readFile sends the contents of a file to the channel. Text files are
+// decompressed by the bufio package (if necessary) and each line is sent as a
+// separate message. All other files are sent as a single message.
+func readFile(ctx context.Context, f *os.File, metadata []byte, ch chan<- *message.Message) error {
+	mediaType, err := media.File(f)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Seek(0, 0); err != nil {
+		return err
+	}
+
+	// Unsupported media types are sent as binary data.
+	if !slices.Contains(bufio.MediaTypes, mediaType) {
+		r, err := io.ReadAll(f)
+		if err != nil {
+			return err
+		}
+
+		msg := message.New().SetData(r).SetMetadata(metadata)
+		return send(ctx, ch, msg)
+	}
+
+	scanner := bufio.NewScanner()
+	defer scanner.Close()
+
+	if err := scanner.ReadFile(f); err != nil {
+		return err
+	}
+
+	for scanner.Scan() {
+		b := []byte(scanner.Text())
+		msg := message.New().SetData(b).SetMetadata(metadata)
+
+		if err := send(ctx, ch, msg); err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
@@ -0,0 +1,133 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+
+	iconfig "github.com/brexhq/substation/v2/internal/config"
+)
+
+var sourceNewTests = []struct {
+	name string
+	cfg  config.Config
+	err  error
+}{
+	{
+		"invalid type",
+		config.Config{
+			Type: "invalid",
+		},
+		iconfig.ErrInvalidFactoryInput,
+	},
+	{
+		"missing file path",
+		config.Config{
+			Type: "file",
+		},
+		iconfig.ErrMissingRequiredOption,
+	},
+	{
+		"missing tcp address",
+		config.Config{
+			Type:     "tcp",
+			Settings: map[string]interface{}{},
+		},
+		iconfig.ErrMissingRequiredOption,
+	},
+	{
+		"missing syslog tls certificate",
+		config.Config{
+			Type: "syslog",
+			Settings: map[string]interface{}{
+				"address":  ":6514",
+				"protocol": "tls",
+			},
+		},
+		iconfig.ErrMissingRequiredOption,
+	},
+	{
+		"invalid syslog protocol",
+		config.Config{
+			Type: "syslog",
+			Settings: map[string]interface{}{
+				"address":  ":514",
+				"protocol": "http",
+			},
+		},
+		iconfig.ErrInvalidOption,
+	},
+	{
+		"missing kafka topic",
+		config.Config{
+			Type: "kafka",
+			Settings: map[string]interface{}{
+				"brokers":  []string{"localhost:9092"},
+				"group_id": "substation",
+			},
+		},
+		iconfig.ErrMissingRequiredOption,
+	},
+	{
+		"invalid kafka delivery",
+		config.Config{
+			Type: "kafka",
+			Settings: map[string]interface{}{
+				"brokers":  []string{"localhost:9092"},
+				"topic":    "logs",
+				"group_id": "substation",
+				"delivery": "exactly_once",
+			},
+		},
+		iconfig.ErrInvalidOption,
+	},
+}
+
+func TestNew(t *testing.T) {
+	ctx := context.TODO()
+
+	for _, test := range sourceNewTests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := New(ctx, test.cfg)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+
+			// Option errors are formatted with %v, so only the factory error is wrapped.
+			if errors.Is(test.err, iconfig.ErrInvalidFactoryInput) && !errors.Is(err, test.err) {
+				t.Errorf("expected %v, got %v", test.err, err)
+			}
+		})
+	}
+}
+
+// readAll reads messages from the source until it returns or the
+// timeout expires.
+func readAll(t *testing.T, src Source, timeout time.Duration) []*message.Message {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	ch := make(chan *message.Message)
+	errCh := make(chan error, 1)
+	go func() {
+		defer close(ch)
+		errCh <- src.Read(ctx, ch)
+	}()
+
+	var msgs []*message.Message
+	for msg := range ch {
+		msgs = append(msgs, msg)
+	}
+
+	if err := <-errCh; err != nil {
+		t.Fatal(err)
+	}
+
+	return msgs
+}
@@ -0,0 +1,99 @@
+package source
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+
+	iconfig "github.com/brexhq/substation/v2/internal/config"
+)
+
+type stdinConfig struct{}
+
+func (c *stdinConfig) Decode(in interface{}) error {
+	return iconfig.Decode(in, c)
+}
+
+func newStdin(_ context.Context, cfg config.Config) (*stdin, error) {
+	conf := stdinConfig{}
+	if err := conf.Decode(cfg.Settings); err != nil {
+		return nil, fmt.Errorf("source stdin: %v", err)
+	}
+
+	src := stdin{
+		conf:   conf,
+		reader: os.Stdin,
+	}
+
+	return &src, nil
+}
+
+// stdin reads each line from standard input as a message.
+type stdin struct {
+	conf   stdinConfig
+	reader io.Reader
+}
+
+func (src *stdin) Read(ctx context.Context, ch chan<- *message.Message) error {
+	// Reads from stdin block and cannot be canceled, so the scanner runs in a
+	// separate goroutine. Only Read sends to the channel, so nothing is sent
+	// after the caller closes it. If the context is canceled, then the
+	// goroutine exits after its next read.
+	scanCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	msgs := make(chan *message.Message)
+	errCh := make(chan error, 1)
+	go func() {
+		defer close(msgs)
+		errCh <- scan(scanCtx, src.reader, bufio.ScanLines, nil, msgs)
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg, ok := <-msgs:
+			if !ok {
+				if err := <-errCh; err != nil && ctx.Err() == nil {
+					return fmt.Errorf("source stdin: %v", err)
+				}
+
+				return nil
+			}
+
+			if err := send(ctx, ch, msg); err != nil {
+				return nil
+			}
+		}
+	}
+}
+
+// scan sends each token from the reader as a message. Empty tokens are
+// ignored.
+func scan(ctx context.Context, r io.Reader, split bufio.SplitFunc, metadata []byte, ch chan<- *message.Message) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Split(split)
+	scanner.Buffer(make([]byte, bufio.MaxScanTokenSize), 1000*1000*128)
+
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		b := make([]byte, len(scanner.Bytes()))
+		copy(b, scanner.Bytes())
+
+		msg := message.New().SetData(b).SetMetadata(metadata)
+		if err := send(ctx, ch, msg); err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
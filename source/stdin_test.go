@@ -0,0 +1,71 @@
+package source
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brexhq/substation/v2/message"
+)
+
+var _ Source = &stdin{}
+
+func TestStdin(t *testing.T) {
+	src := &stdin{reader: strings.NewReader("a\n\nb\n")}
+
+	msgs := readAll(t, src, time.Second)
+	if len(msgs) != 2 {
+		t.Fatalf("expected 2 messages, got %d", len(msgs))
+	}
+
+	for i, e := range []string{"a", "b"} {
+		if string(msgs[i].Data()) != e {
+			t.Errorf("expected %s, got %s", e, msgs[i].Data())
+		}
+	}
+}
+
+// Reads from stdin cannot be canceled, so lines that are read after the
+// context is canceled must not be sent to the channel, which is closed by the
+// caller.
+func TestStdinCancel(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		r, w := io.Pipe()
+		src := &stdin{reader: r}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		ch := make(chan *message.Message)
+		errCh := make(chan error, 1)
+		go func() {
+			defer close(ch)
+			errCh <- src.Read(ctx, ch)
+		}()
+
+		go func() {
+			_, _ = w.Write([]byte("a\n"))
+		}()
+
+		if msg := <-ch; string(msg.Data()) != "a" {
+			t.Fatalf("expected a, got %s", msg.Data())
+		}
+
+		// The scanner is blocked on the next read when the context is
+		// canceled.
+		cancel()
+		if err := <-errCh; err != nil {
+			t.Fatal(err)
+		}
+
+		if _, ok := <-ch; ok {
+			t.Fatal("expected closed channel")
+		}
+
+		// The scanner reads this line after the channel is closed.
+		if _, err := w.Write([]byte("b\n")); err != nil {
+			t.Fatal(err)
+		}
+		w.Close()
+	}
+}
@@ -0,0 +1,165 @@
+package source
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"os"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+
+	iconfig "github.com/brexhq/substation/v2/internal/config"
+)
+
+type syslogServerTLSConfig struct {
+	// CertFile is the path to the PEM encoded certificate of the server.
+	CertFile string `json:"cert_file"`
+	// KeyFile is the path to the PEM encoded private key of the server.
+	KeyFile string `json:"key_file"`
+	// ClientCAFile is the path to PEM encoded certificate authorities that are
+	// used to verify client certificates.
+	//
+	// This is optional and defaults to not requiring client certificates.
+	ClientCAFile string `json:"client_ca_file"`
+}
+
+type syslogServerConfig struct {
+	// Address is the network address that the server listens on (e.g., ":514").
+	Address string `json:"address"`
+	// Protocol is the transport used by clients. Must be one of:
+	//
+	// - udp: each datagram is a message (RFC 5426).
+	//
+	// - tcp: each line or octet counted frame is a message (RFC 6587).
+	//
+	// - tls: the same as tcp, but connections use TLS (RFC 5425).
+	//
+	// This is optional and defaults to udp.
+	Protocol string `json:"protocol"`
+	// TLS configures the server certificate. This is required if the
+	// protocol is tls.
+	TLS syslogServerTLSConfig `json:"tls"`
+}
+
+func (c *syslogServerConfig) Decode(in interface{}) error {
+	return iconfig.Decode(in, c)
+}
+
+func (c *syslogServerConfig) Validate() error {
+	if c.Address == "" {
+		return fmt.Errorf("address: %v", iconfig.ErrMissingRequiredOption)
+	}
+
+	switch c.Protocol {
+	case "udp", "tcp":
+	case "tls":
+		if c.TLS.CertFile == "" {
+			return fmt.Errorf("tls.cert_file: %v", iconfig.ErrMissingRequiredOption)
+		}
+
+		if c.TLS.KeyFile == "" {
+			return fmt.Errorf("tls.key_file: %v", iconfig.ErrMissingRequiredOption)
+		}
+	default:
+		return fmt.Errorf("protocol %s: %v", c.Protocol, iconfig.ErrInvalidOption)
+	}
+
+	return nil
+}
+
+func newSyslogServer(_ context.Context, cfg config.Config) (*syslogServer, error) {
+	conf := syslogServerConfig{}
+	if err := conf.Decode(cfg.Settings); err != nil {
+		return nil, fmt.Errorf("source syslog: %v", err)
+	}
+
+	if conf.Protocol == "" {
+		conf.Protocol = "udp"
+	}
+
+	if err := conf.Validate(); err != nil {
+		return nil, fmt.Errorf("source syslog: %v", err)
+	}
+
+	src := syslogServer{
+		conf: conf,
+	}
+
+	if conf.Protocol == "tls" {
+		tlsConf, err := syslogTLSConfig(conf.TLS)
+		if err != nil {
+			return nil, fmt.Errorf("source syslog: %v", err)
+		}
+
+		src.tls = tlsConf
+	}
+
+	return &src, nil
+}
+
+// syslogServer reads syslog messages sent over UDP, TCP, or TLS. Messages are
+// not parsed, which is done by the format_from_syslog transform.
+type syslogServer struct {
+	conf syslogServerConfig
+	tls  *tls.Config
+}
+
+func (src *syslogServer) Read(ctx context.Context, ch chan<- *message.Message) error {
+	switch src.conf.Protocol {
+	case "tcp":
+		ln, err := net.Listen("tcp", src.conf.Address)
+		if err != nil {
+			return fmt.Errorf("source syslog: %v", err)
+		}
+
+		return serve(ctx, ln, ch)
+	case "tls":
+		ln, err := tls.Listen("tcp", src.conf.Address, src.tls)
+		if err != nil {
+			return fmt.Errorf("source syslog: %v", err)
+		}
+
+		return serve(ctx, ln, ch)
+	default:
+		conn, err := net.ListenPacket("udp", src.conf.Address)
+		if err != nil {
+			return fmt.Errorf("source syslog: %v", err)
+		}
+
+		return servePacket(ctx, conn, ch)
+	}
+}
+
+// syslogTLSConfig returns a server TLS configuration. If a client CA file is
+// provided, then clients must present a certificate that is signed by it.
+func syslogTLSConfig(c syslogServerTLSConfig) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	conf := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if c.ClientCAFile != "" {
+		b, err := os.ReadFile(c.ClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(b) {
+			return nil, fmt.Errorf("tls.client_ca_file %s: %v", c.ClientCAFile, iconfig.ErrInvalidOption)
+		}
+
+		conf.ClientCAs = pool
+		conf.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return conf, nil
+}
@@ -0,0 +1,271 @@
+package source
+
+import (
+	"bufio"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+)
+
+var _ Source = &syslogServer{}
+
+// syslogTestCert writes a self-signed certificate and key to a temporary
+// directory and returns their paths.
+func syslogTestCert(t *testing.T) (string, string, *x509.Certificate) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "localhost"},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return certFile, keyFile, cert
+}
+
+func TestSyslogServerTLS(t *testing.T) {
+	certFile, keyFile, cert := syslogTestCert(t)
+
+	src, err := newSyslogServer(context.TODO(), config.Config{
+		Settings: map[string]interface{}{
+			"address":  "127.0.0.1:0",
+			"protocol": "tls",
+			"tls": map[string]interface{}{
+				"cert_file": certFile,
+				"key_file":  keyFile,
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The listener is created by the test to use a random port.
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", src.tls)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ch := make(chan *message.Message)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- serve(ctx, ln, ch)
+	}()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+
+	conn, err := tls.Dial("tcp", ln.Addr().String(), &tls.Config{
+		RootCAs:    pool,
+		MinVersion: tls.VersionTLS12,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := conn.Write([]byte("12 <13>hello hi<13>goodbye\n")); err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+
+	expected := []string{"<13>hello hi", "<13>goodbye"}
+	for _, e := range expected {
+		msg := <-ch
+		if string(msg.Data()) != e {
+			t.Errorf("expected %s, got %s", e, msg.Data())
+		}
+	}
+
+	cancel()
+	if err := <-errCh; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSyslogServerTLSClientCertificate(t *testing.T) {
+	certFile, keyFile, cert := syslogTestCert(t)
+
+	src, err := newSyslogServer(context.TODO(), config.Config{
+		Settings: map[string]interface{}{
+			"address":  "127.0.0.1:0",
+			"protocol": "tls",
+			"tls": map[string]interface{}{
+				"cert_file":      certFile,
+				"key_file":       keyFile,
+				"client_ca_file": certFile,
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", src.tls)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ch := make(chan *message.Message)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- serve(ctx, ln, ch)
+	}()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+
+	// Clients without a certificate are rejected during the handshake.
+	conn, err := tls.Dial("tcp", ln.Addr().String(), &tls.Config{
+		RootCAs:    pool,
+		MinVersion: tls.VersionTLS12,
+	})
+	if err == nil {
+		_, _ = conn.Write([]byte("<13>hello\n"))
+		_, err = bufio.NewReader(conn).ReadByte()
+		conn.Close()
+	}
+
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	cancel()
+	if err := <-errCh; err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case msg := <-ch:
+		t.Errorf("expected no messages, got %s", msg.Data())
+	default:
+	}
+}
+
+func TestSyslogServerUDP(t *testing.T) {
+	// A random port is reserved and released so that the source can
+	// listen on it.
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addr := conn.LocalAddr().String()
+	conn.Close()
+
+	src, err := newSyslogServer(context.TODO(), config.Config{
+		Settings: map[string]interface{}{
+			"address": addr,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ch := make(chan *message.Message)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- src.Read(ctx, ch)
+	}()
+
+	udpAddr, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The client is not connected, so writes do not fail if the source is
+	// not listening yet.
+	client, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	// Datagrams that are sent before the source is listening are lost, so
+	// they are sent until one is received.
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		if _, err := client.WriteTo([]byte("<13>hello"), udpAddr); err != nil {
+			t.Fatal(err)
+		}
+
+		select {
+		case msg := <-ch:
+			if string(msg.Data()) != "<13>hello" {
+				t.Errorf("expected %s, got %s", "<13>hello", msg.Data())
+			}
+
+			cancel()
+			if err := <-errCh; err != nil {
+				t.Fatal(err)
+			}
+
+			return
+		case <-ticker.C:
+		case <-ctx.Done():
+			t.Fatal(ctx.Err())
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package source
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+
+	iconfig "github.com/brexhq/substation/v2/internal/config"
+)
+
+type netMetadata struct {
+	RemoteAddr string `json:"remoteAddr"`
+}
+
+type tcpServerConfig struct {
+	// Address is the network address that the server listens on (e.g., ":514").
+	Address string `json:"address"`
+}
+
+func (c *tcpServerConfig) Decode(in interface{}) error {
+	return iconfig.Decode(in, c)
+}
+
+func (c *tcpServerConfig) Validate() error {
+	if c.Address == "" {
+		return fmt.Errorf("address: %v", iconfig.ErrMissingRequiredOption)
+	}
+
+	return nil
+}
+
+func newTCPServer(_ context.Context, cfg config.Config) (*tcpServer, error) {
+	conf := tcpServerConfig{}
+	if err := conf.Decode(cfg.Settings); err != nil {
+		return nil, fmt.Errorf("source tcp: %v", err)
+	}
+
+	if err := conf.Validate(); err != nil {
+		return nil, fmt.Errorf("source tcp: %v", err)
+	}
+
+	src := tcpServer{
+		conf: conf,
+	}
+
+	return &src, nil
+}
+
+// tcpServer reads each line (or octet counted frame) received from a client
+// as a message. This is compatible with syslog over TCP (RFC 6587).
+type tcpServer struct {
+	conf tcpServerConfig
+}
+
+func (src *tcpServer) Read(ctx context.Context, ch chan<- *message.Message) error {
+	ln, err := net.Listen("tcp", src.conf.Address)
+	if err != nil {
+		return fmt.Errorf("source tcp: %v", err)
+	}
+
+	return serve(ctx, ln, ch)
+}
+
+// serve accepts connections from the listener until the context is canceled.
+func serve(ctx context.Context, ln net.Listener, ch chan<- *message.Message) error {
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		conns  = make(map[net.Conn]struct{})
+		closed bool
+	)
+
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+
+		mu.Lock()
+		closed = true
+		for c := range conns {
+			c.Close()
+		}
+		mu.Unlock()
+	}()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			wg.Wait()
+
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			return fmt.Errorf("source tcp: %v", err)
+		}
+
+		// Connections that are accepted after shutdown starts are not
+		// closed by the shutdown goroutine, so they are closed here.
+		mu.Lock()
+		if closed {
+			mu.Unlock()
+			conn.Close()
+
+			continue
+		}
+
+		conns[conn] = struct{}{}
+		mu.Unlock()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() {
+				mu.Lock()
+				delete(conns, conn)
+				mu.Unlock()
+
+				conn.Close()
+			}()
+
+			metadata, _ := json.Marshal(netMetadata{RemoteAddr: conn.RemoteAddr().String()})
+
+			// Errors from individual connections (e.g., resets) do not stop the source.
+			_ = scan(ctx, conn, splitSyslog, metadata, ch)
+		}()
+	}
+}
+
+// splitSyslog splits a stream into newline delimited lines or octet counted
+// frames. Octet counting is described in RFC 6587 and is only used if the
+// frame contains a syslog message (starts with '<').
+func splitSyslog(data []byte, atEOF bool) (int, []byte, error) {
+	if len(data) > 0 && data[0] >= '1' && data[0] <= '9' {
+		// Message lengths are limited to 10 digits.
+		idx := bytes.IndexByte(data[:min(len(data), 11)], ' ')
+
+		switch {
+		case idx > 0 && len(data) > idx+1:
+			n, err := strconv.Atoi(string(data[:idx]))
+			if err == nil && data[idx+1] == '<' {
+				if len(data) >= idx+1+n {
+					return idx + 1 + n, bytes.TrimRight(data[idx+1:idx+1+n], "\r\n"), nil
+				}
+
+				if !atEOF {
+					return 0, nil, nil
+				}
+			}
+		case !atEOF && bytes.IndexByte(data, '\n') == -1 && (idx == -1 && len(data) < 11 || idx == len(data)-1):
+			// More data is needed to determine the framing.
+			return 0, nil, nil
+		}
+	}
+
+	return bufio.ScanLines(data, atEOF)
+}
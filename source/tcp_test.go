@@ -0,0 +1,176 @@
+package source
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/brexhq/substation/v2/message"
+)
+
+var _ Source = &tcpServer{}
+
+var splitSyslogTests = []struct {
+	name     string
+	data     []byte
+	expected []string
+}{
+	{
+		"lines",
+		[]byte("foo\nbar\r\nbaz"),
+		[]string{"foo", "bar", "baz"},
+	},
+	{
+		"octet counting",
+		[]byte("12 <13>hello hi14 <13>goodbye hi"),
+		[]string{"<13>hello hi", "<13>goodbye hi"},
+	},
+	{
+		"octet counting with trailer",
+		[]byte("13 <13>hello hi\n"),
+		[]string{"<13>hello hi"},
+	},
+	{
+		"digits without syslog",
+		[]byte("123 abc\n456\n"),
+		[]string{"123 abc", "456"},
+	},
+}
+
+func TestSplitSyslog(t *testing.T) {
+	for _, test := range splitSyslogTests {
+		t.Run(test.name, func(t *testing.T) {
+			scanner := bufio.NewScanner(bytes.NewReader(test.data))
+			scanner.Split(splitSyslog)
+
+			var result []string
+			for scanner.Scan() {
+				result = append(result, scanner.Text())
+			}
+
+			if err := scanner.Err(); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(result) != len(test.expected) {
+				t.Fatalf("expected %q, got %q", test.expected, result)
+			}
+
+			for i := range result {
+				if result[i] != test.expected[i] {
+					t.Errorf("expected %q, got %q", test.expected[i], result[i])
+				}
+			}
+		})
+	}
+}
+
+func TestTCPServer(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ch := make(chan *message.Message)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- serve(ctx, ln, ch)
+	}()
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := conn.Write([]byte("12 <13>hello hifoo\n")); err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+
+	expected := []string{"<13>hello hi", "foo"}
+	for _, e := range expected {
+		msg := <-ch
+		if string(msg.Data()) != e {
+			t.Errorf("expected %s, got %s", e, msg.Data())
+		}
+
+		if msg.GetValue("meta remoteAddr").String() != conn.LocalAddr().String() {
+			t.Errorf("expected %s, got %s", conn.LocalAddr(), msg.GetValue("meta remoteAddr"))
+		}
+	}
+
+	cancel()
+	if err := <-errCh; err != nil {
+		t.Fatal(err)
+	}
+}
+
+// tcpTestListener returns connections from a channel, even after it is
+// closed.
+type tcpTestListener struct {
+	conns  chan net.Conn
+	closed chan struct{}
+}
+
+func (l *tcpTestListener) Accept() (net.Conn, error) {
+	c, ok := <-l.conns
+	if !ok {
+		return nil, net.ErrClosed
+	}
+
+	return c, nil
+}
+
+func (l *tcpTestListener) Close() error {
+	close(l.closed)
+	return nil
+}
+
+func (l *tcpTestListener) Addr() net.Addr {
+	return &net.TCPAddr{}
+}
+
+func TestTCPServerAcceptAfterShutdown(t *testing.T) {
+	ln := &tcpTestListener{
+		conns:  make(chan net.Conn),
+		closed: make(chan struct{}),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- serve(ctx, ln, make(chan *message.Message))
+	}()
+
+	cancel()
+	<-ln.closed
+
+	// Allow the shutdown goroutine to close the open connections before the
+	// listener returns a new connection.
+	time.Sleep(50 * time.Millisecond)
+
+	server, client := net.Pipe()
+	defer client.Close()
+
+	ln.conns <- server
+	close(ln.conns)
+
+	select {
+	case err := <-errCh:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("server did not shut down")
+	}
+
+	if _, err := client.Read(make([]byte, 1)); err == nil {
+		t.Error("expected closed connection")
+	}
+}
@@ -0,0 +1,101 @@
+package source
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+
+	iconfig "github.com/brexhq/substation/v2/internal/config"
+)
+
+// udpMaxDatagramSize is the largest UDP payload that can be received.
+const udpMaxDatagramSize = 64 * 1024
+
+type udpServerConfig struct {
+	// Address is the network address that the server listens on (e.g., ":514").
+	Address string `json:"address"`
+}
+
+func (c *udpServerConfig) Decode(in interface{}) error {
+	return iconfig.Decode(in, c)
+}
+
+func (c *udpServerConfig) Validate() error {
+	if c.Address == "" {
+		return fmt.Errorf("address: %v", iconfig.ErrMissingRequiredOption)
+	}
+
+	return nil
+}
+
+func newUDPServer(_ context.Context, cfg config.Config) (*udpServer, error) {
+	conf := udpServerConfig{}
+	if err := conf.Decode(cfg.Settings); err != nil {
+		return nil, fmt.Errorf("source udp: %v", err)
+	}
+
+	if err := conf.Validate(); err != nil {
+		return nil, fmt.Errorf("source udp: %v", err)
+	}
+
+	src := udpServer{
+		conf: conf,
+	}
+
+	return &src, nil
+}
+
+// udpServer reads each datagram as a message. This is compatible with syslog
+// over UDP (RFC 5426).
+type udpServer struct {
+	conf udpServerConfig
+}
+
+func (src *udpServer) Read(ctx context.Context, ch chan<- *message.Message) error {
+	conn, err := net.ListenPacket("udp", src.conf.Address)
+	if err != nil {
+		return fmt.Errorf("source udp: %v", err)
+	}
+
+	return servePacket(ctx, conn, ch)
+}
+
+// servePacket reads datagrams from the connection until the context is canceled.
+func servePacket(ctx context.Context, conn net.PacketConn, ch chan<- *message.Message) error {
+	defer conn.Close()
+
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	buf := make([]byte, udpMaxDatagramSize)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+
+			return fmt.Errorf("source udp: %v", err)
+		}
+
+		b := bytes.TrimRight(buf[:n], "\r\n")
+		if len(b) == 0 {
+			continue
+		}
+
+		metadata, _ := json.Marshal(netMetadata{RemoteAddr: addr.String()})
+		msg := message.New().SetData(bytes.Clone(b)).SetMetadata(metadata)
+
+		if err := send(ctx, ch, msg); err != nil {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package source
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/brexhq/substation/v2/message"
+)
+
+var _ Source = &udpServer{}
+
+func TestUDPServer(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ch := make(chan *message.Message)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- servePacket(ctx, conn, ch)
+	}()
+
+	client, err := net.Dial("udp", conn.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	expected := []string{"<13>hello", "<13>goodbye"}
+	for _, e := range expected {
+		if _, err := client.Write([]byte(e)); err != nil {
+			t.Fatal(err)
+		}
+
+		msg := <-ch
+		if string(msg.Data()) != e {
+			t.Errorf("expected %s, got %s", e, msg.Data())
+		}
+	}
+
+	cancel()
+	if err := <-errCh; err != nil {
+		t.Fatal(err)
+	}
+}
@@ -4,10 +4,19 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"runtime"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sync/errgroup"
 
 	"github.com/brexhq/substation/v2/config"
 	"github.com/brexhq/substation/v2/message"
+	"github.com/brexhq/substation/v2/source"
 	"github.com/brexhq/substation/v2/transform"
+
+	"github.com/brexhq/substation/v2/internal/tracing"
 )
 
 var errNoTransforms = fmt.Errorf("no transforms configured")
@@ -17,14 +26,41 @@ var errNoTransforms = fmt.Errorf("no transforms configured")
 type Config struct {
 	// Transforms contains a list of data transformatons that are executed.
 	Transforms []config.Config `json:"transforms"`
+	// DeadLetter is an optional policy for handling messages that cause a
+	// transform to fail. If this is not set, then any transform error stops
+	// processing of all messages.
+	DeadLetter *DeadLetterConfig `json:"dead_letter,omitempty"`
+}
+
+// DeadLetterConfig routes messages that cause a transform to fail to a
+// separate list of transforms instead of returning an error.
+//
+// Failed messages are annotated with the ID of the transform that failed and
+// the error in the metadata key "dead_letter" (e.g., "meta dead_letter.id",
+// "meta dead_letter.error"). The message is routed as it was when the transform
+// failed, which may include changes made by earlier transforms. Control
+// messages are sent to the dead-letter transforms after they pass through the
+// configured transforms, and errors caused by control messages are always
+// returned.
+type DeadLetterConfig struct {
+	// Transforms contains a list of data transformations that are executed
+	// on failed messages (e.g., send_aws_sqs, send_file).
+	Transforms []config.Config `json:"transforms"`
 }
 
 // Substation provides access to data transformation functions.
 type Substation struct {
 	cfg Config
 
-	factory transform.Factory
-	tforms  []transform.Transformer
+	factory     transform.Factory
+	tforms      []transform.Transformer
+	concurrency int
+	// dlq contains dead-letter transforms. If this is nil, then
+	// dead-letter routing is disabled.
+	dlq []transform.Transformer
+	// tracer creates spans for messages and transforms. If this is nil,
+	// then tracing is disabled.
+	tracer trace.Tracer
 }
 
 // New returns a new Substation instance.
@@ -42,6 +78,22 @@ func New(ctx context.Context, cfg Config, opts ...func(*Substation)) (*Substatio
 		o(sub)
 	}
 
+	if cfg.DeadLetter != nil {
+		if len(cfg.DeadLetter.Transforms) == 0 {
+			return nil, fmt.Errorf("dead_letter: %v", errNoTransforms)
+		}
+
+		sub.dlq = make([]transform.Transformer, 0, len(cfg.DeadLetter.Transforms))
+		for _, c := range cfg.DeadLetter.Transforms {
+			t, err := sub.factory(ctx, c)
+			if err != nil {
+				return nil, fmt.Errorf("dead_letter: %v", err)
+			}
+
+			sub.dlq = append(sub.dlq, t)
+		}
+	}
+
 	// Create transforms from the configuration.
 	for _, c := range cfg.Transforms {
 		t, err := sub.factory(ctx, c)
@@ -49,12 +101,30 @@ func New(ctx context.Context, cfg Config, opts ...func(*Substation)) (*Substatio
 			return nil, err
 		}
 
+		if sub.tracer != nil {
+			t = &traced{id: transformID(c), typ: c.Type, tf: t, tracer: sub.tracer}
+		}
+
+		if sub.dlq != nil {
+			t = &deadLetter{id: transformID(c), tf: t, dlq: sub.dlq}
+		}
+
 		sub.tforms = append(sub.tforms, t)
 	}
 
 	return sub, nil
 }
 
+// transformID returns the ID of a transform from its configuration. All
+// transforms use their type as the default ID.
+func transformID(cfg config.Config) string {
+	if id, ok := cfg.Settings["id"].(string); ok && id != "" {
+		return id
+	}
+
+	return cfg.Type
+}
+
 // WithTransformFactory implements a custom transform factory.
 func WithTransformFactory(fac transform.Factory) func(*Substation) {
 	return func(s *Substation) {
@@ -62,12 +132,269 @@ func WithTransformFactory(fac transform.Factory) func(*Substation) {
 	}
 }
 
+// WithConcurrency sets the maximum number of goroutines used when Transform
+// is called with multiple messages. Messages are pipelined through the
+// transforms and the order of the messages is kept for transforms that depend
+// on it. See transform.ApplyConcurrent for more information.
+//
+// This defaults to 1 (messages are transformed sequentially).
+func WithConcurrency(n int) func(*Substation) {
+	return func(s *Substation) {
+		s.concurrency = n
+	}
+}
+
+// WithTracerProvider enables OpenTelemetry tracing. Each message is traced
+// with a span that contains a child span for each configured transform, tagged
+// with the transform's ID and type.
+//
+// The trace context is carried in the message metadata ("meta traceparent",
+// "meta tracestate"). If a message already contains a trace context (e.g.,
+// from an upstream system), then its spans are added to that trace.
+func WithTracerProvider(tp trace.TracerProvider) func(*Substation) {
+	return func(s *Substation) {
+		s.tracer = tp.Tracer(tracing.Name)
+	}
+}
+
 // Transform runs the configured data transformation functions on the
 // provided messages.
 //
 // This is safe to use concurrently.
 func (s *Substation) Transform(ctx context.Context, msg ...*message.Message) ([]*message.Message, error) {
-	return transform.Apply(ctx, s.tforms, msg...)
+	if s.tracer != nil {
+		spans := s.startSpans(ctx, msg)
+		defer func() {
+			for _, span := range spans {
+				span.End()
+			}
+		}()
+	}
+
+	msgs, err := transform.ApplyConcurrent(ctx, s.tforms, s.concurrency, msg...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Control messages flush the dead-letter transforms after the configured
+	// transforms, which may have sent failed messages to them.
+	if s.dlq != nil {
+		for _, m := range msg {
+			if !m.IsControl() {
+				continue
+			}
+
+			if _, err := transform.Apply(ctx, s.dlq, m); err != nil {
+				return nil, fmt.Errorf("dead_letter: %v", err)
+			}
+		}
+	}
+
+	return msgs, nil
+}
+
+// startSpans starts a span for each message and adds the trace context to the
+// message metadata. Messages that already contain a trace context use it as
+// the parent span.
+func (s *Substation) startSpans(ctx context.Context, msgs []*message.Message) []trace.Span {
+	spans := make([]trace.Span, 0, len(msgs))
+	for _, m := range msgs {
+		name := "substation"
+		if m.IsControl() {
+			name = "substation ctrl"
+		}
+
+		spanCtx, span := s.tracer.Start(tracing.Extract(ctx, m), name)
+		tracing.Inject(spanCtx, m)
+
+		spans = append(spans, span)
+	}
+
+	return spans
+}
+
+// Run reads messages from the source and runs the configured data
+// transformation functions on them until the source is exhausted. Up to
+// concurrency messages are transformed at the same time; if concurrency is
+// less than 1, then it defaults to the number of CPUs.
+//
+// After all messages are transformed, a control message is sent through the
+// transforms to flush any buffered data. Sources can also send control
+// messages, which flush the pipeline after all messages that were sent before
+// them are transformed. If the source is a source.Checkpointer, then it is
+// checkpointed after each flush. Transformed messages are not returned to the
+// caller.
+//
+// If the context ends before the source is exhausted, then the context error
+// is returned because some messages may not have been transformed.
+func (s *Substation) Run(ctx context.Context, src source.Source, concurrency int) error {
+	if concurrency < 1 {
+		concurrency = runtime.NumCPU()
+	}
+
+	ch := make(chan *message.Message, concurrency)
+	group, groupCtx := errgroup.WithContext(ctx)
+
+	// Data transformation. Transforms are executed concurrently using a worker pool
+	// managed by an errgroup. Each message is processed in a separate goroutine.
+	group.Go(func() error {
+		tfGroup, tfCtx := errgroup.WithContext(groupCtx)
+		tfGroup.SetLimit(concurrency)
+
+		for msg := range ch {
+			// If a transform failed or the context ended, then the
+			// remaining messages are not transformed.
+			if tfCtx.Err() != nil {
+				break
+			}
+
+			if msg.IsControl() {
+				if err := tfGroup.Wait(); err != nil {
+					return err
+				}
+
+				if err := s.flush(groupCtx, src, msg); err != nil {
+					return err
+				}
+
+				// The context of a group is canceled when Wait returns,
+				// so a new group is used for the next messages.
+				tfGroup, tfCtx = errgroup.WithContext(groupCtx)
+				tfGroup.SetLimit(concurrency)
+
+				continue
+			}
+
+			tfGroup.Go(func() error {
+				if _, err := s.Transform(tfCtx, msg); err != nil {
+					return err
+				}
+
+				return nil
+			})
+		}
+
+		if err := tfGroup.Wait(); err != nil {
+			return err
+		}
+
+		// Messages were dropped if the context ended, so the pipeline is
+		// not flushed.
+		if err := groupCtx.Err(); err != nil {
+			return err
+		}
+
+		// CTRL messages flush the pipeline. This must be done
+		// after all messages have been processed.
+		return s.flush(groupCtx, src, message.New().AsControl())
+	})
+
+	// Data ingest.
+	group.Go(func() error {
+		defer close(ch)
+
+		if err := src.Read(groupCtx, ch); err != nil {
+			return err
+		}
+
+		// Sources return nil when the context ends, so the context is
+		// checked to find out if the source was exhausted.
+		return ctx.Err()
+	})
+
+	// Wait for all goroutines to complete. This includes the goroutines that are
+	// executing the transform functions.
+	err := group.Wait()
+
+	if cp, ok := src.(source.Checkpointer); ok {
+		if cErr := cp.Close(); err == nil {
+			err = cErr
+		}
+	}
+
+	return err
+}
+
+// flush sends a control message through the transforms and checkpoints the
+// source, if it supports checkpoints.
+func (s *Substation) flush(ctx context.Context, src source.Source, ctrl *message.Message) error {
+	if _, err := s.Transform(ctx, ctrl); err != nil {
+		return err
+	}
+
+	if cp, ok := src.(source.Checkpointer); ok {
+		return cp.Checkpoint(ctx)
+	}
+
+	return nil
+}
+
+// deadLetter wraps a transform and sends data messages that cause the
+// transform to fail to the dead-letter transforms.
+type deadLetter struct {
+	id  string
+	tf  transform.Transformer
+	dlq []transform.Transformer
+}
+
+// deadLetterMetadata is added to the metadata of failed messages.
+type deadLetterMetadata struct {
+	ID    string `json:"id"`
+	Error string `json:"error"`
+}
+
+func (d *deadLetter) Transform(ctx context.Context, msg *message.Message) ([]*message.Message, error) {
+	msgs, err := d.tf.Transform(ctx, msg)
+	if err == nil || msg.IsControl() {
+		return msgs, err
+	}
+
+	if err := msg.SetValue("meta dead_letter", deadLetterMetadata{
+		ID:    d.id,
+		Error: err.Error(),
+	}); err != nil {
+		return nil, fmt.Errorf("dead_letter: %v", err)
+	}
+
+	if _, err := transform.Apply(ctx, d.dlq, msg); err != nil {
+		return nil, fmt.Errorf("dead_letter: %v", err)
+	}
+
+	return nil, nil
+}
+
+// Unwrap returns the wrapped transform.
+func (d *deadLetter) Unwrap() transform.Transformer {
+	return d.tf
+}
+
+// traced wraps a transform and creates a span for each call to Transform.
+type traced struct {
+	id     string
+	typ    string
+	tf     transform.Transformer
+	tracer trace.Tracer
+}
+
+func (t *traced) Transform(ctx context.Context, msg *message.Message) ([]*message.Message, error) {
+	ctx, span := t.tracer.Start(tracing.Extract(ctx, msg), t.id, trace.WithAttributes(
+		attribute.String("substation.transform.id", t.id),
+		attribute.String("substation.transform.type", t.typ),
+	))
+	defer span.End()
+
+	msgs, err := t.tf.Transform(ctx, msg)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	return msgs, err
+}
+
+// Unwrap returns the wrapped transform.
+func (t *traced) Unwrap() transform.Transformer {
+	return t.tf
 }
 
 // String returns a JSON representation of the configuration.
@@ -22,6 +22,7 @@ local helpers = {
     all(i): $.condition.meta.all({ conditions: helpers.make_array(i) }),
     any(i): $.condition.meta.any({ conditions: helpers.make_array(i) }),
     none(i): $.condition.meta.none({ conditions: helpers.make_array(i) }),
+    expr(i): $.condition.meta.expression({ expression: i }),
     meta: {
       all(settings={}): {
         local default = {
@@ -41,6 +42,15 @@ local helpers = {
         type: 'meta_any',
         settings: std.prune(std.mergePatch(default, helpers.abbv(settings))),
       },
+      expr(settings={}): $.condition.meta.expression(settings=settings),
+      expression(settings={}): {
+        local default = {
+          expression: null,
+        },
+
+        type: 'meta_expression',
+        settings: std.prune(std.mergePatch(default, helpers.abbv(settings))),
+      },
       none(settings={}): {
         local default = {
           object: $.config.object,
@@ -50,12 +60,31 @@ local helpers = {
         type: 'meta_none',
         settings: std.prune(std.mergePatch(default, helpers.abbv(settings))),
       },
+      sigma(settings={}): {
+        local default = {
+          rule: null,
+          field_mappings: null,
+        },
+
+        type: 'meta_sigma',
+        settings: std.prune(std.mergePatch(default, helpers.abbv(settings))),
+      },
     },
     fmt: $.condition.format,
     format: {
       json(settings={}): {
         type: 'format_json',
       },
+      json_schema(settings={}): {
+        local default = {
+          object: $.config.object,
+          schema: null,
+          metadata_key: null,
+        },
+
+        type: 'format_json_schema',
+        settings: std.prune(std.mergePatch(default, helpers.abbv(settings))),
+      },
       mime(settings={}): {
         local default = {
           object: $.config.object,
@@ -172,6 +201,12 @@ local helpers = {
           type: 'network_ip_global_unicast',
           settings: std.prune(std.mergePatch(default, helpers.abbv(settings))),
         },
+        in_cidr(settings={}): {
+          local default = $.condition.network.ip.default { cidrs: null, cidr_files: null },
+
+          type: 'network_ip_in_cidr',
+          settings: std.prune(std.mergePatch(default, helpers.abbv(settings))),
+        },
         link_local_multicast(settings={}): {
           local default = $.condition.network.ip.default,
 
@@ -338,6 +373,54 @@ local helpers = {
             separator: null,
           },
 
+          type: type,
+          settings: std.prune(std.mergePatch(default, helpers.abbv(settings))),
+        },
+      },
+      window: {
+        default: {
+          object: $.config.object,
+          window: { duration: '1m', slide: null },
+        },
+        count(settings={}): {
+          local type = 'aggregate_window_count',
+          local default = $.transform.aggregate.window.default { id: helpers.id(type, settings) },
+
+          type: type,
+          settings: std.prune(std.mergePatch(default, helpers.abbv(settings))),
+        },
+        distinct_count(settings={}): {
+          local type = 'aggregate_window_distinct_count',
+          local default = $.transform.aggregate.window.default { id: helpers.id(type, settings) },
+
+          type: type,
+          settings: std.prune(std.mergePatch(default, helpers.abbv(settings))),
+        },
+        max(settings={}): {
+          local type = 'aggregate_window_max',
+          local default = $.transform.aggregate.window.default { id: helpers.id(type, settings) },
+
+          type: type,
+          settings: std.prune(std.mergePatch(default, helpers.abbv(settings))),
+        },
+        min(settings={}): {
+          local type = 'aggregate_window_min',
+          local default = $.transform.aggregate.window.default { id: helpers.id(type, settings) },
+
+          type: type,
+          settings: std.prune(std.mergePatch(default, helpers.abbv(settings))),
+        },
+        percentile(settings={}): {
+          local type = 'aggregate_window_percentile',
+          local default = $.transform.aggregate.window.default { id: helpers.id(type, settings), percentiles: null },
+
+          type: type,
+          settings: std.prune(std.mergePatch(default, helpers.abbv(settings))),
+        },
+        sum(settings={}): {
+          local type = 'aggregate_window_sum',
+          local default = $.transform.aggregate.window.default { id: helpers.id(type, settings) },
+
           type: type,
           settings: std.prune(std.mergePatch(default, helpers.abbv(settings))),
         },
@@ -367,6 +450,31 @@ local helpers = {
         settings: std.prune(std.mergePatch(default, helpers.abbv(settings))),
       },
     },
+    crypto: {
+      default: {
+        object: $.config.object,
+        aws: $.config.aws,
+        key: null,
+        key_file: null,
+      },
+      decrypt(settings={}): {
+        local type = 'crypto_decrypt',
+        local default = $.transform.crypto.default { id: helpers.id(type, settings) },
+
+        type: type,
+        settings: std.prune(std.mergePatch(default, helpers.abbv(settings))),
+      },
+      encrypt(settings={}): {
+        local type = 'crypto_encrypt',
+        local default = $.transform.crypto.default {
+          id: helpers.id(type, settings),
+          data_key: { count: null, duration: null },
+        },
+
+        type: type,
+        settings: std.prune(std.mergePatch(default, helpers.abbv(settings))),
+      },
+    },
     enrich: {
       aws: {
         dynamodb: {
@@ -489,6 +597,17 @@ local helpers = {
         object: $.config.object,
       },
       from: {
+        avro(settings={}): {
+          local type = 'format_from_avro',
+          local default = {
+            id: helpers.id(type, settings),
+            schema: null,
+            format: null,
+          },
+
+          type: type,
+          settings: std.prune(std.mergePatch(default, helpers.abbv(settings))),
+        },
         b64(settings={}): $.transform.format.from.base64(settings=settings),
         base64(settings={}): {
           local type = 'format_from_base64',
@@ -497,6 +616,20 @@ local helpers = {
           type: type,
           settings: std.prune(std.mergePatch(default, helpers.abbv(settings))),
         },
+        csv(settings={}): {
+          local type = 'format_from_csv',
+          local default = {
+            id: helpers.id(type, settings),
+            object: $.config.object,
+            columns: null,
+            header: false,
+            delimiter: null,
+            lazy_quotes: false,
+          },
+
+          type: type,
+          settings: std.prune(std.mergePatch(default, helpers.abbv(settings))),
+        },
         gz(settings={}): $.transform.format.from.gzip(settings=settings),
         gzip(settings={}): {
           local type = 'format_from_gzip',
@@ -505,6 +638,27 @@ local helpers = {
           type: type,
           settings: std.prune(std.mergePatch(default, helpers.abbv(settings))),
         },
+        kv(settings={}): {
+          local type = 'format_from_kv',
+          local default = {
+            id: helpers.id(type, settings),
+            object: $.config.object,
+            pair_separator: null,
+            field_separator: null,
+            quote: null,
+            unquoted_separators: false,
+          },
+
+          type: type,
+          settings: std.prune(std.mergePatch(default, helpers.abbv(settings))),
+        },
+        parquet(settings={}): {
+          local type = 'format_from_parquet',
+          local default = { id: helpers.id(type, settings) },
+
+          type: type,
+          settings: std.prune(std.mergePatch(default, helpers.abbv(settings))),
+        },
         pretty_print(settings={}): {
           local type = 'format_from_pretty_print',
           local default = { id: helpers.id(type, settings) },
@@ -512,6 +666,29 @@ local helpers = {
           type: type,
           settings: std.prune(std.mergePatch(default, helpers.abbv(settings))),
         },
+        protobuf(settings={}): {
+          local type = 'format_from_protobuf',
+          local default = {
+            id: helpers.id(type, settings),
+            schema: null,
+            message: null,
+            delimited: false,
+          },
+
+          type: type,
+          settings: std.prune(std.mergePatch(default, helpers.abbv(settings))),
+        },
+        syslog(settings={}): {
+          local type = 'format_from_syslog',
+          local default = {
+            id: helpers.id(type, settings),
+            object: $.config.object,
+            location: null,
+          },
+
+          type: type,
+          settings: std.prune(std.mergePatch(default, helpers.abbv(settings))),
+        },
         zip(settings={}): {
           local type = 'format_from_zip',
           local default = { id: helpers.id(type, settings) },
@@ -521,6 +698,19 @@ local helpers = {
         },
       },
       to: {
+        avro(settings={}): {
+          local type = 'format_to_avro',
+          local default = {
+            id: helpers.id(type, settings),
+            schema: null,
+            format: null,
+            schema_id: null,
+            compression: null,
+          },
+
+          type: type,
+          settings: std.prune(std.mergePatch(default, helpers.abbv(settings))),
+        },
         b64(settings={}): $.transform.format.to.base64(settings=settings),
         base64(settings={}): {
           local type = 'format_to_base64',
@@ -529,11 +719,49 @@ local helpers = {
           type: type,
           settings: std.prune(std.mergePatch(default, helpers.abbv(settings))),
         },
+        csv(settings={}): {
+          local type = 'format_to_csv',
+          local default = {
+            id: helpers.id(type, settings),
+            object: $.config.object,
+            batch: $.config.batch,
+            columns: null,
+            header: false,
+            delimiter: null,
+          },
+
+          type: type,
+          settings: std.prune(std.mergePatch(default, helpers.abbv(settings))),
+        },
         gz(settings={}): $.transform.format.to.gzip(settings=settings),
         gzip(settings={}): {
           local type = 'format_to_gzip',
           local default = { id: helpers.id(type, settings) },
 
+          type: type,
+          settings: std.prune(std.mergePatch(default, helpers.abbv(settings))),
+        },
+        parquet(settings={}): {
+          local type = 'format_to_parquet',
+          local default = {
+            id: helpers.id(type, settings),
+            schema: null,
+            compression: null,
+            batch: $.config.batch,
+          },
+
+          type: type,
+          settings: std.prune(std.mergePatch(default, helpers.abbv(settings))),
+        },
+        protobuf(settings={}): {
+          local type = 'format_to_protobuf',
+          local default = {
+            id: helpers.id(type, settings),
+            schema: null,
+            message: null,
+            delimited: false,
+          },
+
           type: type,
           settings: std.prune(std.mergePatch(default, helpers.abbv(settings))),
         },
@@ -543,6 +771,45 @@ local helpers = {
       default: {
         object: $.config.object,
       },
+      blake2b(settings={}): {
+        local type = 'hash_blake2b',
+        local default = $.transform.hash.default { id: helpers.id(type, settings) },
+
+        type: type,
+        settings: std.prune(std.mergePatch(default, helpers.abbv(settings))),
+      },
+      blake3(settings={}): {
+        local type = 'hash_blake3',
+        local default = $.transform.hash.default { id: helpers.id(type, settings) },
+
+        type: type,
+        settings: std.prune(std.mergePatch(default, helpers.abbv(settings))),
+      },
+      crc32(settings={}): {
+        local type = 'hash_crc32',
+        local default = $.transform.hash.default { id: helpers.id(type, settings) },
+
+        type: type,
+        settings: std.prune(std.mergePatch(default, helpers.abbv(settings))),
+      },
+      fnv(settings={}): {
+        local type = 'hash_fnv',
+        local default = $.transform.hash.default { id: helpers.id(type, settings) },
+
+        type: type,
+        settings: std.prune(std.mergePatch(default, helpers.abbv(settings))),
+      },
+      hmac(settings={}): {
+        local type = 'hash_hmac',
+        local default = $.transform.hash.default {
+          id: helpers.id(type, settings),
+          key: null,
+          algorithm: 'sha256',
+        },
+
+        type: type,
+        settings: std.prune(std.mergePatch(default, helpers.abbv(settings))),
+      },
       md5(settings={}): {
         local type = 'hash_md5',
         local default = $.transform.hash.default { id: helpers.id(type, settings) },
@@ -550,10 +817,45 @@ local helpers = {
         type: type,
         settings: std.prune(std.mergePatch(default, helpers.abbv(settings))),
       },
+      sha1(settings={}): {
+        local type = 'hash_sha1',
+        local default = $.transform.hash.default { id: helpers.id(type, settings) },
+
+        type: type,
+        settings: std.prune(std.mergePatch(default, helpers.abbv(settings))),
+      },
       sha256(settings={}): {
         local type = 'hash_sha256',
         local default = $.transform.hash.default { id: helpers.id(type, settings) },
 
+        type: type,
+        settings: std.prune(std.mergePatch(default, helpers.abbv(settings))),
+      },
+      sha512(settings={}): {
+        local type = 'hash_sha512',
+        local default = $.transform.hash.default { id: helpers.id(type, settings) },
+
+        type: type,
+        settings: std.prune(std.mergePatch(default, helpers.abbv(settings))),
+      },
+      ssdeep(settings={}): {
+        local type = 'hash_ssdeep',
+        local default = $.transform.hash.default { id: helpers.id(type, settings) },
+
+        type: type,
+        settings: std.prune(std.mergePatch(default, helpers.abbv(settings))),
+      },
+      tlsh(settings={}): {
+        local type = 'hash_tlsh',
+        local default = $.transform.hash.default { id: helpers.id(type, settings) },
+
+        type: type,
+        settings: std.prune(std.mergePatch(default, helpers.abbv(settings))),
+      },
+      xxhash(settings={}): {
+        local type = 'hash_xxhash',
+        local default = $.transform.hash.default { id: helpers.id(type, settings) },
+
         type: type,
         settings: std.prune(std.mergePatch(default, helpers.abbv(settings))),
       },
@@ -694,6 +996,22 @@ local helpers = {
           cases: null,
         },
 
+        type: type,
+        settings: std.prune(std.mergePatch(default, helpers.abbv(settings))),
+      },
+      threshold(settings={}): {
+        local type = 'meta_threshold',
+        local default = {
+          id: helpers.id(type, settings),
+          object: $.config.object,
+          condition: null,
+          threshold: null,
+          duration: '1m',
+          cooldown: null,
+          prefix: null,
+          kv_store: null,
+        },
+
         type: type,
         settings: std.prune(std.mergePatch(default, helpers.abbv(settings))),
       },
@@ -808,6 +1126,30 @@ local helpers = {
         },
       },
     },
+    schema: {
+      default: {
+        object: $.config.object,
+        source: null,
+        mapping: null,
+        unmapped: false,
+      },
+      to: {
+        ecs(settings={}): {
+          local type = 'schema_to_ecs',
+          local default = $.transform.schema.default { id: helpers.id(type, settings) },
+
+          type: type,
+          settings: std.prune(std.mergePatch(default, helpers.abbv(settings))),
+        },
+        ocsf(settings={}): {
+          local type = 'schema_to_ocsf',
+          local default = $.transform.schema.default { id: helpers.id(type, settings) },
+
+          type: type,
+          settings: std.prune(std.mergePatch(default, helpers.abbv(settings))),
+        },
+      },
+    },
     send: {
       aws: {
         dynamodb: {
@@ -988,6 +1330,25 @@ local helpers = {
           settings: std.prune(std.mergePatch(default, helpers.abbv(s))),
         },
       },
+      kafka(settings={}): {
+        local type = 'send_kafka',
+        local default = {
+          id: helpers.id(type, settings),
+          batch: $.config.batch,
+          auxiliary_transforms: null,
+          brokers: null,
+          topic: null,
+          use_batch_key_as_partition_key: false,
+        },
+
+        local s = std.mergePatch(settings, {
+          auxiliary_transforms: if std.objectHas(settings, 'auxiliary_transforms') then settings.auxiliary_transforms else if std.objectHas(settings, 'aux_tforms') then settings.aux_tforms else null,
+          aux_tforms: null,
+        }),
+
+        type: type,
+        settings: std.prune(std.mergePatch(default, helpers.abbv(s))),
+      },
       stdout(settings={}): {
         local type = 'send_stdout',
         local default = {
@@ -1030,6 +1391,33 @@ local helpers = {
         type: type,
         settings: std.prune(std.mergePatch(default, helpers.abbv(settings))),
       },
+      grok(settings={}): {
+        local type = 'string_grok',
+        local default = {
+          id: helpers.id(type, settings),
+          object: $.config.object,
+          patterns: null,
+          pattern_definitions: null,
+          pattern_files: null,
+        },
+
+        type: type,
+        settings: std.prune(std.mergePatch(default, helpers.abbv(settings))),
+      },
+      redact(settings={}): {
+        local type = 'string_redact',
+        local default = {
+          id: helpers.id(type, settings),
+          object: $.config.object,
+          detectors: null,
+          action: 'mask',
+          mask: '*',
+          key: null,
+        },
+
+        type: type,
+        settings: std.prune(std.mergePatch(default, helpers.abbv(settings))),
+      },
       repl: $.transform.string.replace,
       replace(settings={}): {
         local type = 'string_replace',
@@ -1123,6 +1511,7 @@ local helpers = {
             id: helpers.id(type, settings),
             object: $.config.object,
             format: null,
+            formats: null,
             location: 'UTC',
           },
 
@@ -1218,6 +1607,20 @@ local helpers = {
         type: type,
         settings: std.prune(std.mergePatch(default, helpers.abbv(settings))),
       },
+      dedupe(settings={}): {
+        local type = 'utility_dedupe',
+        local default = {
+          id: helpers.id(type, settings),
+          object: $.config.object,
+          keys: null,
+          prefix: null,
+          ttl_offset: null,
+          kv_store: null,
+        },
+
+        type: type,
+        settings: std.prune(std.mergePatch(default, helpers.abbv(settings))),
+      },
       drop(settings={}): {
         local type = 'utility_drop',
         local default = {
@@ -1271,6 +1674,20 @@ local helpers = {
           settings: std.prune(std.mergePatch(default, helpers.abbv(settings))),
         },
       },
+      rate_limit(settings={}): {
+        local type = 'utility_rate_limit',
+        local default = {
+          id: helpers.id(type, settings),
+          object: $.config.object,
+          rate: null,
+          burst: null,
+          sample_rate: null,
+          action: 'drop',
+        },
+
+        type: type,
+        settings: std.prune(std.mergePatch(default, helpers.abbv(settings))),
+      },
       secret(settings={}): {
         local type = 'utility_secret',
         local default = {
@@ -4,11 +4,18 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 
 	"github.com/brexhq/substation/v2"
 	"github.com/brexhq/substation/v2/config"
 	"github.com/brexhq/substation/v2/message"
+	"github.com/brexhq/substation/v2/source"
 	"github.com/brexhq/substation/v2/transform"
 )
 
@@ -64,6 +71,302 @@ func ExampleSubstation() {
 	// {"a":"b","c":"b"}
 }
 
+// Sources read messages from external systems. This source sends a
+// fixed list of messages.
+type sliceSource struct {
+	data [][]byte
+}
+
+func (s *sliceSource) Read(ctx context.Context, ch chan<- *message.Message) error {
+	for _, d := range s.data {
+		select {
+		case <-ctx.Done():
+			return nil
+		case ch <- message.New().SetData(d):
+		}
+	}
+
+	return nil
+}
+
+func Example_substationRun() {
+	ctx := context.Background()
+
+	// This example batches objects into an array and prints the array to stdout.
+	// The array is only printed after the source is exhausted, when Run sends a
+	// ctrl message that flushes the pipeline.
+	conf := []byte(`
+		{
+			"transforms":[
+				{"type":"aggregate_to_array","settings":{"object":{"target_key":"a"}}},
+				{"type":"send_stdout"}
+			]
+		}
+	`)
+
+	cfg := substation.Config{}
+	if err := json.Unmarshal(conf, &cfg); err != nil {
+		// Handle error.
+		panic(err)
+	}
+
+	sub, err := substation.New(ctx, cfg)
+	if err != nil {
+		// Handle error.
+		panic(err)
+	}
+
+	// Any Source can be used, including those from the source package (for
+	// example, source.New or source.FromAWSSQSEvent).
+	var src source.Source = &sliceSource{
+		data: [][]byte{[]byte(`{"b":1}`), []byte(`{"b":2}`)},
+	}
+
+	// A concurrency of 1 preserves the order of the messages.
+	if err := sub.Run(ctx, src, 1); err != nil {
+		// Handle error.
+		panic(err)
+	}
+
+	// Output:
+	// {"a":[{"b":1},{"b":2}]}
+}
+
+// stopSource stops reading when its own context is canceled, like the run
+// command does when the process is interrupted.
+type stopSource struct {
+	source.Source
+}
+
+func (s *stopSource) Read(ctx context.Context, ch chan<- *message.Message) error {
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+
+	return s.Source.Read(ctx, ch)
+}
+
+func TestSubstationRunCanceled(t *testing.T) {
+	sub, err := substation.New(context.TODO(), substation.Config{
+		Transforms: []config.Config{
+			{Type: "utility_delay", Settings: map[string]interface{}{"duration": "10ms"}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := make([][]byte, 100)
+	for i := range data {
+		data[i] = []byte(`{"a":"b"}`)
+	}
+
+	// The context ends before the source is exhausted.
+	ctx, cancel := context.WithTimeout(context.TODO(), 50*time.Millisecond)
+	defer cancel()
+
+	if err := sub.Run(ctx, &sliceSource{data: data}, 1); err == nil {
+		t.Error("expected error")
+	}
+
+	// The source stops, but the context does not end.
+	if err := sub.Run(context.TODO(), &stopSource{&sliceSource{data: data}}, 1); err != nil {
+		t.Error(err)
+	}
+}
+
+// This source replaces the third message with a ctrl message and prints each
+// checkpoint.
+type checkpointSource struct {
+	sliceSource
+}
+
+func (s *checkpointSource) Read(ctx context.Context, ch chan<- *message.Message) error {
+	for i, d := range s.data {
+		msg := message.New().SetData(d)
+		if i == 2 {
+			msg = message.New().AsControl()
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case ch <- msg:
+		}
+	}
+
+	return nil
+}
+
+func (s *checkpointSource) Checkpoint(ctx context.Context) error {
+	fmt.Println("checkpoint")
+	return nil
+}
+
+func (s *checkpointSource) Close() error {
+	fmt.Println("close")
+	return nil
+}
+
+func Example_substationRunCheckpoint() {
+	ctx := context.Background()
+
+	conf := []byte(`
+		{
+			"transforms":[
+				{"type":"aggregate_to_array","settings":{"object":{"target_key":"a"}}},
+				{"type":"send_stdout"}
+			]
+		}
+	`)
+
+	cfg := substation.Config{}
+	if err := json.Unmarshal(conf, &cfg); err != nil {
+		// Handle error.
+		panic(err)
+	}
+
+	sub, err := substation.New(ctx, cfg)
+	if err != nil {
+		// Handle error.
+		panic(err)
+	}
+
+	// Sources that implement source.Checkpointer are checkpointed after each
+	// ctrl message flushes the pipeline. All messages that were sent before
+	// the ctrl message are transformed before the flush, even if they are
+	// transformed concurrently.
+	var src source.Source = &checkpointSource{
+		sliceSource{data: [][]byte{[]byte(`{"b":1}`), []byte(`{"b":2}`), nil, []byte(`{"b":3}`)}},
+	}
+
+	if err := sub.Run(ctx, src, 1); err != nil {
+		// Handle error.
+		panic(err)
+	}
+
+	// Output:
+	// {"a":[{"b":1},{"b":2}]}
+	// checkpoint
+	// {"a":[{"b":3}]}
+	// checkpoint
+	// close
+}
+
+func Example_substationDeadLetter() {
+	ctx := context.Background()
+
+	// This example decodes base64 data and prints the result to stdout. Messages
+	// that cannot be decoded are sent to the dead-letter transforms, which print
+	// the transform ID and error to stdout instead of stopping the program.
+	conf := []byte(`
+		{
+			"transforms":[
+				{"type":"format_from_base64","settings":{"id":"decode"}},
+				{"type":"send_stdout"}
+			],
+			"dead_letter":{
+				"transforms":[
+					{"type":"object_copy","settings":{"object":{"source_key":"meta dead_letter.id"}}},
+					{"type":"send_stdout"}
+				]
+			}
+		}
+	`)
+
+	cfg := substation.Config{}
+	if err := json.Unmarshal(conf, &cfg); err != nil {
+		// Handle error.
+		panic(err)
+	}
+
+	sub, err := substation.New(ctx, cfg)
+	if err != nil {
+		// Handle error.
+		panic(err)
+	}
+
+	msg := []*message.Message{
+		message.New().SetData([]byte(`eyJhIjoiYiJ9`)),
+		message.New().SetData([]byte(`!!!`)),
+		message.New().SetData([]byte(`eyJjIjoiZCJ9`)),
+		message.New().AsControl(),
+	}
+
+	if _, err := sub.Transform(ctx, msg...); err != nil {
+		// Handle error.
+		panic(err)
+	}
+
+	// Data is printed when the ctrl message flushes each list of transforms.
+	//
+	// Output:
+	// {"a":"b"}
+	// {"c":"d"}
+	// decode
+}
+
+func TestSubstationTracing(t *testing.T) {
+	ctx := context.Background()
+	cfg := substation.Config{
+		Transforms: []config.Config{
+			{Type: "object_copy", Settings: map[string]interface{}{"id": "copy", "object": map[string]interface{}{"source_key": "a", "target_key": "b"}}},
+			{Type: "utility_err", Settings: map[string]interface{}{"id": "err"}},
+		},
+		DeadLetter: &substation.DeadLetterConfig{
+			Transforms: []config.Config{{Type: "utility_drop"}},
+		},
+	}
+
+	rec := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+
+	sub, err := substation.New(ctx, cfg, substation.WithTracerProvider(tp))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The message continues a trace from an upstream system.
+	traceID := "4bf92f3577b34da6a3ce929d0e0e4736"
+	msg := message.New().SetData([]byte(`{"a":"b"}`)).
+		SetMetadata([]byte(`{"traceparent":"00-` + traceID + `-00f067aa0ba902b7-01"}`))
+
+	if _, err := sub.Transform(ctx, msg); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := rec.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("expected 3 spans, got %d", len(spans))
+	}
+
+	root := spans[len(spans)-1]
+	for _, span := range spans {
+		if span.SpanContext().TraceID().String() != traceID {
+			t.Errorf("expected trace %s, got %s", traceID, span.SpanContext().TraceID())
+		}
+	}
+
+	expected := []string{"copy", "err"}
+	for i, span := range spans[:2] {
+		if span.Name() != expected[i] {
+			t.Errorf("expected span %s, got %s", expected[i], span.Name())
+		}
+
+		if span.Parent().SpanID() != root.SpanContext().SpanID() {
+			t.Errorf("expected parent %s, got %s", root.SpanContext().SpanID(), span.Parent().SpanID())
+		}
+	}
+
+	if spans[1].Status().Code != codes.Error {
+		t.Error("expected error status on span err")
+	}
+
+	// The trace context of the message is updated to the root span.
+	if !strings.Contains(msg.GetValue("meta traceparent").String(), root.SpanContext().SpanID().String()) {
+		t.Errorf("expected traceparent to contain %s, got %s", root.SpanContext().SpanID(), msg.GetValue("meta traceparent"))
+	}
+}
+
 // Custom applications should embed the Substation configuration and
 // add additional configuration options.
 type customConfig struct {
@@ -2,8 +2,15 @@ package transform
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
+	"math"
 	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/brexhq/substation/v2/message"
 
 	iconfig "github.com/brexhq/substation/v2/internal/config"
 )
@@ -58,3 +65,386 @@ func aggToStr(data [][]byte, separator []byte) []byte {
 func aggFromStr(data []byte, separator []byte) [][]byte {
 	return bytes.Split(data, separator)
 }
+
+type aggregateWindowWindowConfig struct {
+	// Duration is the length of each window (e.g., "5m"). Windows are aligned
+	// to multiples of the duration since the Unix epoch, so a "1h" window
+	// begins at the start of each hour.
+	//
+	// This is optional and defaults to "1m".
+	Duration string `json:"duration"`
+	// Slide is the interval that windows advance by. If this is less than
+	// Duration, then windows overlap (sliding windows) and a summary is
+	// emitted for each key at every interval. The interval must evenly divide
+	// Duration.
+	//
+	// This is optional and defaults to Duration (tumbling windows).
+	Slide string `json:"slide"`
+}
+
+func (c *aggregateWindowWindowConfig) Validate() error {
+	_, _, err := c.durations()
+	return err
+}
+
+// durations returns the size and slide of the window.
+func (c *aggregateWindowWindowConfig) durations() (time.Duration, time.Duration, error) {
+	dur := c.Duration
+	if dur == "" {
+		dur = "1m"
+	}
+
+	size, err := time.ParseDuration(dur)
+	if err != nil {
+		return 0, 0, fmt.Errorf("window.duration: %v", err)
+	}
+
+	if size <= 0 {
+		return 0, 0, fmt.Errorf("window.duration %s: %v", dur, iconfig.ErrInvalidOption)
+	}
+
+	slide := size
+	if c.Slide != "" {
+		if slide, err = time.ParseDuration(c.Slide); err != nil {
+			return 0, 0, fmt.Errorf("window.slide: %v", err)
+		}
+	}
+
+	if slide <= 0 || slide > size || size%slide != 0 {
+		return 0, 0, fmt.Errorf("window.slide %s: %v", c.Slide, iconfig.ErrInvalidOption)
+	}
+
+	return size, slide, nil
+}
+
+type aggregateWindowConfig struct {
+	Window aggregateWindowWindowConfig `json:"window"`
+
+	ID     string         `json:"id"`
+	Object iconfig.Object `json:"object"`
+}
+
+func (c *aggregateWindowConfig) Decode(in interface{}) error {
+	return iconfig.Decode(in, c)
+}
+
+func (c *aggregateWindowConfig) Validate() error {
+	if c.Object.SourceKey == "" {
+		return fmt.Errorf("object_source_key: %v", iconfig.ErrMissingRequiredOption)
+	}
+
+	return c.Window.Validate()
+}
+
+// aggWindowState is the state of a key in a single interval of a window.
+// States are merged to create the summary of a window.
+type aggWindowState struct {
+	count    int
+	sum      float64
+	min      float64
+	max      float64
+	distinct map[string]struct{}
+	values   []float64
+}
+
+func (s *aggWindowState) addNumber(f float64) {
+	if s.count == 0 || f < s.min {
+		s.min = f
+	}
+
+	if s.count == 0 || f > s.max {
+		s.max = f
+	}
+
+	s.count++
+	s.sum += f
+}
+
+func (s *aggWindowState) addString(str string) {
+	if s.distinct == nil {
+		s.distinct = make(map[string]struct{})
+	}
+
+	s.count++
+	s.distinct[str] = struct{}{}
+}
+
+func (s *aggWindowState) merge(other *aggWindowState) {
+	if other.count == 0 {
+		return
+	}
+
+	if s.count == 0 || other.min < s.min {
+		s.min = other.min
+	}
+
+	if s.count == 0 || other.max > s.max {
+		s.max = other.max
+	}
+
+	s.count += other.count
+	s.sum += other.sum
+	s.values = append(s.values, other.values...)
+
+	for k := range other.distinct {
+		if s.distinct == nil {
+			s.distinct = make(map[string]struct{})
+		}
+
+		s.distinct[k] = struct{}{}
+	}
+}
+
+// aggWindowSummary is the data of messages that are emitted when a window
+// closes. Type is the type of the transform that created the summary and Key
+// is the batch key, which is empty if the batch key is not set. Start and End
+// are Unix nanoseconds.
+type aggWindowSummary struct {
+	Type  string      `json:"type"`
+	Key   string      `json:"key"`
+	Start int64       `json:"start"`
+	End   int64       `json:"end"`
+	Value interface{} `json:"value"`
+
+	state *aggWindowState
+}
+
+// aggWindow groups values by key into tumbling or sliding windows. Each key
+// stores one state per slide interval (bucket), and the buckets that are
+// inside a window are merged when the window closes.
+//
+// Windows use the time when messages are received, and are closed when a
+// message is received after the end of the window or when a control message
+// is received.
+type aggWindow struct {
+	// typ is the type of the transform, which is added to each summary.
+	typ   string
+	size  time.Duration
+	slide time.Duration
+
+	mu  sync.Mutex
+	now func() time.Time
+	// end is the end of the current bucket. All keys share the same buckets.
+	end  time.Time
+	keys map[string]map[int64]*aggWindowState
+}
+
+func newAggWindow(typ string, conf aggregateWindowWindowConfig) (*aggWindow, error) {
+	size, slide, err := conf.durations()
+	if err != nil {
+		return nil, err
+	}
+
+	return &aggWindow{
+		typ:   typ,
+		size:  size,
+		slide: slide,
+		now:   time.Now,
+		keys:  make(map[string]map[int64]*aggWindowState),
+	}, nil
+}
+
+// Apply adds the message to the window and returns the summaries of windows
+// that closed, followed by the message. If the message is a control message,
+// then all windows are closed.
+func (w *aggWindow) Apply(msg *message.Message, obj iconfig.Object, add func(*aggWindowState, message.Value), summarize func(*aggWindowState) interface{}) ([]*message.Message, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := w.now()
+
+	var summaries []aggWindowSummary
+	if msg.IsControl() {
+		summaries = w.advance(now)
+		summaries = append(summaries, w.flush()...)
+	} else {
+		summaries = w.advance(now)
+
+		key := msg.GetValue(obj.BatchKey).String()
+		if _, ok := w.keys[key]; !ok {
+			w.keys[key] = make(map[int64]*aggWindowState)
+		}
+
+		start := w.end.Add(-w.slide).UnixNano()
+		s, ok := w.keys[key][start]
+		if !ok {
+			s = &aggWindowState{}
+			w.keys[key][start] = s
+		}
+
+		add(s, msg.GetValue(obj.SourceKey))
+	}
+
+	msgs := make([]*message.Message, 0, len(summaries)+1)
+	for _, s := range summaries {
+		s.Value = summarize(s.state)
+
+		outMsg := message.New()
+		if obj.TargetKey != "" {
+			if err := outMsg.SetValue(obj.TargetKey, s); err != nil {
+				return nil, err
+			}
+		} else {
+			b, err := json.Marshal(s)
+			if err != nil {
+				return nil, err
+			}
+
+			outMsg.SetData(b)
+		}
+
+		msgs = append(msgs, outMsg)
+	}
+
+	return append(msgs, msg), nil
+}
+
+// advance closes all windows that end before or at the current time.
+func (w *aggWindow) advance(now time.Time) []aggWindowSummary {
+	var summaries []aggWindowSummary
+
+	for !w.end.IsZero() && !now.Before(w.end) {
+		if len(w.keys) == 0 {
+			break
+		}
+
+		summaries = append(summaries, w.close(w.end)...)
+
+		// Buckets that are not in the next window are removed.
+		oldest := w.end.Add(w.slide - w.size).UnixNano()
+		for key, buckets := range w.keys {
+			for start := range buckets {
+				if start < oldest {
+					delete(buckets, start)
+				}
+			}
+
+			if len(buckets) == 0 {
+				delete(w.keys, key)
+			}
+		}
+
+		w.end = w.end.Add(w.slide)
+	}
+
+	if w.end.IsZero() || !now.Before(w.end) {
+		w.end = now.Truncate(w.slide).Add(w.slide)
+	}
+
+	return summaries
+}
+
+// close returns the summaries of the window that ends at the time.
+func (w *aggWindow) close(end time.Time) []aggWindowSummary {
+	start := end.Add(-w.size)
+
+	keys := make([]string, 0, len(w.keys))
+	for key := range w.keys {
+		keys = append(keys, key)
+	}
+
+	slices.Sort(keys)
+
+	var summaries []aggWindowSummary
+	for _, key := range keys {
+		merged := &aggWindowState{}
+		for bucket, s := range w.keys[key] {
+			if bucket >= start.UnixNano() && bucket < end.UnixNano() {
+				merged.merge(s)
+			}
+		}
+
+		if merged.count == 0 {
+			continue
+		}
+
+		summaries = append(summaries, aggWindowSummary{
+			Type:  w.typ,
+			Key:   key,
+			Start: start.UnixNano(),
+			End:   end.UnixNano(),
+			state: merged,
+		})
+	}
+
+	return summaries
+}
+
+// flush closes the current window and removes all keys.
+func (w *aggWindow) flush() []aggWindowSummary {
+	if w.end.IsZero() {
+		return nil
+	}
+
+	summaries := w.close(w.end)
+	w.keys = make(map[string]map[int64]*aggWindowState)
+
+	return summaries
+}
+
+// aggWindowAddNumber adds numbers to the state. Arrays add each element and
+// values that are not numbers are ignored.
+func aggWindowAddNumber(s *aggWindowState, v message.Value) {
+	aggWindowNumbers(v, s.addNumber)
+}
+
+// aggWindowAddValue is the same as aggWindowAddNumber, but also stores each
+// number. This is required to calculate percentiles.
+func aggWindowAddValue(s *aggWindowState, v message.Value) {
+	aggWindowNumbers(v, func(f float64) {
+		s.addNumber(f)
+		s.values = append(s.values, f)
+	})
+}
+
+func aggWindowNumbers(v message.Value, fn func(float64)) {
+	if !v.Exists() {
+		return
+	}
+
+	if v.IsArray() {
+		for _, e := range v.Array() {
+			aggWindowNumbers(e, fn)
+		}
+
+		return
+	}
+
+	f, err := strconv.ParseFloat(v.String(), 64)
+	if err != nil {
+		return
+	}
+
+	fn(f)
+}
+
+// aggWindowAddString adds strings to the state. Arrays add each element.
+func aggWindowAddString(s *aggWindowState, v message.Value) {
+	if !v.Exists() {
+		return
+	}
+
+	if v.IsArray() {
+		for _, e := range v.Array() {
+			aggWindowAddString(s, e)
+		}
+
+		return
+	}
+
+	s.addString(v.String())
+}
+
+// aggWindowPercentile returns the percentile (0 to 100) of sorted values
+// using linear interpolation between the closest ranks.
+func aggWindowPercentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(rank-float64(lower))
+}
@@ -1,7 +1,14 @@
 package transform
 
 import (
+	"context"
+	"fmt"
+	"math"
 	"testing"
+	"time"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
 )
 
 func TestAggregateArrayConfigDecode(t *testing.T) {
@@ -138,3 +145,185 @@ func FuzzTestAggregateStrConfigDecode(f *testing.F) {
 		}
 	})
 }
+
+type aggWindowTestMessage struct {
+	// offset is the time since the start of the test that the message is
+	// received at.
+	offset time.Duration
+	data   string
+}
+
+// aggWindowTestRun applies the transform to the messages and a control
+// message, and returns the data of the summaries. The clock of the window
+// starts at one hour after the Unix epoch.
+func aggWindowTestRun(t *testing.T, tf Transformer, win *aggWindow, msgs []aggWindowTestMessage) []string {
+	t.Helper()
+
+	ctx := context.TODO()
+	start := time.Unix(3600, 0)
+
+	var now time.Time
+	win.now = func() time.Time { return now }
+
+	var summaries []string
+	apply := func(msg *message.Message) {
+		res, err := tf.Transform(ctx, msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(res) == 0 || res[len(res)-1] != msg {
+			t.Fatalf("expected the message to be returned last")
+		}
+
+		for _, m := range res[:len(res)-1] {
+			summaries = append(summaries, string(m.Data()))
+		}
+	}
+
+	for _, m := range msgs {
+		now = start.Add(m.offset)
+		apply(message.New().SetData([]byte(m.data)))
+	}
+
+	if len(msgs) > 0 {
+		now = start.Add(msgs[len(msgs)-1].offset)
+	}
+
+	apply(message.New().AsControl())
+
+	return summaries
+}
+
+func TestAggregateWindowConfigDecode(t *testing.T) {
+	config := &aggregateWindowConfig{}
+	err := config.Decode(map[string]interface{}{
+		"id": "test_id",
+		"object": map[string]interface{}{
+			"source_key": "foo",
+			"batch_key":  "bar",
+		},
+		"window": map[string]interface{}{
+			"duration": "5m",
+			"slide":    "1m",
+		},
+	})
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if config.Object.BatchKey != "bar" {
+		t.Errorf("expected batch_key to be 'bar', got %s", config.Object.BatchKey)
+	}
+	if config.Window.Duration != "5m" {
+		t.Errorf("expected window duration to be '5m', got %s", config.Window.Duration)
+	}
+	if config.Window.Slide != "1m" {
+		t.Errorf("expected window slide to be '1m', got %s", config.Window.Slide)
+	}
+}
+
+func TestNewAggWindow(t *testing.T) {
+	valid := []aggregateWindowWindowConfig{
+		{},
+		{Duration: "1h"},
+		{Duration: "1h", Slide: "15m"},
+	}
+
+	for _, conf := range valid {
+		if _, err := newAggWindow("", conf); err != nil {
+			t.Errorf("%+v: unexpected error: %v", conf, err)
+		}
+	}
+
+	invalid := []aggregateWindowWindowConfig{
+		{Duration: "x"},
+		{Duration: "-1m"},
+		{Duration: "1h", Slide: "7m"},
+		{Duration: "1m", Slide: "2m"},
+	}
+
+	for _, conf := range invalid {
+		if _, err := newAggWindow("", conf); err == nil {
+			t.Errorf("%+v: expected error", conf)
+		}
+	}
+}
+
+var aggWindowSlidingTests = []struct {
+	name     string
+	data     []aggWindowTestMessage
+	expected []string
+}{
+	{
+		"sliding",
+		[]aggWindowTestMessage{
+			{0, `{"user":"a"}`},
+			{30 * time.Second, `{"user":"a"}`},
+			{70 * time.Second, `{"user":"a"}`},
+			{130 * time.Second, `{"user":"b"}`},
+		},
+		[]string{
+			// Windows that end at 1m and 2m after the first message.
+			`{"type":"aggregate_window_count","key":"a","start":3540000000000,"end":3660000000000,"value":2}`,
+			`{"type":"aggregate_window_count","key":"a","start":3600000000000,"end":3720000000000,"value":3}`,
+			// Window that ends at 3m is closed by the control message.
+			`{"type":"aggregate_window_count","key":"a","start":3660000000000,"end":3780000000000,"value":1}`,
+			`{"type":"aggregate_window_count","key":"b","start":3660000000000,"end":3780000000000,"value":1}`,
+		},
+	},
+	{
+		"gap",
+		[]aggWindowTestMessage{
+			{0, `{"user":"a"}`},
+			{10 * time.Minute, `{"user":"a"}`},
+		},
+		[]string{
+			`{"type":"aggregate_window_count","key":"a","start":3540000000000,"end":3660000000000,"value":1}`,
+			`{"type":"aggregate_window_count","key":"a","start":3600000000000,"end":3720000000000,"value":1}`,
+			`{"type":"aggregate_window_count","key":"a","start":4140000000000,"end":4260000000000,"value":1}`,
+		},
+	},
+}
+
+func TestAggWindowSliding(t *testing.T) {
+	for _, test := range aggWindowSlidingTests {
+		t.Run(test.name, func(t *testing.T) {
+			tf, err := newAggregateWindowCount(context.TODO(), config.Config{
+				Settings: map[string]interface{}{
+					"object": map[string]interface{}{
+						"batch_key": "user",
+					},
+					"window": map[string]interface{}{
+						"duration": "2m",
+						"slide":    "1m",
+					},
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result := aggWindowTestRun(t, tf, tf.win, test.data)
+			if fmt.Sprint(result) != fmt.Sprint(test.expected) {
+				t.Errorf("expected %s, got %s", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestAggWindowPercentile(t *testing.T) {
+	values := []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+
+	tests := map[float64]float64{
+		0:   1,
+		50:  5.5,
+		90:  9.1,
+		100: 10,
+	}
+
+	for p, expected := range tests {
+		if result := aggWindowPercentile(values, p); math.Abs(result-expected) > 1e-9 {
+			t.Errorf("p%v: expected %v, got %v", p, expected, result)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package transform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+
+	iconfig "github.com/brexhq/substation/v2/internal/config"
+)
+
+type aggregateWindowCountConfig struct {
+	Window aggregateWindowWindowConfig `json:"window"`
+
+	ID string `json:"id"`
+	// Object.SourceKey is optional. If it is set, then only messages that
+	// contain the key are counted.
+	Object iconfig.Object `json:"object"`
+}
+
+func (c *aggregateWindowCountConfig) Decode(in interface{}) error {
+	return iconfig.Decode(in, c)
+}
+
+func (c *aggregateWindowCountConfig) Validate() error {
+	return c.Window.Validate()
+}
+
+func newAggregateWindowCount(_ context.Context, cfg config.Config) (*aggregateWindowCount, error) {
+	conf := aggregateWindowCountConfig{}
+	if err := conf.Decode(cfg.Settings); err != nil {
+		return nil, fmt.Errorf("transform aggregate_window_count: %v", err)
+	}
+
+	if conf.ID == "" {
+		conf.ID = "aggregate_window_count"
+	}
+
+	if err := conf.Validate(); err != nil {
+		return nil, fmt.Errorf("transform %s: %v", conf.ID, err)
+	}
+
+	win, err := newAggWindow("aggregate_window_count", conf.Window)
+	if err != nil {
+		return nil, fmt.Errorf("transform %s: %v", conf.ID, err)
+	}
+
+	tf := aggregateWindowCount{
+		conf: conf,
+		win:  win,
+	}
+
+	return &tf, nil
+}
+
+// aggregateWindowCount counts the messages in each window. If the source key
+// is set, then only messages that contain the key are counted.
+type aggregateWindowCount struct {
+	conf aggregateWindowCountConfig
+	win  *aggWindow
+}
+
+func (tf *aggregateWindowCount) Transform(ctx context.Context, msg *message.Message) ([]*message.Message, error) {
+	msgs, err := tf.win.Apply(msg, tf.conf.Object, tf.add, func(s *aggWindowState) interface{} {
+		return s.count
+	})
+	if err != nil {
+		return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, err)
+	}
+
+	return msgs, nil
+}
+
+func (tf *aggregateWindowCount) String() string {
+	b, _ := json.Marshal(tf.conf)
+	return string(b)
+}
+
+func (tf *aggregateWindowCount) add(s *aggWindowState, v message.Value) {
+	if tf.conf.Object.SourceKey == "" || v.Exists() {
+		s.count++
+	}
+}
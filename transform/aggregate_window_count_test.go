@@ -0,0 +1,128 @@
+package transform
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+)
+
+var _ Transformer = &aggregateWindowCount{}
+
+var aggregateWindowCountTests = []struct {
+	name     string
+	cfg      config.Config
+	data     []aggWindowTestMessage
+	expected []string
+}{
+	{
+		"count",
+		config.Config{
+			Settings: map[string]interface{}{
+				"object": map[string]interface{}{
+					"batch_key": "user",
+				},
+			},
+		},
+		[]aggWindowTestMessage{
+			{0, `{"user":"a","bytes":10}`},
+			{10 * time.Second, `{"user":"b","bytes":5}`},
+			{20 * time.Second, `{"user":"a","bytes":[1,2.5]}`},
+			{30 * time.Second, `{"user":"a","bytes":"x"}`},
+			{70 * time.Second, `{"user":"a","bytes":"7"}`},
+		},
+		[]string{
+			`{"type":"aggregate_window_count","key":"a","start":3600000000000,"end":3660000000000,"value":3}`,
+			`{"type":"aggregate_window_count","key":"b","start":3600000000000,"end":3660000000000,"value":1}`,
+			`{"type":"aggregate_window_count","key":"a","start":3660000000000,"end":3720000000000,"value":1}`,
+		},
+	},
+	{
+		"source_key",
+		config.Config{
+			Settings: map[string]interface{}{
+				"object": map[string]interface{}{
+					"source_key": "bytes",
+					"target_key": "summary",
+				},
+				"window": map[string]interface{}{
+					"duration": "1h",
+				},
+			},
+		},
+		[]aggWindowTestMessage{
+			{0, `{"user":"a","bytes":10}`},
+			{10 * time.Second, `{"user":"b"}`},
+		},
+		[]string{
+			`{"summary":{"type":"aggregate_window_count","key":"","start":3600000000000,"end":7200000000000,"value":1}}`,
+		},
+	},
+}
+
+func TestAggregateWindowCount(t *testing.T) {
+	for _, test := range aggregateWindowCountTests {
+		t.Run(test.name, func(t *testing.T) {
+			tf, err := newAggregateWindowCount(context.TODO(), test.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result := aggWindowTestRun(t, tf, tf.win, test.data)
+			if fmt.Sprint(result) != fmt.Sprint(test.expected) {
+				t.Errorf("expected %s, got %s", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestAggregateWindowCountInvalid(t *testing.T) {
+	if _, err := newAggregateWindowCount(context.TODO(), config.Config{
+		Settings: map[string]interface{}{
+			"window": map[string]interface{}{
+				"duration": "1h",
+				"slide":    "7m",
+			},
+		},
+	}); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func FuzzTestAggregateWindowCount(f *testing.F) {
+	testcases := [][]byte{
+		[]byte(`{"user":"a","bytes":10}`),
+		[]byte(`{"user":"b","bytes":[1,2,3]}`),
+		[]byte(`{"user":"c","bytes":"x"}`),
+		[]byte(``),
+	}
+
+	for _, tc := range testcases {
+		f.Add(tc)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ctx := context.TODO()
+		msg := message.New().SetData(data)
+
+		tf, err := newAggregateWindowCount(ctx, config.Config{
+			Settings: map[string]interface{}{
+				"object": map[string]interface{}{
+					"source_key": "bytes",
+					"batch_key":  "user",
+				},
+			},
+		})
+		if err != nil {
+			return
+		}
+
+		_, err = tf.Transform(ctx, msg)
+		if err != nil {
+			return
+		}
+	})
+}
@@ -0,0 +1,59 @@
+package transform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+)
+
+func newAggregateWindowDistinctCount(_ context.Context, cfg config.Config) (*aggregateWindowDistinctCount, error) {
+	conf := aggregateWindowConfig{}
+	if err := conf.Decode(cfg.Settings); err != nil {
+		return nil, fmt.Errorf("transform aggregate_window_distinct_count: %v", err)
+	}
+
+	if conf.ID == "" {
+		conf.ID = "aggregate_window_distinct_count"
+	}
+
+	if err := conf.Validate(); err != nil {
+		return nil, fmt.Errorf("transform %s: %v", conf.ID, err)
+	}
+
+	win, err := newAggWindow("aggregate_window_distinct_count", conf.Window)
+	if err != nil {
+		return nil, fmt.Errorf("transform %s: %v", conf.ID, err)
+	}
+
+	tf := aggregateWindowDistinctCount{
+		conf: conf,
+		win:  win,
+	}
+
+	return &tf, nil
+}
+
+// aggregateWindowDistinctCount counts the distinct values in each window.
+type aggregateWindowDistinctCount struct {
+	conf aggregateWindowConfig
+	win  *aggWindow
+}
+
+func (tf *aggregateWindowDistinctCount) Transform(ctx context.Context, msg *message.Message) ([]*message.Message, error) {
+	msgs, err := tf.win.Apply(msg, tf.conf.Object, aggWindowAddString, func(s *aggWindowState) interface{} {
+		return len(s.distinct)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, err)
+	}
+
+	return msgs, nil
+}
+
+func (tf *aggregateWindowDistinctCount) String() string {
+	b, _ := json.Marshal(tf.conf)
+	return string(b)
+}
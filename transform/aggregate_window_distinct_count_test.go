@@ -0,0 +1,97 @@
+package transform
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+)
+
+var _ Transformer = &aggregateWindowDistinctCount{}
+
+var aggregateWindowDistinctCountTests = []struct {
+	name     string
+	cfg      config.Config
+	data     []aggWindowTestMessage
+	expected []string
+}{
+	{
+		"distinct_count",
+		config.Config{
+			Settings: map[string]interface{}{
+				"object": map[string]interface{}{
+					"source_key": "ip",
+					"batch_key":  "user",
+				},
+				"window": map[string]interface{}{
+					"duration": "5m",
+				},
+			},
+		},
+		[]aggWindowTestMessage{
+			{0, `{"user":"a","ip":"10.0.0.1"}`},
+			{10 * time.Second, `{"user":"a","ip":"10.0.0.1"}`},
+			{20 * time.Second, `{"user":"a","ip":["10.0.0.2","10.0.0.3"]}`},
+			{30 * time.Second, `{"user":"b","ip":"10.0.0.1"}`},
+			{40 * time.Second, `{"user":"b"}`},
+		},
+		[]string{
+			`{"type":"aggregate_window_distinct_count","key":"a","start":3600000000000,"end":3900000000000,"value":3}`,
+			`{"type":"aggregate_window_distinct_count","key":"b","start":3600000000000,"end":3900000000000,"value":1}`,
+		},
+	},
+}
+
+func TestAggregateWindowDistinctCount(t *testing.T) {
+	for _, test := range aggregateWindowDistinctCountTests {
+		t.Run(test.name, func(t *testing.T) {
+			tf, err := newAggregateWindowDistinctCount(context.TODO(), test.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result := aggWindowTestRun(t, tf, tf.win, test.data)
+			if fmt.Sprint(result) != fmt.Sprint(test.expected) {
+				t.Errorf("expected %s, got %s", test.expected, result)
+			}
+		})
+	}
+}
+
+func FuzzTestAggregateWindowDistinctCount(f *testing.F) {
+	testcases := [][]byte{
+		[]byte(`{"user":"a","bytes":10}`),
+		[]byte(`{"user":"b","bytes":[1,2,3]}`),
+		[]byte(`{"user":"c","bytes":"x"}`),
+		[]byte(``),
+	}
+
+	for _, tc := range testcases {
+		f.Add(tc)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ctx := context.TODO()
+		msg := message.New().SetData(data)
+
+		tf, err := newAggregateWindowDistinctCount(ctx, config.Config{
+			Settings: map[string]interface{}{
+				"object": map[string]interface{}{
+					"source_key": "bytes",
+					"batch_key":  "user",
+				},
+			},
+		})
+		if err != nil {
+			return
+		}
+
+		_, err = tf.Transform(ctx, msg)
+		if err != nil {
+			return
+		}
+	})
+}
@@ -0,0 +1,59 @@
+package transform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+)
+
+func newAggregateWindowMin(_ context.Context, cfg config.Config) (*aggregateWindowMin, error) {
+	conf := aggregateWindowConfig{}
+	if err := conf.Decode(cfg.Settings); err != nil {
+		return nil, fmt.Errorf("transform aggregate_window_min: %v", err)
+	}
+
+	if conf.ID == "" {
+		conf.ID = "aggregate_window_min"
+	}
+
+	if err := conf.Validate(); err != nil {
+		return nil, fmt.Errorf("transform %s: %v", conf.ID, err)
+	}
+
+	win, err := newAggWindow("aggregate_window_min", conf.Window)
+	if err != nil {
+		return nil, fmt.Errorf("transform %s: %v", conf.ID, err)
+	}
+
+	tf := aggregateWindowMin{
+		conf: conf,
+		win:  win,
+	}
+
+	return &tf, nil
+}
+
+// aggregateWindowMin returns the minimum number in each window.
+type aggregateWindowMin struct {
+	conf aggregateWindowConfig
+	win  *aggWindow
+}
+
+func (tf *aggregateWindowMin) Transform(ctx context.Context, msg *message.Message) ([]*message.Message, error) {
+	msgs, err := tf.win.Apply(msg, tf.conf.Object, aggWindowAddNumber, func(s *aggWindowState) interface{} {
+		return s.min
+	})
+	if err != nil {
+		return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, err)
+	}
+
+	return msgs, nil
+}
+
+func (tf *aggregateWindowMin) String() string {
+	b, _ := json.Marshal(tf.conf)
+	return string(b)
+}
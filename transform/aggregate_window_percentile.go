@@ -0,0 +1,109 @@
+package transform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+
+	iconfig "github.com/brexhq/substation/v2/internal/config"
+)
+
+type aggregateWindowPercentileConfig struct {
+	// Percentiles are the percentiles (0 to 100) that are calculated for each
+	// window (e.g., [50, 95, 99]). Each percentile is returned as a key in
+	// the summary value (e.g., "p95").
+	//
+	// This is optional and defaults to [50].
+	Percentiles []float64                   `json:"percentiles"`
+	Window      aggregateWindowWindowConfig `json:"window"`
+
+	ID     string         `json:"id"`
+	Object iconfig.Object `json:"object"`
+}
+
+func (c *aggregateWindowPercentileConfig) Decode(in interface{}) error {
+	return iconfig.Decode(in, c)
+}
+
+func (c *aggregateWindowPercentileConfig) Validate() error {
+	if c.Object.SourceKey == "" {
+		return fmt.Errorf("object_source_key: %v", iconfig.ErrMissingRequiredOption)
+	}
+
+	for _, p := range c.Percentiles {
+		if p < 0 || p > 100 {
+			return fmt.Errorf("percentiles %v: %v", p, iconfig.ErrInvalidOption)
+		}
+	}
+
+	return c.Window.Validate()
+}
+
+func newAggregateWindowPercentile(_ context.Context, cfg config.Config) (*aggregateWindowPercentile, error) {
+	conf := aggregateWindowPercentileConfig{}
+	if err := conf.Decode(cfg.Settings); err != nil {
+		return nil, fmt.Errorf("transform aggregate_window_percentile: %v", err)
+	}
+
+	if conf.ID == "" {
+		conf.ID = "aggregate_window_percentile"
+	}
+
+	if len(conf.Percentiles) == 0 {
+		conf.Percentiles = []float64{50}
+	}
+
+	if err := conf.Validate(); err != nil {
+		return nil, fmt.Errorf("transform %s: %v", conf.ID, err)
+	}
+
+	win, err := newAggWindow("aggregate_window_percentile", conf.Window)
+	if err != nil {
+		return nil, fmt.Errorf("transform %s: %v", conf.ID, err)
+	}
+
+	tf := aggregateWindowPercentile{
+		conf: conf,
+		win:  win,
+	}
+
+	return &tf, nil
+}
+
+// aggregateWindowPercentile calculates percentiles of the numbers in each
+// window. All numbers in a window are stored in memory until the window
+// closes.
+type aggregateWindowPercentile struct {
+	conf aggregateWindowPercentileConfig
+	win  *aggWindow
+}
+
+func (tf *aggregateWindowPercentile) Transform(ctx context.Context, msg *message.Message) ([]*message.Message, error) {
+	msgs, err := tf.win.Apply(msg, tf.conf.Object, aggWindowAddValue, tf.summarize)
+	if err != nil {
+		return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, err)
+	}
+
+	return msgs, nil
+}
+
+func (tf *aggregateWindowPercentile) String() string {
+	b, _ := json.Marshal(tf.conf)
+	return string(b)
+}
+
+func (tf *aggregateWindowPercentile) summarize(s *aggWindowState) interface{} {
+	slices.Sort(s.values)
+
+	out := make(map[string]float64, len(tf.conf.Percentiles))
+	for _, p := range tf.conf.Percentiles {
+		out["p"+strconv.FormatFloat(p, 'f', -1, 64)] = aggWindowPercentile(s.values, p)
+	}
+
+	return out
+}
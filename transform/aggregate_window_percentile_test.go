@@ -0,0 +1,90 @@
+package transform
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+)
+
+var _ Transformer = &aggregateWindowPercentile{}
+
+var aggregateWindowPercentileTests = []struct {
+	name     string
+	cfg      config.Config
+	data     []aggWindowTestMessage
+	expected []string
+}{
+	{
+		"percentile",
+		config.Config{
+			Settings: map[string]interface{}{
+				"object": map[string]interface{}{
+					"source_key": "latency",
+				},
+				"percentiles": []float64{50, 75},
+			},
+		},
+		[]aggWindowTestMessage{
+			{0, `{"latency":[1,2,3,4,5,6,7,8,9]}`},
+			{10 * time.Second, `{"latency":10}`},
+		},
+		[]string{
+			`{"type":"aggregate_window_percentile","key":"","start":3600000000000,"end":3660000000000,"value":{"p50":5.5,"p75":7.75}}`,
+		},
+	},
+}
+
+func TestAggregateWindowPercentile(t *testing.T) {
+	for _, test := range aggregateWindowPercentileTests {
+		t.Run(test.name, func(t *testing.T) {
+			tf, err := newAggregateWindowPercentile(context.TODO(), test.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result := aggWindowTestRun(t, tf, tf.win, test.data)
+			if fmt.Sprint(result) != fmt.Sprint(test.expected) {
+				t.Errorf("expected %s, got %s", test.expected, result)
+			}
+		})
+	}
+}
+
+func FuzzTestAggregateWindowPercentile(f *testing.F) {
+	testcases := [][]byte{
+		[]byte(`{"user":"a","bytes":10}`),
+		[]byte(`{"user":"b","bytes":[1,2,3]}`),
+		[]byte(`{"user":"c","bytes":"x"}`),
+		[]byte(``),
+	}
+
+	for _, tc := range testcases {
+		f.Add(tc)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ctx := context.TODO()
+		msg := message.New().SetData(data)
+
+		tf, err := newAggregateWindowPercentile(ctx, config.Config{
+			Settings: map[string]interface{}{
+				"object": map[string]interface{}{
+					"source_key": "bytes",
+					"batch_key":  "user",
+				},
+			},
+		})
+		if err != nil {
+			return
+		}
+
+		_, err = tf.Transform(ctx, msg)
+		if err != nil {
+			return
+		}
+	})
+}
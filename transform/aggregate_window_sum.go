@@ -0,0 +1,59 @@
+package transform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+)
+
+func newAggregateWindowSum(_ context.Context, cfg config.Config) (*aggregateWindowSum, error) {
+	conf := aggregateWindowConfig{}
+	if err := conf.Decode(cfg.Settings); err != nil {
+		return nil, fmt.Errorf("transform aggregate_window_sum: %v", err)
+	}
+
+	if conf.ID == "" {
+		conf.ID = "aggregate_window_sum"
+	}
+
+	if err := conf.Validate(); err != nil {
+		return nil, fmt.Errorf("transform %s: %v", conf.ID, err)
+	}
+
+	win, err := newAggWindow("aggregate_window_sum", conf.Window)
+	if err != nil {
+		return nil, fmt.Errorf("transform %s: %v", conf.ID, err)
+	}
+
+	tf := aggregateWindowSum{
+		conf: conf,
+		win:  win,
+	}
+
+	return &tf, nil
+}
+
+// aggregateWindowSum sums the numbers in each window.
+type aggregateWindowSum struct {
+	conf aggregateWindowConfig
+	win  *aggWindow
+}
+
+func (tf *aggregateWindowSum) Transform(ctx context.Context, msg *message.Message) ([]*message.Message, error) {
+	msgs, err := tf.win.Apply(msg, tf.conf.Object, aggWindowAddNumber, func(s *aggWindowState) interface{} {
+		return s.sum
+	})
+	if err != nil {
+		return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, err)
+	}
+
+	return msgs, nil
+}
+
+func (tf *aggregateWindowSum) String() string {
+	b, _ := json.Marshal(tf.conf)
+	return string(b)
+}
@@ -0,0 +1,95 @@
+package transform
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+)
+
+var _ Transformer = &aggregateWindowSum{}
+
+var aggregateWindowSumTests = []struct {
+	name     string
+	cfg      config.Config
+	data     []aggWindowTestMessage
+	expected []string
+}{
+	{
+		"sum",
+		config.Config{
+			Settings: map[string]interface{}{
+				"object": map[string]interface{}{
+					"source_key": "bytes",
+					"batch_key":  "user",
+				},
+			},
+		},
+		[]aggWindowTestMessage{
+			{0, `{"user":"a","bytes":10}`},
+			{10 * time.Second, `{"user":"b","bytes":5}`},
+			{20 * time.Second, `{"user":"a","bytes":[1,2.5]}`},
+			{30 * time.Second, `{"user":"a","bytes":"x"}`},
+			{70 * time.Second, `{"user":"a","bytes":"7"}`},
+		},
+		[]string{
+			`{"type":"aggregate_window_sum","key":"a","start":3600000000000,"end":3660000000000,"value":13.5}`,
+			`{"type":"aggregate_window_sum","key":"b","start":3600000000000,"end":3660000000000,"value":5}`,
+			`{"type":"aggregate_window_sum","key":"a","start":3660000000000,"end":3720000000000,"value":7}`,
+		},
+	},
+}
+
+func TestAggregateWindowSum(t *testing.T) {
+	for _, test := range aggregateWindowSumTests {
+		t.Run(test.name, func(t *testing.T) {
+			tf, err := newAggregateWindowSum(context.TODO(), test.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result := aggWindowTestRun(t, tf, tf.win, test.data)
+			if fmt.Sprint(result) != fmt.Sprint(test.expected) {
+				t.Errorf("expected %s, got %s", test.expected, result)
+			}
+		})
+	}
+}
+
+func FuzzTestAggregateWindowSum(f *testing.F) {
+	testcases := [][]byte{
+		[]byte(`{"user":"a","bytes":10}`),
+		[]byte(`{"user":"b","bytes":[1,2,3]}`),
+		[]byte(`{"user":"c","bytes":"x"}`),
+		[]byte(``),
+	}
+
+	for _, tc := range testcases {
+		f.Add(tc)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ctx := context.TODO()
+		msg := message.New().SetData(data)
+
+		tf, err := newAggregateWindowSum(ctx, config.Config{
+			Settings: map[string]interface{}{
+				"object": map[string]interface{}{
+					"source_key": "bytes",
+					"batch_key":  "user",
+				},
+			},
+		})
+		if err != nil {
+			return
+		}
+
+		_, err = tf.Transform(ctx, msg)
+		if err != nil {
+			return
+		}
+	})
+}
@@ -0,0 +1,362 @@
+package transform
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/kms/types"
+	"golang.org/x/sync/singleflight"
+
+	ibase64 "github.com/brexhq/substation/v2/internal/base64"
+	iconfig "github.com/brexhq/substation/v2/internal/config"
+	"github.com/brexhq/substation/v2/internal/file"
+	"github.com/brexhq/substation/v2/internal/secrets"
+)
+
+// Encrypted values start with a header that contains the version of the
+// format and the data key that encrypted the value. The data key is wrapped
+// (encrypted) by a key encryption key, so values can be decrypted after data
+// keys are rotated:
+//
+//	version (1 byte) | wrap type (1 byte) | wrapped key length (2 bytes) | wrapped key
+//
+// The header is followed by the nonce and the AES-GCM ciphertext. The header
+// is authenticated as additional data.
+const (
+	cryptoVersion    byte = 1
+	cryptoWrapAWSKMS byte = 1
+	cryptoWrapLocal  byte = 2
+
+	cryptoHeaderSize = 4
+	cryptoKeySize    = 32
+	cryptoKeyIDSize  = 8
+	// cryptoDataKeyCacheSize is the number of unwrapped data keys that are
+	// stored by crypto_decrypt.
+	cryptoDataKeyCacheSize = 1000
+)
+
+var (
+	// errCryptoInvalidCiphertext is returned when a value is not in the
+	// encrypted format or was modified.
+	errCryptoInvalidCiphertext = fmt.Errorf("invalid ciphertext")
+	// errCryptoKeyMismatch is returned when a value was encrypted with a
+	// different key encryption key.
+	errCryptoKeyMismatch = fmt.Errorf("key encryption key does not match")
+)
+
+type cryptoDataKeyConfig struct {
+	// Count is the number of values that are encrypted with a data key
+	// before it is rotated.
+	//
+	// This is optional and defaults to 1000000.
+	Count int `json:"count"`
+	// Duration is the amount of time that a data key is used before it is
+	// rotated (e.g., "1h").
+	//
+	// This is optional and defaults to "1h".
+	Duration string `json:"duration"`
+}
+
+type cryptoConfig struct {
+	// Key is a base64 encoded 256-bit key that wraps data keys. Secrets can
+	// be used with the utility_secret transform (e.g., "${SECRET:KEY}").
+	//
+	// This is optional if KeyFile or AWS is set.
+	Key string `json:"key"`
+	// KeyFile is the location of a file that contains a base64 encoded
+	// 256-bit key that wraps data keys. The file can be a local file, an
+	// HTTP(S) URL, or an AWS S3 object.
+	//
+	// This is optional if Key or AWS is set.
+	KeyFile string `json:"key_file"`
+	// DataKey determines when data keys are rotated. This is only used by
+	// crypto_encrypt.
+	DataKey cryptoDataKeyConfig `json:"data_key"`
+
+	ID     string         `json:"id"`
+	Object iconfig.Object `json:"object"`
+	// AWS.ARN is the AWS KMS key that wraps data keys.
+	//
+	// This is optional if Key or KeyFile is set.
+	AWS iconfig.AWS `json:"aws"`
+}
+
+func (c *cryptoConfig) Decode(in interface{}) error {
+	return iconfig.Decode(in, c)
+}
+
+func (c *cryptoConfig) Validate() error {
+	if c.Object.SourceKey == "" && c.Object.TargetKey != "" {
+		return fmt.Errorf("object_source_key: %v", iconfig.ErrMissingRequiredOption)
+	}
+
+	if c.Object.SourceKey != "" && c.Object.TargetKey == "" {
+		return fmt.Errorf("object_target_key: %v", iconfig.ErrMissingRequiredOption)
+	}
+
+	var n int
+	for _, s := range []string{c.Key, c.KeyFile, c.AWS.ARN} {
+		if s != "" {
+			n++
+		}
+	}
+
+	if n == 0 {
+		return fmt.Errorf("key: %v", iconfig.ErrMissingRequiredOption)
+	}
+
+	if n > 1 {
+		return fmt.Errorf("key: %v", iconfig.ErrInvalidOption)
+	}
+
+	if c.DataKey.Count < 0 {
+		return fmt.Errorf("data_key.count %d: %v", c.DataKey.Count, iconfig.ErrInvalidOption)
+	}
+
+	return nil
+}
+
+// cryptoKeyring creates and unwraps data keys with a key encryption key.
+type cryptoKeyring interface {
+	// Generate returns a new data key and the data key wrapped by the key
+	// encryption key.
+	Generate(context.Context) ([]byte, []byte, error)
+	Unwrap(context.Context, []byte) ([]byte, error)
+	// Type is the wrap type that is stored in the header.
+	Type() byte
+}
+
+func newCryptoKeyring(ctx context.Context, conf cryptoConfig) (cryptoKeyring, error) {
+	if conf.AWS.ARN != "" {
+		awsCfg, err := iconfig.NewAWS(ctx, conf.AWS)
+		if err != nil {
+			return nil, err
+		}
+
+		return &cryptoAWSKMSKeyring{
+			client: kms.NewFromConfig(awsCfg),
+			arn:    conf.AWS.ARN,
+		}, nil
+	}
+
+	key := conf.Key
+	if conf.KeyFile != "" {
+		path, err := file.Get(ctx, conf.KeyFile)
+		defer os.Remove(path)
+		if err != nil {
+			return nil, fmt.Errorf("key_file %s: %v", conf.KeyFile, err)
+		}
+
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("key_file %s: %v", conf.KeyFile, err)
+		}
+
+		key = string(b)
+	}
+
+	key, err := secrets.Interpolate(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	kek, err := ibase64.Decode([]byte(strings.TrimSpace(key)))
+	if err != nil {
+		return nil, fmt.Errorf("key: %v", err)
+	}
+
+	if len(kek) != cryptoKeySize {
+		return nil, fmt.Errorf("key: %v", iconfig.ErrInvalidOption)
+	}
+
+	aead, err := cryptoNewAEAD(kek)
+	if err != nil {
+		return nil, err
+	}
+
+	// The ID identifies the key without revealing it, so values that were
+	// encrypted with a different key return a useful error.
+	sum := sha256.Sum256(kek)
+
+	return &cryptoLocalKeyring{
+		id:   sum[:cryptoKeyIDSize],
+		aead: aead,
+	}, nil
+}
+
+// cryptoAWSKMSKeyring wraps data keys with an AWS KMS key.
+type cryptoAWSKMSKeyring struct {
+	client *kms.Client
+	arn    string
+}
+
+func (k *cryptoAWSKMSKeyring) Generate(ctx context.Context) ([]byte, []byte, error) {
+	resp, err := k.client.GenerateDataKey(ctx, &kms.GenerateDataKeyInput{
+		KeyId:   &k.arn,
+		KeySpec: types.DataKeySpecAes256,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return resp.Plaintext, resp.CiphertextBlob, nil
+}
+
+// Unwrap does not set the key ID because KMS ciphertext contains it, which
+// allows values to be decrypted after the KMS key is replaced.
+func (k *cryptoAWSKMSKeyring) Unwrap(ctx context.Context, wrapped []byte) ([]byte, error) {
+	resp, err := k.client.Decrypt(ctx, &kms.DecryptInput{
+		CiphertextBlob: wrapped,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Plaintext, nil
+}
+
+func (k *cryptoAWSKMSKeyring) Type() byte {
+	return cryptoWrapAWSKMS
+}
+
+// cryptoLocalKeyring wraps data keys with a local key. Wrapped keys contain
+// the ID of the local key, a nonce, and the AES-GCM ciphertext of the data key.
+type cryptoLocalKeyring struct {
+	id   []byte
+	aead cipher.AEAD
+}
+
+func (k *cryptoLocalKeyring) Generate(_ context.Context) ([]byte, []byte, error) {
+	key := make([]byte, cryptoKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, nil, err
+	}
+
+	nonce := make([]byte, k.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, nil, err
+	}
+
+	wrapped := append([]byte{}, k.id...)
+	wrapped = append(wrapped, nonce...)
+	wrapped = k.aead.Seal(wrapped, nonce, key, k.id)
+
+	return key, wrapped, nil
+}
+
+func (k *cryptoLocalKeyring) Unwrap(_ context.Context, wrapped []byte) ([]byte, error) {
+	if len(wrapped) < cryptoKeyIDSize+k.aead.NonceSize() {
+		return nil, errCryptoInvalidCiphertext
+	}
+
+	id, wrapped := wrapped[:cryptoKeyIDSize], wrapped[cryptoKeyIDSize:]
+	if string(id) != string(k.id) {
+		return nil, errCryptoKeyMismatch
+	}
+
+	nonce, wrapped := wrapped[:k.aead.NonceSize()], wrapped[k.aead.NonceSize():]
+	key, err := k.aead.Open(nil, nonce, wrapped, id)
+	if err != nil {
+		return nil, errCryptoInvalidCiphertext
+	}
+
+	return key, nil
+}
+
+func (k *cryptoLocalKeyring) Type() byte {
+	return cryptoWrapLocal
+}
+
+func cryptoNewAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// cryptoHeader returns the header for a wrapped data key.
+func cryptoHeader(wrapType byte, wrapped []byte) []byte {
+	h := make([]byte, cryptoHeaderSize, cryptoHeaderSize+len(wrapped))
+	h[0] = cryptoVersion
+	h[1] = wrapType
+	binary.BigEndian.PutUint16(h[2:], uint16(len(wrapped)))
+
+	return append(h, wrapped...)
+}
+
+// cryptoParseHeader returns the wrap type, the wrapped data key, and the
+// length of the header in an encrypted value.
+func cryptoParseHeader(b []byte) (byte, []byte, int, error) {
+	if len(b) < cryptoHeaderSize {
+		return 0, nil, 0, errCryptoInvalidCiphertext
+	}
+
+	if b[0] != cryptoVersion {
+		return 0, nil, 0, fmt.Errorf("version %d: %v", b[0], errCryptoInvalidCiphertext)
+	}
+
+	n := cryptoHeaderSize + int(binary.BigEndian.Uint16(b[2:cryptoHeaderSize]))
+	if len(b) < n {
+		return 0, nil, 0, errCryptoInvalidCiphertext
+	}
+
+	return b[1], b[cryptoHeaderSize:n], n, nil
+}
+
+// cryptoDataKeyCache stores unwrapped data keys so that the key encryption
+// key is not used for every value. Keys are unwrapped without holding the
+// lock, and concurrent requests for the same key share one unwrap.
+type cryptoDataKeyCache struct {
+	mu    sync.Mutex
+	keys  map[string]cipher.AEAD
+	group singleflight.Group
+}
+
+func (c *cryptoDataKeyCache) Get(ctx context.Context, keyring cryptoKeyring, wrapped []byte) (cipher.AEAD, error) {
+	c.mu.Lock()
+	aead, ok := c.keys[string(wrapped)]
+	c.mu.Unlock()
+
+	if ok {
+		return aead, nil
+	}
+
+	v, err, _ := c.group.Do(string(wrapped), func() (interface{}, error) {
+		key, err := keyring.Unwrap(ctx, wrapped)
+		if err != nil {
+			return nil, err
+		}
+
+		aead, err := cryptoNewAEAD(key)
+		if err != nil {
+			return nil, err
+		}
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		if c.keys == nil || len(c.keys) >= cryptoDataKeyCacheSize {
+			c.keys = make(map[string]cipher.AEAD)
+		}
+
+		c.keys[string(wrapped)] = aead
+
+		return aead, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v.(cipher.AEAD), nil
+}
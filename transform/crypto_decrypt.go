@@ -0,0 +1,122 @@
+package transform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+
+	ibase64 "github.com/brexhq/substation/v2/internal/base64"
+)
+
+func newCryptoDecrypt(ctx context.Context, cfg config.Config) (*cryptoDecrypt, error) {
+	conf := cryptoConfig{}
+	if err := conf.Decode(cfg.Settings); err != nil {
+		return nil, fmt.Errorf("transform crypto_decrypt: %v", err)
+	}
+
+	if conf.ID == "" {
+		conf.ID = "crypto_decrypt"
+	}
+
+	if err := conf.Validate(); err != nil {
+		return nil, fmt.Errorf("transform %s: %v", conf.ID, err)
+	}
+
+	keyring, err := newCryptoKeyring(ctx, conf)
+	if err != nil {
+		return nil, fmt.Errorf("transform %s: %v", conf.ID, err)
+	}
+
+	tf := cryptoDecrypt{
+		conf:     conf,
+		isObject: conf.Object.SourceKey != "" && conf.Object.TargetKey != "",
+		keyring:  keyring,
+	}
+
+	return &tf, nil
+}
+
+// cryptoDecrypt decrypts values that were encrypted by crypto_encrypt. Data
+// keys are unwrapped by the keyring and cached.
+type cryptoDecrypt struct {
+	conf     cryptoConfig
+	isObject bool
+	keyring  cryptoKeyring
+	cache    cryptoDataKeyCache
+}
+
+func (tf *cryptoDecrypt) Transform(ctx context.Context, msg *message.Message) ([]*message.Message, error) {
+	if msg.IsControl() {
+		return []*message.Message{msg}, nil
+	}
+
+	if !tf.isObject {
+		b, err := tf.decrypt(ctx, msg.Data())
+		if err != nil {
+			return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, err)
+		}
+
+		msg.SetData(b)
+		return []*message.Message{msg}, nil
+	}
+
+	value := msg.GetValue(tf.conf.Object.SourceKey)
+	if !value.Exists() {
+		return []*message.Message{msg}, nil
+	}
+
+	// Encrypted values are stored in objects as base64.
+	ciphertext, err := ibase64.Decode(value.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, err)
+	}
+
+	b, err := tf.decrypt(ctx, ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, err)
+	}
+
+	// The JSON encoding of the value was encrypted, so its type is restored.
+	if err := msg.SetValue(tf.conf.Object.TargetKey, json.RawMessage(b)); err != nil {
+		return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, err)
+	}
+
+	return []*message.Message{msg}, nil
+}
+
+func (tf *cryptoDecrypt) String() string {
+	b, _ := json.Marshal(tf.conf)
+	return string(b)
+}
+
+func (tf *cryptoDecrypt) decrypt(ctx context.Context, ciphertext []byte) ([]byte, error) {
+	wrapType, wrapped, n, err := cryptoParseHeader(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+
+	if wrapType != tf.keyring.Type() {
+		return nil, errCryptoKeyMismatch
+	}
+
+	aead, err := tf.cache.Get(ctx, tf.keyring, wrapped)
+	if err != nil {
+		return nil, err
+	}
+
+	header, ciphertext := ciphertext[:n], ciphertext[n:]
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, errCryptoInvalidCiphertext
+	}
+
+	nonce, ciphertext := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+	b, err := aead.Open(nil, nonce, ciphertext, header)
+	if err != nil {
+		return nil, errCryptoInvalidCiphertext
+	}
+
+	return b, nil
+}
@@ -0,0 +1,335 @@
+package transform
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+)
+
+var _ Transformer = &cryptoDecrypt{}
+
+// The encrypted values were created by crypto_encrypt with cryptoTestKey and
+// must always be decrypted by future versions.
+var cryptoDecryptTests = []struct {
+	name     string
+	cfg      config.Config
+	test     []byte
+	expected [][]byte
+}{
+	{
+		"object",
+		config.Config{
+			Settings: map[string]interface{}{
+				"object": map[string]interface{}{
+					"source_key": "a",
+					"target_key": "a",
+				},
+				"key": cryptoTestKey,
+			},
+		},
+		[]byte(`{"a":"AQIARD6xvUOZR+t2MeHjQwToo+CVFtv5ww/7Z7gzzVYHiGokXwqjroN8rF15hgyKunLVkoIwwFT7A7z8WTx5JfV5XawL/I121cBnEJbZ2UGPYffRMhPlUaJ8ZZ2KlnxmcJNNcKBDkw=="}`),
+		[][]byte{
+			[]byte(`{"a":"b"}`),
+		},
+	},
+	{
+		"object",
+		config.Config{
+			Settings: map[string]interface{}{
+				"object": map[string]interface{}{
+					"source_key": "a",
+					"target_key": "a",
+				},
+				"key": cryptoTestKey,
+			},
+		},
+		[]byte(`{"a":"AQIARD6xvUOZR+t2MeHjQwToo+CVFtv5ww/7Z7gzzVYHiGokXwqjroN8rF15hgyKunLVkoIwwFT7A7z8WTx5JfV5XawL/I12DPnRaELDxotkQ2uFa5F2CZIP+2ThhN0y6uRDnJkUGboox8uSRQ=="}`),
+		[][]byte{
+			[]byte(`{"a":{"b":"c"}}`),
+		},
+	},
+	{
+		"object number",
+		config.Config{
+			Settings: map[string]interface{}{
+				"object": map[string]interface{}{
+					"source_key": "a",
+					"target_key": "a",
+				},
+				"key": cryptoTestKey,
+			},
+		},
+		[]byte(`{"a":"AQIARD6xvUOZR+t2MeHjQwToo+CVFtv5ww/7Z7gzzVYHiGokXwqjroN8rF15hgyKunLVkoIwwFT7A7z8WTx5JfV5XawL/I12835KzSky4Q5DCzRNSXcy3l7V3m0JJqRzXnO2JOo="}`),
+		[][]byte{
+			[]byte(`{"a":1}`),
+		},
+	},
+	{
+		"object string",
+		config.Config{
+			Settings: map[string]interface{}{
+				"object": map[string]interface{}{
+					"source_key": "a",
+					"target_key": "a",
+				},
+				"key": cryptoTestKey,
+			},
+		},
+		[]byte(`{"a":"AQIARD6xvUOZR+t2MeHjQwToo+CVFtv5ww/7Z7gzzVYHiGokXwqjroN8rF15hgyKunLVkoIwwFT7A7z8WTx5JfV5XawL/I12+91TXBZAc0qsdzTV02cTzSIKgeTcCWdRVJe06C3cCw=="}`),
+		[][]byte{
+			[]byte(`{"a":"1"}`),
+		},
+	},
+}
+
+func TestCryptoDecrypt(t *testing.T) {
+	ctx := context.TODO()
+	for _, test := range cryptoDecryptTests {
+		t.Run(test.name, func(t *testing.T) {
+			tf, err := newCryptoDecrypt(ctx, test.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			msg := message.New().SetData(test.test)
+			result, err := tf.Transform(ctx, msg)
+			if err != nil {
+				t.Error(err)
+			}
+
+			var data [][]byte
+			for _, c := range result {
+				data = append(data, c.Data())
+			}
+
+			if !reflect.DeepEqual(data, test.expected) {
+				t.Errorf("expected %s, got %s", test.expected, data)
+			}
+		})
+	}
+}
+
+func TestCryptoDecryptKeyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "key")
+	if err := os.WriteFile(path, []byte(cryptoTestKey+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	test := cryptoDecryptTests[0]
+	tf, err := newCryptoDecrypt(context.TODO(), config.Config{
+		Settings: map[string]interface{}{
+			"object": map[string]interface{}{
+				"source_key": "a",
+				"target_key": "a",
+			},
+			"key_file": path,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := tf.Transform(context.TODO(), message.New().SetData(test.test))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(result[0].Data(), test.expected[0]) {
+		t.Errorf("expected %s, got %s", test.expected[0], result[0].Data())
+	}
+}
+
+func TestCryptoDecryptError(t *testing.T) {
+	tests := []struct {
+		name string
+		key  string
+		test []byte
+	}{
+		{
+			"modified",
+			cryptoTestKey,
+			[]byte(`{"a":"AQIARD6xvUOZR+t2MeHjQwToo+CVFtv5ww/7Z7gzzVYHiGokXwqjroN8rF15hgyKunLVkoIwwFT7A7z8WTx5JfV5XawL/I121cBnEJbZ2UGPYffRMhPlUaJ8ZZ2KlnxmcJNNcKBEkw=="}`),
+		},
+		{
+			"version",
+			cryptoTestKey,
+			[]byte(`{"a":"AgIARD6xvUOZR+t2MeHjQwToo+CVFtv5ww/7Z7gzzVYHiGokXwqjroN8rF15hgyKunLVkoIwwFT7A7z8WTx5JfV5XawL/I121cBnEJbZ2UGPYffRMhPlUaJ8ZZ2KlnxmcJNNcKBDkw=="}`),
+		},
+		{
+			"key",
+			// The base64 encoding of "abcdef0123456789abcdef0123456789".
+			"YWJjZGVmMDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODk=",
+			cryptoDecryptTests[0].test,
+		},
+		{
+			"short",
+			cryptoTestKey,
+			[]byte(`{"a":"AQIA"}`),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tf, err := newCryptoDecrypt(context.TODO(), config.Config{
+				Settings: map[string]interface{}{
+					"object": map[string]interface{}{
+						"source_key": "a",
+						"target_key": "a",
+					},
+					"key": test.key,
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := tf.Transform(context.TODO(), message.New().SetData(test.test)); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+// cryptoTestKeyring is a local keyring that blocks unwraps of the wrapped key
+// until it is released.
+type cryptoTestKeyring struct {
+	cryptoKeyring
+
+	wrapped []byte
+	release chan struct{}
+	unwraps atomic.Int32
+}
+
+func (k *cryptoTestKeyring) Unwrap(ctx context.Context, wrapped []byte) ([]byte, error) {
+	if bytes.Equal(wrapped, k.wrapped) {
+		k.unwraps.Add(1)
+		<-k.release
+	}
+
+	return k.cryptoKeyring.Unwrap(ctx, wrapped)
+}
+
+func TestCryptoDataKeyCache(t *testing.T) {
+	ctx := context.TODO()
+	keyring, err := newCryptoKeyring(ctx, cryptoConfig{Key: cryptoTestKey})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, a, err := keyring.Generate(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, b, err := keyring.Generate(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	k := &cryptoTestKeyring{
+		cryptoKeyring: keyring,
+		wrapped:       a,
+		release:       make(chan struct{}),
+	}
+
+	var cache cryptoDataKeyCache
+	if _, err := cache.Get(ctx, k, b); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			if _, err := cache.Get(ctx, k, a); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+
+	// Cached keys are returned while another key is unwrapped.
+	for k.unwraps.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	if _, err := cache.Get(ctx, k, b); err != nil {
+		t.Fatal(err)
+	}
+
+	close(k.release)
+	wg.Wait()
+
+	if n := k.unwraps.Load(); n != 1 {
+		t.Errorf("expected 1 unwrap, got %d", n)
+	}
+}
+
+func benchmarkCryptoDecrypt(b *testing.B, tf *cryptoDecrypt, data []byte) {
+	ctx := context.TODO()
+	for i := 0; i < b.N; i++ {
+		msg := message.New().SetData(data)
+		_, _ = tf.Transform(ctx, msg)
+	}
+}
+
+func BenchmarkCryptoDecrypt(b *testing.B) {
+	for _, test := range cryptoDecryptTests {
+		tf, err := newCryptoDecrypt(context.TODO(), test.cfg)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(test.name,
+			func(b *testing.B) {
+				benchmarkCryptoDecrypt(b, tf, test.test)
+			},
+		)
+	}
+}
+
+func FuzzTestCryptoDecrypt(f *testing.F) {
+	testcases := [][]byte{
+		cryptoDecryptTests[0].test,
+		[]byte(`{"a":"AQIA"}`),
+		[]byte(`a`),
+		[]byte(``),
+	}
+
+	for _, tc := range testcases {
+		f.Add(tc)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ctx := context.TODO()
+		msg := message.New().SetData(data)
+
+		tf, err := newCryptoDecrypt(ctx, config.Config{
+			Settings: map[string]interface{}{
+				"object": map[string]interface{}{
+					"source_key": "a",
+					"target_key": "a",
+				},
+				"key": cryptoTestKey,
+			},
+		})
+		if err != nil {
+			return
+		}
+
+		_, err = tf.Transform(ctx, msg)
+		if err != nil {
+			return
+		}
+	})
+}
@@ -0,0 +1,171 @@
+package transform
+
+import (
+	"context"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+
+	ibase64 "github.com/brexhq/substation/v2/internal/base64"
+)
+
+func newCryptoEncrypt(ctx context.Context, cfg config.Config) (*cryptoEncrypt, error) {
+	conf := cryptoConfig{}
+	if err := conf.Decode(cfg.Settings); err != nil {
+		return nil, fmt.Errorf("transform crypto_encrypt: %v", err)
+	}
+
+	if conf.ID == "" {
+		conf.ID = "crypto_encrypt"
+	}
+
+	if conf.DataKey.Count == 0 {
+		conf.DataKey.Count = 1000000
+	}
+
+	if conf.DataKey.Duration == "" {
+		conf.DataKey.Duration = "1h"
+	}
+
+	if err := conf.Validate(); err != nil {
+		return nil, fmt.Errorf("transform %s: %v", conf.ID, err)
+	}
+
+	dur, err := time.ParseDuration(conf.DataKey.Duration)
+	if err != nil {
+		return nil, fmt.Errorf("transform %s: data_key.duration: %v", conf.ID, err)
+	}
+
+	keyring, err := newCryptoKeyring(ctx, conf)
+	if err != nil {
+		return nil, fmt.Errorf("transform %s: %v", conf.ID, err)
+	}
+
+	tf := cryptoEncrypt{
+		conf:     conf,
+		isObject: conf.Object.SourceKey != "" && conf.Object.TargetKey != "",
+		keyring:  keyring,
+		dur:      dur,
+		now:      time.Now,
+	}
+
+	return &tf, nil
+}
+
+// cryptoEncrypt encrypts values with AES-GCM using envelope encryption. Data
+// keys are created by the keyring and are used until they are rotated.
+type cryptoEncrypt struct {
+	conf     cryptoConfig
+	isObject bool
+	keyring  cryptoKeyring
+	dur      time.Duration
+	now      func() time.Time
+
+	// mu is required to prevent concurrent access to the data key.
+	mu      sync.Mutex
+	aead    cipher.AEAD
+	header  []byte
+	count   int
+	expires time.Time
+}
+
+func (tf *cryptoEncrypt) Transform(ctx context.Context, msg *message.Message) ([]*message.Message, error) {
+	if msg.IsControl() {
+		return []*message.Message{msg}, nil
+	}
+
+	if !tf.isObject {
+		b, err := tf.encrypt(ctx, msg.Data())
+		if err != nil {
+			return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, err)
+		}
+
+		msg.SetData(b)
+		return []*message.Message{msg}, nil
+	}
+
+	value := msg.GetValue(tf.conf.Object.SourceKey)
+	if !value.Exists() {
+		return []*message.Message{msg}, nil
+	}
+
+	// The JSON encoding of the value is encrypted so that its type is
+	// restored by crypto_decrypt.
+	var plaintext []byte
+	switch v := value.Value().(type) {
+	case string, nil:
+		plaintext, _ = json.Marshal(v)
+	default:
+		plaintext = value.Bytes()
+	}
+
+	b, err := tf.encrypt(ctx, plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, err)
+	}
+
+	// Encrypted values are stored in objects as base64.
+	if err := msg.SetValue(tf.conf.Object.TargetKey, string(ibase64.Encode(b))); err != nil {
+		return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, err)
+	}
+
+	return []*message.Message{msg}, nil
+}
+
+func (tf *cryptoEncrypt) String() string {
+	b, _ := json.Marshal(tf.conf)
+	return string(b)
+}
+
+func (tf *cryptoEncrypt) encrypt(ctx context.Context, plaintext []byte) ([]byte, error) {
+	aead, header, err := tf.dataKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, 0, len(header)+len(nonce)+len(plaintext)+aead.Overhead())
+	out = append(out, header...)
+	out = append(out, nonce...)
+
+	return aead.Seal(out, nonce, plaintext, header), nil
+}
+
+// dataKey returns the current data key and its header. A new data key is
+// created if the current key was used too many times or is too old.
+func (tf *cryptoEncrypt) dataKey(ctx context.Context) (cipher.AEAD, []byte, error) {
+	tf.mu.Lock()
+	defer tf.mu.Unlock()
+
+	now := tf.now()
+	if tf.aead == nil || tf.count >= tf.conf.DataKey.Count || !now.Before(tf.expires) {
+		key, wrapped, err := tf.keyring.Generate(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		aead, err := cryptoNewAEAD(key)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		tf.aead = aead
+		tf.header = cryptoHeader(tf.keyring.Type(), wrapped)
+		tf.count = 0
+		tf.expires = now.Add(tf.dur)
+	}
+
+	tf.count++
+
+	return tf.aead, tf.header, nil
+}
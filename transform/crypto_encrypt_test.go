@@ -0,0 +1,281 @@
+package transform
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+)
+
+var _ Transformer = &cryptoEncrypt{}
+
+// cryptoTestKey is the base64 encoding of "0123456789abcdef0123456789abcdef".
+const cryptoTestKey = "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="
+
+// The expected values are decrypted by crypto_decrypt because encrypted
+// values are random.
+var cryptoEncryptTests = []struct {
+	name     string
+	cfg      config.Config
+	test     []byte
+	expected [][]byte
+}{
+	{
+		"data",
+		config.Config{
+			Settings: map[string]interface{}{
+				"key": cryptoTestKey,
+			},
+		},
+		[]byte(`a`),
+		[][]byte{
+			[]byte(`a`),
+		},
+	},
+	{
+		"object",
+		config.Config{
+			Settings: map[string]interface{}{
+				"object": map[string]interface{}{
+					"source_key": "a",
+					"target_key": "a",
+				},
+				"key": cryptoTestKey,
+			},
+		},
+		[]byte(`{"a":"b"}`),
+		[][]byte{
+			[]byte(`{"a":"b"}`),
+		},
+	},
+	{
+		"object",
+		config.Config{
+			Settings: map[string]interface{}{
+				"object": map[string]interface{}{
+					"source_key": "a",
+					"target_key": "a",
+				},
+				"key": cryptoTestKey,
+			},
+		},
+		[]byte(`{"a":{"b":"c"}}`),
+		[][]byte{
+			[]byte(`{"a":{"b":"c"}}`),
+		},
+	},
+	{
+		"object number",
+		config.Config{
+			Settings: map[string]interface{}{
+				"object": map[string]interface{}{
+					"source_key": "a",
+					"target_key": "a",
+				},
+				"key": cryptoTestKey,
+			},
+		},
+		[]byte(`{"a":1.5}`),
+		[][]byte{
+			[]byte(`{"a":1.5}`),
+		},
+	},
+	{
+		"object bool",
+		config.Config{
+			Settings: map[string]interface{}{
+				"object": map[string]interface{}{
+					"source_key": "a",
+					"target_key": "a",
+				},
+				"key": cryptoTestKey,
+			},
+		},
+		[]byte(`{"a":true}`),
+		[][]byte{
+			[]byte(`{"a":true}`),
+		},
+	},
+	{
+		"object null",
+		config.Config{
+			Settings: map[string]interface{}{
+				"object": map[string]interface{}{
+					"source_key": "a",
+					"target_key": "a",
+				},
+				"key": cryptoTestKey,
+			},
+		},
+		[]byte(`{"a":null}`),
+		[][]byte{
+			[]byte(`{"a":null}`),
+		},
+	},
+	{
+		"object numeric string",
+		config.Config{
+			Settings: map[string]interface{}{
+				"object": map[string]interface{}{
+					"source_key": "a",
+					"target_key": "a",
+				},
+				"key": cryptoTestKey,
+			},
+		},
+		[]byte(`{"a":"1"}`),
+		[][]byte{
+			[]byte(`{"a":"1"}`),
+		},
+	},
+}
+
+func TestCryptoEncrypt(t *testing.T) {
+	ctx := context.TODO()
+	for _, test := range cryptoEncryptTests {
+		t.Run(test.name, func(t *testing.T) {
+			enc, err := newCryptoEncrypt(ctx, test.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			dec, err := newCryptoDecrypt(ctx, test.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			msg := message.New().SetData(test.test)
+			result, err := enc.Transform(ctx, msg)
+			if err != nil {
+				t.Error(err)
+			}
+
+			var data [][]byte
+			for _, c := range result {
+				if bytes.Equal(c.Data(), test.test) {
+					t.Errorf("expected encrypted data, got %s", c.Data())
+				}
+
+				msgs, err := dec.Transform(ctx, c)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				for _, m := range msgs {
+					data = append(data, m.Data())
+				}
+			}
+
+			if !reflect.DeepEqual(data, test.expected) {
+				t.Errorf("expected %s, got %s", test.expected, data)
+			}
+		})
+	}
+}
+
+func TestCryptoEncryptRotate(t *testing.T) {
+	ctx := context.TODO()
+	tf, err := newCryptoEncrypt(ctx, config.Config{
+		Settings: map[string]interface{}{
+			"key": cryptoTestKey,
+			"data_key": map[string]interface{}{
+				"count":    2,
+				"duration": "1m",
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	tf.now = func() time.Time { return now }
+
+	var headers [][]byte
+	for i := 0; i < 4; i++ {
+		// The last data key expires before it is used a second time.
+		if i == 3 {
+			now = now.Add(time.Minute)
+		}
+
+		result, err := tf.Transform(ctx, message.New().SetData([]byte(`a`)))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, _, n, err := cryptoParseHeader(result[0].Data())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		headers = append(headers, result[0].Data()[:n])
+	}
+
+	// The first two values share a data key, and the rest are rotated by
+	// count and duration.
+	expected := []bool{true, false, false}
+	for i, exp := range expected {
+		if got := bytes.Equal(headers[i], headers[i+1]); got != exp {
+			t.Errorf("header %d: expected equal %v, got %v", i+1, exp, got)
+		}
+	}
+}
+
+func benchmarkCryptoEncrypt(b *testing.B, tf *cryptoEncrypt, data []byte) {
+	ctx := context.TODO()
+	for i := 0; i < b.N; i++ {
+		msg := message.New().SetData(data)
+		_, _ = tf.Transform(ctx, msg)
+	}
+}
+
+func BenchmarkCryptoEncrypt(b *testing.B) {
+	for _, test := range cryptoEncryptTests {
+		tf, err := newCryptoEncrypt(context.TODO(), test.cfg)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(test.name,
+			func(b *testing.B) {
+				benchmarkCryptoEncrypt(b, tf, test.test)
+			},
+		)
+	}
+}
+
+func FuzzTestCryptoEncrypt(f *testing.F) {
+	testcases := [][]byte{
+		[]byte(`{"a":"b"}`),
+		[]byte(`{"a":1}`),
+		[]byte(`a`),
+		[]byte(``),
+	}
+
+	for _, tc := range testcases {
+		f.Add(tc)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ctx := context.TODO()
+		msg := message.New().SetData(data)
+
+		tf, err := newCryptoEncrypt(ctx, config.Config{
+			Settings: map[string]interface{}{
+				"key": cryptoTestKey,
+			},
+		})
+		if err != nil {
+			return
+		}
+
+		_, err = tf.Transform(ctx, msg)
+		if err != nil {
+			return
+		}
+	})
+}
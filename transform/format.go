@@ -3,10 +3,30 @@ package transform
 import (
 	"bytes"
 	"compress/gzip"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/linkedin/goavro/v2"
+	"github.com/parquet-go/parquet-go"
+	"github.com/tidwall/gjson"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
 
 	iconfig "github.com/brexhq/substation/v2/internal/config"
+	"github.com/brexhq/substation/v2/internal/file"
 )
 
 type formatBase64Config struct {
@@ -65,3 +85,1081 @@ func fmtFromGzip(data []byte) ([]byte, error) {
 
 	return output, nil
 }
+
+type formatToParquetConfig struct {
+	// Schema maps the top-level keys of JSON objects to Parquet column types.
+	// Supported types are "string", "boolean", "int32", "int64", "float",
+	// "double", "timestamp" (RFC 3339 strings), and "json" (nested objects and
+	// arrays).
+	//
+	// Keys that are not in the schema are not written to the file.
+	//
+	// This is optional and defaults to inferring the schema from every object
+	// in each file. Inferred files are written when they are flushed, so all
+	// objects in the file are kept in memory until then.
+	Schema map[string]string `json:"schema"`
+	// Compression is the codec used to compress columns in the file. Must be
+	// one of: none, snappy, gzip, zstd.
+	//
+	// This is optional and defaults to snappy.
+	Compression string `json:"compression"`
+
+	ID    string        `json:"id"`
+	Batch iconfig.Batch `json:"batch"`
+}
+
+func (c *formatToParquetConfig) Decode(in interface{}) error {
+	return iconfig.Decode(in, c)
+}
+
+func (c *formatToParquetConfig) Validate() error {
+	for k, v := range c.Schema {
+		if _, err := fmtParquetNode(v); err != nil {
+			return fmt.Errorf("schema %s: %v", k, err)
+		}
+	}
+
+	switch c.Compression {
+	case "", "none", "snappy", "gzip", "zstd":
+	default:
+		return fmt.Errorf("compression %s: %v", c.Compression, iconfig.ErrInvalidOption)
+	}
+
+	return nil
+}
+
+type formatFromParquetConfig struct {
+	ID string `json:"id"`
+}
+
+func (c *formatFromParquetConfig) Decode(in interface{}) error {
+	return iconfig.Decode(in, c)
+}
+
+// fmtParquetNode returns an optional Parquet column for a schema type.
+func fmtParquetNode(typ string) (parquet.Node, error) {
+	var n parquet.Node
+	switch typ {
+	case "string":
+		n = parquet.String()
+	case "boolean":
+		n = parquet.Leaf(parquet.BooleanType)
+	case "int32":
+		n = parquet.Int(32)
+	case "int64":
+		n = parquet.Int(64)
+	case "float":
+		n = parquet.Leaf(parquet.FloatType)
+	case "double":
+		n = parquet.Leaf(parquet.DoubleType)
+	case "timestamp":
+		n = parquet.Timestamp(parquet.Microsecond)
+	case "json":
+		n = parquet.JSON()
+	default:
+		return nil, fmt.Errorf("type %s: %v", typ, iconfig.ErrInvalidOption)
+	}
+
+	return parquet.Optional(n), nil
+}
+
+// fmtParquetSchema returns a Parquet schema from a map of column names to
+// schema types.
+func fmtParquetSchema(schema map[string]string) (*parquet.Schema, error) {
+	group := make(parquet.Group, len(schema))
+	for k, v := range schema {
+		n, err := fmtParquetNode(v)
+		if err != nil {
+			return nil, err
+		}
+
+		group[k] = n
+	}
+
+	return parquet.NewSchema("substation", group), nil
+}
+
+// fmtParquetInfer returns a map of column names to schema types that
+// describes every top-level key in the JSON objects. Keys that only contain
+// null values are not included. If a key contains values of different types,
+// then integers are widened to doubles, and other values are stored as
+// strings (or JSON, if any value is an object or array).
+func fmtParquetInfer(data [][]byte) map[string]string {
+	schema := make(map[string]string)
+	for _, d := range data {
+		gjson.ParseBytes(d).ForEach(func(k, v gjson.Result) bool {
+			typ := fmtParquetType(v)
+			if typ == "" {
+				return true
+			}
+
+			prev, ok := schema[k.String()]
+			switch {
+			case !ok || prev == typ:
+				schema[k.String()] = typ
+			case prev == "json" || typ == "json":
+				schema[k.String()] = "json"
+			case (prev == "int64" && typ == "double") || (prev == "double" && typ == "int64"):
+				schema[k.String()] = "double"
+			default:
+				schema[k.String()] = "string"
+			}
+
+			return true
+		})
+	}
+
+	return schema
+}
+
+// fmtParquetType returns the schema type of a JSON value. Null values return
+// an empty string.
+func fmtParquetType(v gjson.Result) string {
+	switch v.Type {
+	case gjson.String:
+		return "string"
+	case gjson.True, gjson.False:
+		return "boolean"
+	case gjson.Number:
+		if strings.ContainsAny(v.Raw, ".eE") {
+			return "double"
+		}
+
+		return "int64"
+	case gjson.JSON:
+		return "json"
+	default:
+		return ""
+	}
+}
+
+// fmtParquetRow converts a JSON object to a row that is written by the
+// Parquet schema. Keys that are not in the schema are ignored.
+func fmtParquetRow(schema map[string]string, data []byte) (map[string]any, error) {
+	res := gjson.ParseBytes(data)
+	if !res.IsObject() {
+		return nil, errMsgInvalidObject
+	}
+
+	row := make(map[string]any, len(schema))
+	var err error
+	res.ForEach(func(k, v gjson.Result) bool {
+		typ, ok := schema[k.String()]
+		if !ok || v.Type == gjson.Null {
+			return true
+		}
+
+		var val any
+		val, err = fmtParquetValue(typ, v)
+		if err != nil {
+			err = fmt.Errorf("%s: %v", k.String(), err)
+			return false
+		}
+
+		row[k.String()] = val
+		return true
+	})
+
+	return row, err
+}
+
+// fmtParquetValue converts a JSON value to the Go type of a schema type.
+// Strings are parsed if they contain a value of the schema type (e.g., "1" is
+// converted to an integer).
+func fmtParquetValue(typ string, v gjson.Result) (any, error) {
+	switch typ {
+	case "string":
+		if v.Type == gjson.String {
+			return v.Str, nil
+		}
+
+		return v.Raw, nil
+	case "boolean":
+		switch v.Type {
+		case gjson.True, gjson.False:
+			return v.Bool(), nil
+		case gjson.String:
+			return strconv.ParseBool(v.Str)
+		}
+	case "int32":
+		switch v.Type {
+		case gjson.Number:
+			return int32(v.Int()), nil
+		case gjson.String:
+			i, err := strconv.ParseInt(v.Str, 10, 32)
+			return int32(i), err
+		}
+	case "int64":
+		switch v.Type {
+		case gjson.Number:
+			return v.Int(), nil
+		case gjson.String:
+			return strconv.ParseInt(v.Str, 10, 64)
+		}
+	case "float":
+		switch v.Type {
+		case gjson.Number:
+			return float32(v.Float()), nil
+		case gjson.String:
+			f, err := strconv.ParseFloat(v.Str, 32)
+			return float32(f), err
+		}
+	case "double":
+		switch v.Type {
+		case gjson.Number:
+			return v.Float(), nil
+		case gjson.String:
+			return strconv.ParseFloat(v.Str, 64)
+		}
+	case "timestamp":
+		if v.Type == gjson.String {
+			return time.Parse(time.RFC3339Nano, v.Str)
+		}
+	case "json":
+		return v.Raw, nil
+	}
+
+	return nil, fmt.Errorf("cannot convert %s to %s", v.Type, typ)
+}
+
+// fmtFromParquet reads a Parquet file and returns each row as a JSON object.
+// The Parquet library panics on some files that are corrupt or cannot be
+// converted (e.g., empty values in MAP columns), so this recovers from panics
+// and returns an error.
+func fmtFromParquet(data []byte) (output [][]byte, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("invalid file: %v", r)
+		}
+	}()
+
+	r := bytes.NewReader(data)
+	f, err := parquet.OpenFile(r, r.Size())
+	if err != nil {
+		return nil, err
+	}
+
+	reader := parquet.NewReader(f)
+	defer reader.Close()
+
+	rows := make([]parquet.Row, 64)
+	for {
+		n, err := reader.ReadRows(rows)
+		for _, row := range rows[:n] {
+			obj := make(map[string]any)
+			if err := f.Schema().Reconstruct(&obj, row); err != nil {
+				return nil, err
+			}
+
+			b, err := json.Marshal(fmtParquetJSON(f.Schema(), obj))
+			if err != nil {
+				return nil, err
+			}
+
+			output = append(output, b)
+		}
+
+		if errors.Is(err, io.EOF) {
+			return output, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// fmtParquetJSON converts a value that was read from a Parquet file to a
+// value that is encoded as JSON. Lists are converted from their Parquet
+// encoding to arrays, timestamps are converted to RFC 3339
+// strings, and null values are removed from objects.
+func fmtParquetJSON(n parquet.Node, v any) any {
+	if v == nil {
+		return nil
+	}
+
+	// Repeated fields contain a value for each repetition.
+	if s, ok := v.([]any); ok {
+		out := make([]any, len(s))
+		for i, e := range s {
+			out[i] = fmtParquetJSON(n, e)
+		}
+
+		return out
+	}
+
+	lt := n.Type().LogicalType()
+	switch {
+	case fmtParquetIsList(n):
+		m, ok := v.(map[string]any)
+		if !ok {
+			return v
+		}
+
+		list, _ := m[n.Fields()[0].Name()].([]any)
+		elem := n.Fields()[0].Fields()[0]
+
+		out := make([]any, 0, len(list))
+		for _, item := range list {
+			if im, ok := item.(map[string]any); ok {
+				out = append(out, fmtParquetJSON(elem, im[elem.Name()]))
+			}
+		}
+
+		return out
+	case lt != nil && lt.Timestamp != nil:
+		i, ok := v.(int64)
+		if !ok {
+			return v
+		}
+
+		var t time.Time
+		switch {
+		case lt.Timestamp.Unit.Millis != nil:
+			t = time.UnixMilli(i)
+		case lt.Timestamp.Unit.Micros != nil:
+			t = time.UnixMicro(i)
+		default:
+			t = time.Unix(0, i)
+		}
+
+		return t.UTC().Format(time.RFC3339Nano)
+	case !n.Leaf():
+		m, ok := v.(map[string]any)
+		if !ok {
+			return v
+		}
+
+		for _, f := range n.Fields() {
+			val := fmtParquetJSON(f, m[f.Name()])
+			if val == nil {
+				delete(m, f.Name())
+				continue
+			}
+
+			m[f.Name()] = val
+		}
+
+		return m
+	}
+
+	return v
+}
+
+// fmtParquetIsList returns true if the node uses the Parquet LIST encoding: a
+// group that contains a single repeated group with one element. The logical
+// type of groups is not available when a schema is read from a file, so the
+// structure of the node is used instead.
+func fmtParquetIsList(n parquet.Node) bool {
+	if n.Leaf() || n.Repeated() || len(n.Fields()) != 1 {
+		return false
+	}
+
+	r := n.Fields()[0]
+	return r.Repeated() && !r.Leaf() && len(r.Fields()) == 1
+}
+
+// fmtReadSchema returns the contents of a schema file. The file can be stored
+// locally, in an HTTP(S) URL, or in AWS S3 (internal/file).
+func fmtReadSchema(ctx context.Context, location string) ([]byte, error) {
+	path, err := file.Get(ctx, location)
+	defer os.Remove(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return os.ReadFile(path)
+}
+
+type formatAvroConfig struct {
+	// Schema is the location of the Avro schema (JSON) that is used to
+	// convert data. The schema can be a local file, an HTTP(S) URL, or an
+	// AWS S3 object.
+	//
+	// This is optional for format_from_avro if the format is "ocf", which
+	// contains the schema in the file.
+	Schema string `json:"schema"`
+	// Format is the encoding of the Avro data. Must be one of:
+	//
+	// - binary: Avro binary encoding without a header.
+	//
+	// - confluent: Avro binary encoding with a Confluent Schema Registry header
+	// (a magic byte followed by a 4-byte schema ID).
+	//
+	// - ocf: Avro Object Container File. Files contain many records, so
+	// format_from_avro emits a message for each record and format_to_avro
+	// writes all messages to a file when a control message is received.
+	//
+	// This is optional and defaults to binary.
+	Format string `json:"format"`
+	// SchemaID is the schema registry ID that is written in the Confluent
+	// header. This is only used by format_to_avro if the format is "confluent".
+	SchemaID int `json:"schema_id"`
+	// Compression is the codec used to compress blocks in Object Container
+	// Files. Must be one of: null, deflate, snappy. This is only used by
+	// format_to_avro if the format is "ocf".
+	//
+	// This is optional and defaults to null (no compression).
+	Compression string `json:"compression"`
+
+	ID string `json:"id"`
+}
+
+func (c *formatAvroConfig) Decode(in interface{}) error {
+	return iconfig.Decode(in, c)
+}
+
+func (c *formatAvroConfig) Validate() error {
+	switch c.Format {
+	case "", "binary", "confluent", "ocf":
+	default:
+		return fmt.Errorf("format %s: %v", c.Format, iconfig.ErrInvalidOption)
+	}
+
+	switch c.Compression {
+	case "", goavro.CompressionNullLabel, goavro.CompressionDeflateLabel, goavro.CompressionSnappyLabel:
+	default:
+		return fmt.Errorf("compression %s: %v", c.Compression, iconfig.ErrInvalidOption)
+	}
+
+	return nil
+}
+
+// fmtAvroConfluentMagic is the first byte of data that uses the Confluent
+// Schema Registry wire format.
+const fmtAvroConfluentMagic = 0x0
+
+var errFmtAvroInvalidHeader = fmt.Errorf("invalid confluent header")
+
+// fmtAvroCodec returns a codec that converts between Avro and standard JSON.
+// Unions are represented in JSON as their value, not as an object that is
+// keyed by the type of the value.
+func fmtAvroCodec(ctx context.Context, location string) (*goavro.Codec, error) {
+	schema, err := fmtReadSchema(ctx, location)
+	if err != nil {
+		return nil, err
+	}
+
+	return goavro.NewCodecForStandardJSONFull(string(schema))
+}
+
+// fmtAvroToJSON converts a native Avro value to a JSON object. Keys are
+// sorted so that output is deterministic.
+func fmtAvroToJSON(codec *goavro.Codec, native interface{}) ([]byte, error) {
+	text, err := codec.TextualFromNative(nil, native)
+	if err != nil {
+		return nil, err
+	}
+
+	var v interface{}
+	d := json.NewDecoder(bytes.NewReader(text))
+	d.UseNumber()
+	if err := d.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(v)
+}
+
+type formatProtobufConfig struct {
+	// Schema is the location of the Protobuf file descriptor set that contains
+	// the message type (e.g., created by "protoc --descriptor_set_out" or
+	// "buf build -o"). The file can be a local file, an HTTP(S) URL, or an AWS
+	// S3 object.
+	Schema string `json:"schema"`
+	// Message is the full name of the Protobuf message type (e.g.,
+	// "example.v1.Event").
+	Message string `json:"message"`
+	// Delimited determines if Protobuf messages are length-delimited (prefixed
+	// with their size as a varint). If this is true, then format_from_protobuf
+	// emits a message for each Protobuf message in the data.
+	//
+	// This is optional and defaults to false.
+	Delimited bool `json:"delimited"`
+
+	ID string `json:"id"`
+}
+
+func (c *formatProtobufConfig) Decode(in interface{}) error {
+	return iconfig.Decode(in, c)
+}
+
+func (c *formatProtobufConfig) Validate() error {
+	if c.Schema == "" {
+		return fmt.Errorf("schema: %v", iconfig.ErrMissingRequiredOption)
+	}
+
+	if c.Message == "" {
+		return fmt.Errorf("message: %v", iconfig.ErrMissingRequiredOption)
+	}
+
+	return nil
+}
+
+// fmtProtobufType returns a Protobuf message type from a file descriptor set.
+func fmtProtobufType(ctx context.Context, location, name string) (protoreflect.MessageType, error) {
+	b, err := fmtReadSchema(ctx, location)
+	if err != nil {
+		return nil, err
+	}
+
+	set := &descriptorpb.FileDescriptorSet{}
+	if err := proto.Unmarshal(b, set); err != nil {
+		return nil, err
+	}
+
+	files, err := protodesc.NewFiles(set)
+	if err != nil {
+		return nil, err
+	}
+
+	desc, err := files.FindDescriptorByName(protoreflect.FullName(name))
+	if err != nil {
+		return nil, err
+	}
+
+	md, ok := desc.(protoreflect.MessageDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s: not a message", name)
+	}
+
+	return dynamicpb.NewMessageType(md), nil
+}
+
+// fmtProtobufToJSON converts a Protobuf message to a JSON object. The
+// protojson package adds random whitespace to its output, so the output is
+// compacted to be deterministic.
+func fmtProtobufToJSON(pb proto.Message) ([]byte, error) {
+	b, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(pb)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, b); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+type formatCSVConfig struct {
+	// Columns are the names of the columns in each row. If this is used with
+	// Header, then the header row is skipped.
+	//
+	// This is required for format_to_csv. It is optional for format_from_csv
+	// if Header is true.
+	Columns []string `json:"columns"`
+	// Header determines if the data contains a header row. In
+	// format_from_csv, the first row of each message contains the column
+	// names. In format_to_csv, a header row is written at the beginning of
+	// each batch.
+	//
+	// This is optional and defaults to false.
+	Header bool `json:"header"`
+	// Delimiter is the character that separates fields in each row (e.g., ","
+	// for CSV, "\t" for TSV).
+	//
+	// This is optional and defaults to ",".
+	Delimiter string `json:"delimiter"`
+	// LazyQuotes allows quotes to appear in unquoted fields and non-doubled
+	// quotes to appear in quoted fields. This is only used by
+	// format_from_csv.
+	//
+	// This is optional and defaults to false (quotes must follow RFC 4180).
+	LazyQuotes bool `json:"lazy_quotes"`
+
+	ID     string         `json:"id"`
+	Object iconfig.Object `json:"object"`
+	Batch  iconfig.Batch  `json:"batch"`
+}
+
+func (c *formatCSVConfig) Decode(in interface{}) error {
+	return iconfig.Decode(in, c)
+}
+
+func (c *formatCSVConfig) Validate() error {
+	if utf8.RuneCountInString(c.Delimiter) > 1 {
+		return fmt.Errorf("delimiter %s: %v", c.Delimiter, iconfig.ErrInvalidOption)
+	}
+
+	return nil
+}
+
+// delimiter returns the delimiter as a rune. The default is a comma.
+func (c *formatCSVConfig) delimiter() rune {
+	if c.Delimiter == "" {
+		return ','
+	}
+
+	r, _ := utf8.DecodeRuneInString(c.Delimiter)
+	return r
+}
+
+// fmtToCSV returns a record as a row of delimited text that ends with a
+// newline. Fields are quoted if needed.
+func fmtToCSV(delimiter rune, record []string) ([]byte, error) {
+	var buf bytes.Buffer
+
+	w := csv.NewWriter(&buf)
+	w.Comma = delimiter
+	if err := w.Write(record); err != nil {
+		return nil, err
+	}
+
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+type formatKVConfig struct {
+	// PairSeparator is the string that separates key-value pairs (e.g., " "
+	// for logfmt, "\n" for multi-line Windows logs).
+	//
+	// This is optional and defaults to " ".
+	PairSeparator string `json:"pair_separator"`
+	// FieldSeparator is the string that separates the key and the value in
+	// each pair (e.g., "=" for logfmt, ":" for Windows logs).
+	//
+	// This is optional and defaults to "=".
+	FieldSeparator string `json:"field_separator"`
+	// Quote is the character that encloses values that contain separators.
+	// Quoted values can contain escaped quotes (e.g., "\"").
+	//
+	// This is optional and defaults to a double quote ("). If this is set to
+	// "none", then values cannot be quoted.
+	Quote string `json:"quote"`
+	// UnquotedSeparators allows unquoted values to contain the pair
+	// separator. If this is true, then each value ends where the next key
+	// begins, which is required for formats like CEF extensions (e.g.,
+	// "msg=hello world src=10.0.0.1").
+	//
+	// This is optional and defaults to false.
+	UnquotedSeparators bool `json:"unquoted_separators"`
+
+	ID     string         `json:"id"`
+	Object iconfig.Object `json:"object"`
+}
+
+func (c *formatKVConfig) Decode(in interface{}) error {
+	return iconfig.Decode(in, c)
+}
+
+func (c *formatKVConfig) Validate() error {
+	if c.PairSeparator != "" && c.PairSeparator == c.FieldSeparator {
+		return fmt.Errorf("pair_separator %s: %v", c.PairSeparator, iconfig.ErrInvalidOption)
+	}
+
+	if c.Quote != "none" && utf8.RuneCountInString(c.Quote) > 1 {
+		return fmt.Errorf("quote %s: %v", c.Quote, iconfig.ErrInvalidOption)
+	}
+
+	return nil
+}
+
+// fmtKVParser parses key-value pairs from text. Backslashes escape any
+// character in keys and values, including separators and quotes.
+type fmtKVParser struct {
+	pairSep  string
+	fieldSep string
+	// quote is 0 if values cannot be quoted.
+	quote    rune
+	unquoted bool
+}
+
+func newFmtKVParser(c formatKVConfig) fmtKVParser {
+	p := fmtKVParser{
+		pairSep:  c.PairSeparator,
+		fieldSep: c.FieldSeparator,
+		quote:    '"',
+		unquoted: c.UnquotedSeparators,
+	}
+
+	if p.pairSep == "" {
+		p.pairSep = " "
+	}
+
+	if p.fieldSep == "" {
+		p.fieldSep = "="
+	}
+
+	switch c.Quote {
+	case "":
+	case "none":
+		p.quote = 0
+	default:
+		p.quote, _ = utf8.DecodeRuneInString(c.Quote)
+	}
+
+	return p
+}
+
+// Parse returns the key-value pairs in the text as a map. Keys and unquoted
+// values are trimmed of whitespace, and keys that do not have a value are
+// set to an empty string. If a key appears more than once, then the last
+// value is used.
+func (p fmtKVParser) Parse(s string) map[string]string {
+	kv := make(map[string]string)
+	for len(s) > 0 {
+		// Skip any separators before the next key.
+		for strings.HasPrefix(s, p.pairSep) {
+			s = s[len(p.pairSep):]
+		}
+
+		if len(s) == 0 {
+			break
+		}
+
+		key, rest, hasValue := p.scanKey(s)
+		s = rest
+
+		var value string
+		if hasValue {
+			value, s = p.scanValue(s)
+		}
+
+		if key = strings.TrimSpace(key); key != "" {
+			kv[key] = value
+		}
+	}
+
+	return kv
+}
+
+// scanKey returns the key at the beginning of s, the remaining text after
+// the field separator, and whether the key has a value.
+func (p fmtKVParser) scanKey(s string) (string, string, bool) {
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		switch {
+		case s[i] == '\\' && i+1 < len(s):
+			_, size := utf8.DecodeRuneInString(s[i+1:])
+			b.WriteString(s[i+1 : i+1+size])
+			i += 1 + size
+		case strings.HasPrefix(s[i:], p.fieldSep):
+			return b.String(), s[i+len(p.fieldSep):], true
+		case strings.HasPrefix(s[i:], p.pairSep):
+			return b.String(), s[i:], false
+		default:
+			b.WriteByte(s[i])
+			i++
+		}
+	}
+
+	return b.String(), "", false
+}
+
+// scanValue returns the value at the beginning of s and the remaining text.
+func (p fmtKVParser) scanValue(s string) (string, string) {
+	var b strings.Builder
+
+	trimmed := strings.TrimLeft(s, " \t")
+	if r, size := utf8.DecodeRuneInString(trimmed); p.quote != 0 && r == p.quote {
+		s = trimmed[size:]
+		for i := 0; i < len(s); {
+			r, size := utf8.DecodeRuneInString(s[i:])
+			switch {
+			case r == '\\' && i+size < len(s):
+				_, next := utf8.DecodeRuneInString(s[i+size:])
+				b.WriteString(s[i+size : i+size+next])
+				i += size + next
+			case r == p.quote:
+				return b.String(), s[i+size:]
+			default:
+				b.WriteString(s[i : i+size])
+				i += size
+			}
+		}
+
+		// The value is not terminated, so all remaining text is used.
+		return b.String(), ""
+	}
+
+	for i := 0; i < len(s); {
+		switch {
+		case s[i] == '\\' && i+1 < len(s):
+			_, size := utf8.DecodeRuneInString(s[i+1:])
+			b.WriteString(s[i+1 : i+1+size])
+			i += 1 + size
+		case strings.HasPrefix(s[i:], p.pairSep) && (!p.unquoted || p.startsPair(s[i+len(p.pairSep):])):
+			return strings.TrimSpace(b.String()), s[i:]
+		default:
+			b.WriteByte(s[i])
+			i++
+		}
+	}
+
+	return strings.TrimSpace(b.String()), ""
+}
+
+// startsPair returns true if s begins with a key that is followed by the field
+// separator. Keys cannot contain whitespace or the pair separator.
+func (p fmtKVParser) startsPair(s string) bool {
+	for i := 0; i < len(s); {
+		switch {
+		case s[i] == '\\' && i+1 < len(s):
+			i += 2
+		case strings.HasPrefix(s[i:], p.fieldSep):
+			return i > 0
+		case strings.HasPrefix(s[i:], p.pairSep) || s[i] == ' ' || s[i] == '\t':
+			return false
+		default:
+			i++
+		}
+	}
+
+	return false
+}
+
+type formatSyslogConfig struct {
+	// Location is the time zone of RFC 3164 timestamps, which do not contain a
+	// time zone (e.g., "America/New_York").
+	//
+	// This is optional and defaults to UTC.
+	Location string `json:"location"`
+
+	ID     string         `json:"id"`
+	Object iconfig.Object `json:"object"`
+}
+
+func (c *formatSyslogConfig) Decode(in interface{}) error {
+	return iconfig.Decode(in, c)
+}
+
+func (c *formatSyslogConfig) Validate() error {
+	if _, err := time.LoadLocation(c.Location); err != nil {
+		return fmt.Errorf("location %s: %v", c.Location, iconfig.ErrInvalidOption)
+	}
+
+	return nil
+}
+
+// errFmtSyslogInvalid is returned when a syslog message does not begin with a
+// priority or contains invalid RFC 5424 fields.
+var errFmtSyslogInvalid = fmt.Errorf("invalid syslog message")
+
+// fmtSyslogMessage contains the fields of a syslog message. Fields that are
+// missing from the message (or are NILVALUE in RFC 5424) are omitted.
+type fmtSyslogMessage struct {
+	Priority       int                          `json:"priority"`
+	Facility       int                          `json:"facility"`
+	Severity       int                          `json:"severity"`
+	Version        int                          `json:"version,omitempty"`
+	Timestamp      string                       `json:"timestamp,omitempty"`
+	Hostname       string                       `json:"hostname,omitempty"`
+	AppName        string                       `json:"app_name,omitempty"`
+	ProcID         string                       `json:"proc_id,omitempty"`
+	MsgID          string                       `json:"msg_id,omitempty"`
+	StructuredData map[string]map[string]string `json:"structured_data,omitempty"`
+	Message        string                       `json:"message,omitempty"`
+}
+
+// fmtFromSyslog parses an RFC 3164 or RFC 5424 syslog message. RFC 3164
+// timestamps do not contain a year or time zone, so they are converted to RFC
+// 3339 using the location and the year of now.
+func fmtFromSyslog(s string, loc *time.Location, now time.Time) (fmtSyslogMessage, error) {
+	var m fmtSyslogMessage
+
+	s = strings.TrimRight(s, "\r\n")
+	if len(s) < 3 || s[0] != '<' {
+		return m, errFmtSyslogInvalid
+	}
+
+	end := strings.IndexByte(s[:min(len(s), 5)], '>')
+	if end < 2 {
+		return m, errFmtSyslogInvalid
+	}
+
+	pri, err := strconv.Atoi(s[1:end])
+	if err != nil || pri < 0 || pri > 191 {
+		return m, errFmtSyslogInvalid
+	}
+
+	m.Priority = pri
+	m.Facility = pri / 8
+	m.Severity = pri % 8
+	s = s[end+1:]
+
+	// RFC 5424 messages have a version after the priority.
+	if i := strings.IndexByte(s, ' '); i > 0 && i <= 3 {
+		if v, err := strconv.Atoi(s[:i]); err == nil && v > 0 {
+			m.Version = v
+			if err := fmtSyslog5424(&m, s[i+1:]); err != nil {
+				return m, err
+			}
+
+			return m, nil
+		}
+	}
+
+	fmtSyslog3164(&m, s, loc, now)
+	return m, nil
+}
+
+// fmtSyslog5424 parses the header, structured data, and message of an RFC
+// 5424 message.
+func fmtSyslog5424(m *fmtSyslogMessage, s string) error {
+	var header [5]string
+	for i := range header {
+		f, rest, ok := strings.Cut(s, " ")
+		if !ok || f == "" {
+			return errFmtSyslogInvalid
+		}
+
+		if f != "-" {
+			header[i] = f
+		}
+
+		s = rest
+	}
+
+	m.Timestamp, m.Hostname, m.AppName, m.ProcID, m.MsgID = header[0], header[1], header[2], header[3], header[4]
+
+	switch {
+	case strings.HasPrefix(s, "-"):
+		s = s[1:]
+	case strings.HasPrefix(s, "["):
+		sd, rest, err := fmtSyslogStructuredData(s)
+		if err != nil {
+			return err
+		}
+
+		m.StructuredData = sd
+		s = rest
+	default:
+		return errFmtSyslogInvalid
+	}
+
+	// The message may begin with a UTF-8 byte order mark.
+	s = strings.TrimPrefix(s, " ")
+	m.Message = strings.TrimPrefix(s, "\ufeff")
+
+	return nil
+}
+
+// fmtSyslogStructuredData parses RFC 5424 structured data elements (e.g.,
+// [id@32473 key="value"]) and returns the remainder of the string.
+func fmtSyslogStructuredData(s string) (map[string]map[string]string, string, error) {
+	sd := make(map[string]map[string]string)
+	for strings.HasPrefix(s, "[") {
+		s = s[1:]
+
+		i := strings.IndexAny(s, " ]")
+		if i <= 0 {
+			return nil, "", errFmtSyslogInvalid
+		}
+
+		id := s[:i]
+		if _, ok := sd[id]; !ok {
+			sd[id] = make(map[string]string)
+		}
+
+		s = s[i:]
+		for strings.HasPrefix(s, " ") {
+			name, rest, ok := strings.Cut(s[1:], `="`)
+			if !ok || name == "" {
+				return nil, "", errFmtSyslogInvalid
+			}
+
+			// Values can contain escaped quotes, backslashes, and
+			// closing brackets.
+			var b strings.Builder
+			closed := false
+			for j := 0; j < len(rest); j++ {
+				if rest[j] == '\\' && j+1 < len(rest) && strings.IndexByte(`"\]`, rest[j+1]) != -1 {
+					b.WriteByte(rest[j+1])
+					j++
+
+					continue
+				}
+
+				if rest[j] == '"' {
+					s = rest[j+1:]
+					closed = true
+
+					break
+				}
+
+				b.WriteByte(rest[j])
+			}
+
+			if !closed {
+				return nil, "", errFmtSyslogInvalid
+			}
+
+			sd[id][name] = b.String()
+		}
+
+		if !strings.HasPrefix(s, "]") {
+			return nil, "", errFmtSyslogInvalid
+		}
+
+		s = s[1:]
+	}
+
+	return sd, s, nil
+}
+
+// fmtSyslog3164 parses the header and message of an RFC 3164 message. The
+// format is loosely defined, so the header fields are optional and any text
+// that is not part of the header is the message.
+func fmtSyslog3164(m *fmtSyslogMessage, s string, loc *time.Location, now time.Time) {
+	// Timestamps are either "Mmm dd hh:mm:ss" or RFC 3339 (which is
+	// commonly used instead of the original format).
+	if len(s) > len(time.Stamp) && s[len(time.Stamp)] == ' ' {
+		if t, err := time.ParseInLocation(time.Stamp, s[:len(time.Stamp)], loc); err == nil {
+			m.Timestamp = fmtSyslogYear(t, now.In(loc)).Format(time.RFC3339)
+			s = s[len(time.Stamp)+1:]
+		}
+	}
+
+	if m.Timestamp == "" {
+		if i := strings.IndexByte(s, ' '); i > 0 {
+			if _, err := time.Parse(time.RFC3339Nano, s[:i]); err == nil {
+				m.Timestamp = s[:i]
+				s = s[i+1:]
+			}
+		}
+	}
+
+	if m.Timestamp == "" {
+		m.Message = s
+		return
+	}
+
+	// The hostname is optional, so the first field is only the hostname if it
+	// does not look like a tag (e.g., "program:" or "program[pid]:").
+	if i := strings.IndexByte(s, ' '); i > 0 && !strings.HasSuffix(s[:i], ":") && !strings.Contains(s[:i], "[") {
+		m.Hostname = s[:i]
+		s = s[i+1:]
+	}
+
+	// The tag contains the name of the program and an optional process ID.
+	if i := strings.IndexAny(s, "[: "); i > 0 {
+		switch s[i] {
+		case '[':
+			if j := strings.IndexByte(s[i:], ']'); j > 1 {
+				m.AppName = s[:i]
+				m.ProcID = s[i+1 : i+j]
+				s = strings.TrimPrefix(s[i+j+1:], ":")
+				s = strings.TrimPrefix(s, " ")
+			}
+		case ':':
+			m.AppName = s[:i]
+			s = strings.TrimPrefix(s[i+1:], " ")
+		}
+	}
+
+	m.Message = s
+}
+
+// fmtSyslogYear adds the year to an RFC 3164 timestamp. Timestamps that are
+// more than one month in the future are from the previous year (e.g., a
+// message from December that is received in January).
+func fmtSyslogYear(t, now time.Time) time.Time {
+	t = time.Date(now.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	if t.After(now.AddDate(0, 1, 0)) {
+		t = t.AddDate(-1, 0, 0)
+	}
+
+	return t
+}
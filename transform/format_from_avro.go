@@ -0,0 +1,130 @@
+package transform
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/linkedin/goavro/v2"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+
+	iconfig "github.com/brexhq/substation/v2/internal/config"
+)
+
+func newFormatFromAvro(ctx context.Context, cfg config.Config) (*formatFromAvro, error) {
+	conf := formatAvroConfig{}
+	if err := conf.Decode(cfg.Settings); err != nil {
+		return nil, fmt.Errorf("transform format_from_avro: %v", err)
+	}
+
+	if err := conf.Validate(); err != nil {
+		return nil, fmt.Errorf("transform format_from_avro: %v", err)
+	}
+
+	// Object Container Files contain the schema that was used to write them.
+	if conf.Schema == "" && conf.Format != "ocf" {
+		return nil, fmt.Errorf("transform format_from_avro: schema: %v", iconfig.ErrMissingRequiredOption)
+	}
+
+	if conf.ID == "" {
+		conf.ID = "format_from_avro"
+	}
+
+	tf := formatFromAvro{
+		conf: conf,
+	}
+
+	if conf.Format != "ocf" {
+		codec, err := fmtAvroCodec(ctx, conf.Schema)
+		if err != nil {
+			return nil, fmt.Errorf("transform %s: %v", conf.ID, err)
+		}
+
+		tf.codec = codec
+	}
+
+	return &tf, nil
+}
+
+// formatFromAvro converts Avro data to JSON objects.
+type formatFromAvro struct {
+	conf  formatAvroConfig
+	codec *goavro.Codec
+}
+
+func (tf *formatFromAvro) Transform(ctx context.Context, msg *message.Message) ([]*message.Message, error) {
+	if msg.IsControl() {
+		return []*message.Message{msg}, nil
+	}
+
+	if tf.conf.Format == "ocf" {
+		return tf.fromOCF(msg)
+	}
+
+	data := msg.Data()
+	if tf.conf.Format == "confluent" {
+		if len(data) < 5 || data[0] != fmtAvroConfluentMagic {
+			return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, errFmtAvroInvalidHeader)
+		}
+
+		data = data[5:]
+	}
+
+	native, _, err := tf.codec.NativeFromBinary(data)
+	if err != nil {
+		return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, err)
+	}
+
+	b, err := fmtAvroToJSON(tf.codec, native)
+	if err != nil {
+		return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, err)
+	}
+
+	msg.SetData(b)
+	return []*message.Message{msg}, nil
+}
+
+// fromOCF emits a message for each record in an Object Container File.
+func (tf *formatFromAvro) fromOCF(msg *message.Message) ([]*message.Message, error) {
+	r, err := goavro.NewOCFReader(bytes.NewReader(msg.Data()))
+	if err != nil {
+		return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, err)
+	}
+
+	// The reader's codec does not convert to standard JSON.
+	codec, err := goavro.NewCodecForStandardJSONFull(r.Codec().Schema())
+	if err != nil {
+		return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, err)
+	}
+
+	meta := msg.Metadata()
+	var output []*message.Message
+
+	for r.Scan() {
+		native, err := r.Read()
+		if err != nil {
+			return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, err)
+		}
+
+		b, err := fmtAvroToJSON(codec, native)
+		if err != nil {
+			return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, err)
+		}
+
+		output = append(output, message.New().SetData(b).SetMetadata(meta))
+	}
+
+	if err := r.Err(); err != nil {
+		return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, err)
+	}
+
+	return output, nil
+}
+
+func (tf *formatFromAvro) String() string {
+	b, _ := json.Marshal(tf.conf)
+	return string(b)
+}
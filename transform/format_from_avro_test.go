@@ -0,0 +1,167 @@
+package transform
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+)
+
+var _ Transformer = &formatFromAvro{}
+
+var formatFromAvroTests = []struct {
+	name     string
+	cfg      config.Config
+	test     []byte
+	expected [][]byte
+	err      bool
+}{
+	{
+		"binary",
+		config.Config{},
+		[]byte{2, 99, 2, 2},
+		[][]byte{
+			[]byte(`{"a":"c","b":1}`),
+		},
+		false,
+	},
+	{
+		"binary null",
+		config.Config{},
+		[]byte{2, 99, 0},
+		[][]byte{
+			[]byte(`{"a":"c","b":null}`),
+		},
+		false,
+	},
+	{
+		"confluent",
+		config.Config{
+			Settings: map[string]interface{}{
+				"format": "confluent",
+			},
+		},
+		[]byte{0, 0, 0, 1, 2, 2, 99, 2, 2},
+		[][]byte{
+			[]byte(`{"a":"c","b":1}`),
+		},
+		false,
+	},
+	{
+		"confluent invalid header",
+		config.Config{
+			Settings: map[string]interface{}{
+				"format": "confluent",
+			},
+		},
+		[]byte{2, 99, 2, 2},
+		nil,
+		true,
+	},
+}
+
+func TestFormatFromAvro(t *testing.T) {
+	ctx := context.TODO()
+	schema := formatAvroTestSchemaFile(t)
+
+	for _, test := range formatFromAvroTests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.cfg.Settings == nil {
+				test.cfg.Settings = map[string]interface{}{}
+			}
+			test.cfg.Settings["schema"] = schema
+
+			msg := message.New().SetData(test.test)
+
+			tf, err := newFormatFromAvro(ctx, test.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := tf.Transform(ctx, msg)
+			if (err != nil) != test.err {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var data [][]byte
+			for _, c := range result {
+				data = append(data, c.Data())
+			}
+
+			if !reflect.DeepEqual(data, test.expected) {
+				t.Errorf("expected %s, got %s", test.expected, data)
+			}
+		})
+	}
+}
+
+func TestFormatFromAvroMissingSchema(t *testing.T) {
+	if _, err := newFormatFromAvro(context.TODO(), config.Config{}); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func benchmarkFormatFromAvro(b *testing.B, tf *formatFromAvro, data []byte) {
+	ctx := context.TODO()
+	for i := 0; i < b.N; i++ {
+		msg := message.New().SetData(data)
+		_, _ = tf.Transform(ctx, msg)
+	}
+}
+
+func BenchmarkFormatFromAvro(b *testing.B) {
+	schema := formatAvroTestSchemaFile(b)
+
+	for _, test := range formatFromAvroTests {
+		if test.cfg.Settings == nil {
+			test.cfg.Settings = map[string]interface{}{}
+		}
+		test.cfg.Settings["schema"] = schema
+
+		tf, err := newFormatFromAvro(context.TODO(), test.cfg)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(test.name,
+			func(b *testing.B) {
+				benchmarkFormatFromAvro(b, tf, test.test)
+			},
+		)
+	}
+}
+
+func FuzzTestFormatFromAvro(f *testing.F) {
+	testcases := [][]byte{
+		{2, 99, 2, 2},
+		{0, 0, 0, 1, 2, 2, 99, 2, 2},
+		[]byte(`Obj`),
+		[]byte(``),
+	}
+
+	for _, tc := range testcases {
+		f.Add(tc)
+	}
+
+	schema := formatAvroTestSchemaFile(f)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ctx := context.TODO()
+
+		for _, format := range []string{"binary", "confluent", "ocf"} {
+			tf, err := newFormatFromAvro(ctx, config.Config{
+				Settings: map[string]interface{}{
+					"schema": schema,
+					"format": format,
+				},
+			})
+			if err != nil {
+				return
+			}
+
+			_, _ = tf.Transform(ctx, message.New().SetData(data))
+		}
+	})
+}
@@ -0,0 +1,144 @@
+package transform
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+
+	iconfig "github.com/brexhq/substation/v2/internal/config"
+)
+
+func newFormatFromCSV(_ context.Context, cfg config.Config) (*formatFromCSV, error) {
+	conf := formatCSVConfig{}
+	if err := conf.Decode(cfg.Settings); err != nil {
+		return nil, fmt.Errorf("transform format_from_csv: %v", err)
+	}
+
+	if conf.ID == "" {
+		conf.ID = "format_from_csv"
+	}
+
+	if err := conf.Validate(); err != nil {
+		return nil, fmt.Errorf("transform %s: %v", conf.ID, err)
+	}
+
+	if len(conf.Columns) == 0 && !conf.Header {
+		return nil, fmt.Errorf("transform %s: columns: %v", conf.ID, iconfig.ErrMissingRequiredOption)
+	}
+
+	tf := formatFromCSV{
+		conf:      conf,
+		hasObjSrc: conf.Object.SourceKey != "",
+		hasObjTrg: conf.Object.TargetKey != "",
+	}
+
+	return &tf, nil
+}
+
+// formatFromCSV converts delimited text (e.g., CSV, TSV) to JSON objects. If
+// the text contains multiple rows, then a message is emitted for each row.
+// All values are strings.
+type formatFromCSV struct {
+	conf      formatCSVConfig
+	hasObjSrc bool
+	hasObjTrg bool
+}
+
+func (tf *formatFromCSV) Transform(ctx context.Context, msg *message.Message) ([]*message.Message, error) {
+	if msg.IsControl() {
+		return []*message.Message{msg}, nil
+	}
+
+	var value message.Value
+	if tf.hasObjSrc {
+		value = msg.GetValue(tf.conf.Object.SourceKey)
+	} else {
+		value = bytesToValue(msg.Data())
+	}
+
+	if !value.Exists() {
+		return []*message.Message{msg}, nil
+	}
+
+	r := csv.NewReader(bytes.NewReader(value.Bytes()))
+	r.Comma = tf.conf.delimiter()
+	r.LazyQuotes = tf.conf.LazyQuotes
+	r.ReuseRecord = true
+
+	columns := tf.conf.Columns
+	if tf.conf.Header {
+		header, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			return nil, nil
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, err)
+		}
+
+		if len(columns) == 0 {
+			columns = append([]string(nil), header...)
+		}
+	}
+
+	// Every row must have a value for each column.
+	r.FieldsPerRecord = len(columns)
+
+	var output []*message.Message
+	for {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, err)
+		}
+
+		row := make(map[string]string, len(columns))
+		for i, col := range columns {
+			row[col] = record[i]
+		}
+
+		outMsg, err := tf.setRow(msg, row)
+		if err != nil {
+			return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, err)
+		}
+
+		output = append(output, outMsg)
+	}
+
+	return output, nil
+}
+
+// setRow returns a message that contains the row. If the row is written to a
+// target key, then the message is a copy of the original message.
+func (tf *formatFromCSV) setRow(msg *message.Message, row map[string]string) (*message.Message, error) {
+	if !tf.hasObjTrg {
+		b, err := json.Marshal(row)
+		if err != nil {
+			return nil, err
+		}
+
+		return message.New().SetData(b).SetMetadata(msg.Metadata()), nil
+	}
+
+	outMsg := message.New().SetData(msg.Data()).SetMetadata(msg.Metadata())
+	if err := outMsg.SetValue(tf.conf.Object.TargetKey, row); err != nil {
+		return nil, err
+	}
+
+	return outMsg, nil
+}
+
+func (tf *formatFromCSV) String() string {
+	b, _ := json.Marshal(tf.conf)
+	return string(b)
+}
@@ -0,0 +1,173 @@
+package transform
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+)
+
+var _ Transformer = &formatFromCSV{}
+
+var formatFromCSVTests = []struct {
+	name     string
+	cfg      config.Config
+	test     []byte
+	expected [][]byte
+}{
+	{
+		"columns",
+		config.Config{
+			Settings: map[string]interface{}{
+				"columns": []string{"a", "b"},
+			},
+		},
+		[]byte(`c,"d,e"`),
+		[][]byte{
+			[]byte(`{"a":"c","b":"d,e"}`),
+		},
+	},
+	{
+		"header",
+		config.Config{
+			Settings: map[string]interface{}{
+				"header": true,
+			},
+		},
+		[]byte("a,b\nc,d\ne,\"f \"\"g\"\"\"\n"),
+		[][]byte{
+			[]byte(`{"a":"c","b":"d"}`),
+			[]byte(`{"a":"e","b":"f \"g\""}`),
+		},
+	},
+	{
+		"tsv",
+		config.Config{
+			Settings: map[string]interface{}{
+				"columns":     []string{"a", "b"},
+				"delimiter":   "\t",
+				"lazy_quotes": true,
+			},
+		},
+		[]byte("c\td \"e\""),
+		[][]byte{
+			[]byte(`{"a":"c","b":"d \"e\""}`),
+		},
+	},
+	{
+		"object",
+		config.Config{
+			Settings: map[string]interface{}{
+				"object": map[string]interface{}{
+					"source_key": "a",
+					"target_key": "b",
+				},
+				"columns": []string{"c", "d"},
+			},
+		},
+		[]byte(`{"a":"e,f"}`),
+		[][]byte{
+			[]byte(`{"a":"e,f","b":{"c":"e","d":"f"}}`),
+		},
+	},
+}
+
+func TestFormatFromCSV(t *testing.T) {
+	ctx := context.TODO()
+	for _, test := range formatFromCSVTests {
+		t.Run(test.name, func(t *testing.T) {
+			msg := message.New().SetData(test.test)
+
+			tf, err := newFormatFromCSV(ctx, test.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := tf.Transform(ctx, msg)
+			if err != nil {
+				t.Error(err)
+			}
+
+			var data [][]byte
+			for _, c := range result {
+				data = append(data, c.Data())
+			}
+
+			if !reflect.DeepEqual(data, test.expected) {
+				t.Errorf("expected %s, got %s", test.expected, data)
+			}
+		})
+	}
+}
+
+func TestFormatFromCSVFieldCount(t *testing.T) {
+	ctx := context.TODO()
+	tf, err := newFormatFromCSV(ctx, config.Config{
+		Settings: map[string]interface{}{
+			"columns": []string{"a", "b"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := tf.Transform(ctx, message.New().SetData([]byte(`c,d,e`))); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func benchmarkFormatFromCSV(b *testing.B, tf *formatFromCSV, data []byte) {
+	ctx := context.TODO()
+	for i := 0; i < b.N; i++ {
+		msg := message.New().SetData(data)
+		_, _ = tf.Transform(ctx, msg)
+	}
+}
+
+func BenchmarkFormatFromCSV(b *testing.B) {
+	for _, test := range formatFromCSVTests {
+		tf, err := newFormatFromCSV(context.TODO(), test.cfg)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(test.name,
+			func(b *testing.B) {
+				benchmarkFormatFromCSV(b, tf, test.test)
+			},
+		)
+	}
+}
+
+func FuzzTestFormatFromCSV(f *testing.F) {
+	testcases := [][]byte{
+		[]byte("a,b\nc,d"),
+		[]byte(`"a`),
+		[]byte(``),
+	}
+
+	for _, tc := range testcases {
+		f.Add(tc)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ctx := context.TODO()
+		msg := message.New().SetData(data)
+
+		tf, err := newFormatFromCSV(ctx, config.Config{
+			Settings: map[string]interface{}{
+				"header": true,
+			},
+		})
+		if err != nil {
+			return
+		}
+
+		_, err = tf.Transform(ctx, msg)
+		if err != nil {
+			return
+		}
+	})
+}
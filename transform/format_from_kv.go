@@ -0,0 +1,84 @@
+package transform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+)
+
+func newFormatFromKV(_ context.Context, cfg config.Config) (*formatFromKV, error) {
+	conf := formatKVConfig{}
+	if err := conf.Decode(cfg.Settings); err != nil {
+		return nil, fmt.Errorf("transform format_from_kv: %v", err)
+	}
+
+	if conf.ID == "" {
+		conf.ID = "format_from_kv"
+	}
+
+	if err := conf.Validate(); err != nil {
+		return nil, fmt.Errorf("transform %s: %v", conf.ID, err)
+	}
+
+	tf := formatFromKV{
+		conf:      conf,
+		hasObjSrc: conf.Object.SourceKey != "",
+		hasObjTrg: conf.Object.TargetKey != "",
+		parser:    newFmtKVParser(conf),
+	}
+
+	return &tf, nil
+}
+
+// formatFromKV converts key-value pairs (e.g., logfmt, CEF extensions) to a
+// JSON object. All values are strings.
+type formatFromKV struct {
+	conf      formatKVConfig
+	hasObjSrc bool
+	hasObjTrg bool
+
+	parser fmtKVParser
+}
+
+func (tf *formatFromKV) Transform(ctx context.Context, msg *message.Message) ([]*message.Message, error) {
+	if msg.IsControl() {
+		return []*message.Message{msg}, nil
+	}
+
+	var value message.Value
+	if tf.hasObjSrc {
+		value = msg.GetValue(tf.conf.Object.SourceKey)
+	} else {
+		value = bytesToValue(msg.Data())
+	}
+
+	if !value.Exists() {
+		return []*message.Message{msg}, nil
+	}
+
+	kv := tf.parser.Parse(value.String())
+
+	if tf.hasObjTrg {
+		if err := msg.SetValue(tf.conf.Object.TargetKey, kv); err != nil {
+			return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, err)
+		}
+
+		return []*message.Message{msg}, nil
+	}
+
+	b, err := json.Marshal(kv)
+	if err != nil {
+		return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, err)
+	}
+
+	msg.SetData(b)
+	return []*message.Message{msg}, nil
+}
+
+func (tf *formatFromKV) String() string {
+	b, _ := json.Marshal(tf.conf)
+	return string(b)
+}
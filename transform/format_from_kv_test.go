@@ -0,0 +1,146 @@
+package transform
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+)
+
+var _ Transformer = &formatFromKV{}
+
+var formatFromKVTests = []struct {
+	name     string
+	cfg      config.Config
+	test     []byte
+	expected []byte
+}{
+	{
+		"logfmt",
+		config.Config{},
+		[]byte(`level=info msg="hello \"world\"" duration=1.5s  empty= flag`),
+		[]byte(`{"duration":"1.5s","empty":"","flag":"","level":"info","msg":"hello \"world\""}`),
+	},
+	{
+		"cef extension",
+		config.Config{
+			Settings: map[string]interface{}{
+				"quote":               "none",
+				"unquoted_separators": true,
+			},
+		},
+		[]byte(`src=10.0.0.1 msg=Detected a threat. No action needed. eq=a\=b dst=10.0.0.2`),
+		[]byte(`{"dst":"10.0.0.2","eq":"a=b","msg":"Detected a threat. No action needed.","src":"10.0.0.1"}`),
+	},
+	{
+		"windows",
+		config.Config{
+			Settings: map[string]interface{}{
+				"pair_separator":  "\n",
+				"field_separator": ":",
+			},
+		},
+		[]byte("Account Name:\tadmin\r\nLogon Type:  3\r\nDate: 2024-01-01 00:00:00\r\n"),
+		[]byte(`{"Account Name":"admin","Date":"2024-01-01 00:00:00","Logon Type":"3"}`),
+	},
+	{
+		"object",
+		config.Config{
+			Settings: map[string]interface{}{
+				"object": map[string]interface{}{
+					"source_key": "a",
+					"target_key": "b",
+				},
+				"pair_separator":  ";",
+				"field_separator": ":",
+				"quote":           "'",
+			},
+		},
+		[]byte(`{"a":"c:d;e:'f;g'"}`),
+		[]byte(`{"a":"c:d;e:'f;g'","b":{"c":"d","e":"f;g"}}`),
+	},
+}
+
+func TestFormatFromKV(t *testing.T) {
+	ctx := context.TODO()
+	for _, test := range formatFromKVTests {
+		t.Run(test.name, func(t *testing.T) {
+			msg := message.New().SetData(test.test)
+
+			tf, err := newFormatFromKV(ctx, test.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := tf.Transform(ctx, msg)
+			if err != nil {
+				t.Error(err)
+			}
+
+			c := result[0].Data()
+			if !reflect.DeepEqual(c, test.expected) {
+				t.Errorf("expected %s, got %s", test.expected, c)
+			}
+		})
+	}
+}
+
+func benchmarkFormatFromKV(b *testing.B, tf *formatFromKV, data []byte) {
+	ctx := context.TODO()
+	for i := 0; i < b.N; i++ {
+		msg := message.New().SetData(data)
+		_, _ = tf.Transform(ctx, msg)
+	}
+}
+
+func BenchmarkFormatFromKV(b *testing.B) {
+	for _, test := range formatFromKVTests {
+		tf, err := newFormatFromKV(context.TODO(), test.cfg)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(test.name,
+			func(b *testing.B) {
+				benchmarkFormatFromKV(b, tf, test.test)
+			},
+		)
+	}
+}
+
+func FuzzTestFormatFromKV(f *testing.F) {
+	testcases := [][]byte{
+		[]byte(`a=b c="d e"`),
+		[]byte(`a=b\`),
+		[]byte(`a="b`),
+		[]byte(`=`),
+		[]byte(``),
+	}
+
+	for _, tc := range testcases {
+		f.Add(tc)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ctx := context.TODO()
+		msg := message.New().SetData(data)
+
+		for _, unquoted := range []bool{false, true} {
+			tf, err := newFormatFromKV(ctx, config.Config{
+				Settings: map[string]interface{}{
+					"unquoted_separators": unquoted,
+				},
+			})
+			if err != nil {
+				return
+			}
+
+			_, err = tf.Transform(ctx, msg)
+			if err != nil {
+				return
+			}
+		}
+	})
+}
@@ -0,0 +1,57 @@
+package transform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+)
+
+func newFormatFromParquet(_ context.Context, cfg config.Config) (*formatFromParquet, error) {
+	conf := formatFromParquetConfig{}
+	if err := conf.Decode(cfg.Settings); err != nil {
+		return nil, fmt.Errorf("transform format_from_parquet: %v", err)
+	}
+
+	if conf.ID == "" {
+		conf.ID = "format_from_parquet"
+	}
+
+	tf := formatFromParquet{
+		conf: conf,
+	}
+
+	return &tf, nil
+}
+
+// formatFromParquet reads a Parquet file and emits each row as a JSON object.
+// Null values are not included in the object.
+type formatFromParquet struct {
+	conf formatFromParquetConfig
+}
+
+func (tf *formatFromParquet) Transform(ctx context.Context, msg *message.Message) ([]*message.Message, error) {
+	if msg.IsControl() {
+		return []*message.Message{msg}, nil
+	}
+
+	rows, err := fmtFromParquet(msg.Data())
+	if err != nil {
+		return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, err)
+	}
+
+	meta := msg.Metadata()
+	output := make([]*message.Message, 0, len(rows))
+	for _, row := range rows {
+		output = append(output, message.New().SetData(row).SetMetadata(meta))
+	}
+
+	return output, nil
+}
+
+func (tf *formatFromParquet) String() string {
+	b, _ := json.Marshal(tf.conf)
+	return string(b)
+}
@@ -0,0 +1,137 @@
+package transform
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/parquet-go/parquet-go"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+)
+
+var _ Transformer = &formatFromParquet{}
+
+type formatFromParquetRow struct {
+	A string  `parquet:"a"`
+	B *int64  `parquet:"b,optional"`
+	C []int64 `parquet:"c,list"`
+}
+
+func formatFromParquetFile(t testing.TB, rows []formatFromParquetRow) []byte {
+	var buf bytes.Buffer
+	if err := parquet.Write(&buf, rows); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestFormatFromParquet(t *testing.T) {
+	ctx := context.TODO()
+
+	one := int64(1)
+	tests := []struct {
+		name     string
+		cfg      config.Config
+		test     []formatFromParquetRow
+		expected [][]byte
+	}{
+		{
+			"data",
+			config.Config{},
+			[]formatFromParquetRow{
+				{A: "x", B: &one, C: []int64{1, 2}},
+				{A: "y"},
+			},
+			[][]byte{
+				[]byte(`{"a":"x","b":1,"c":[1,2]}`),
+				[]byte(`{"a":"y","c":[]}`),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			msg := message.New().SetData(formatFromParquetFile(t, test.test))
+
+			tf, err := newFormatFromParquet(ctx, test.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := tf.Transform(ctx, msg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var data [][]byte
+			for _, c := range result {
+				data = append(data, c.Data())
+			}
+
+			if !reflect.DeepEqual(data, test.expected) {
+				t.Errorf("expected %s, got %s", test.expected, data)
+			}
+		})
+	}
+}
+
+func TestFormatFromParquetInvalid(t *testing.T) {
+	ctx := context.TODO()
+
+	tf, err := newFormatFromParquet(ctx, config.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := tf.Transform(ctx, message.New().SetData([]byte(`{"a":"b"}`))); err == nil {
+		t.Error("expected error")
+	}
+
+	// Empty maps cannot be converted to objects.
+	var buf bytes.Buffer
+	if err := parquet.Write(&buf, []struct {
+		A map[string]int64 `parquet:"a"`
+	}{{A: map[string]int64{"b": 1}}, {}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := tf.Transform(ctx, message.New().SetData(buf.Bytes())); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func BenchmarkFormatFromParquet(b *testing.B) {
+	ctx := context.TODO()
+	tf, err := newFormatFromParquet(ctx, config.Config{})
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	data := formatFromParquetFile(b, []formatFromParquetRow{{A: "x"}, {A: "y"}})
+	for i := 0; i < b.N; i++ {
+		msg := message.New().SetData(data)
+		_, _ = tf.Transform(ctx, msg)
+	}
+}
+
+func FuzzTestFormatFromParquet(f *testing.F) {
+	f.Add(formatFromParquetFile(f, []formatFromParquetRow{{A: "x"}}))
+	f.Add([]byte(`PAR1`))
+	f.Add([]byte(``))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ctx := context.TODO()
+		msg := message.New().SetData(data)
+
+		tf, err := newFormatFromParquet(ctx, config.Config{})
+		if err != nil {
+			return
+		}
+
+		_, _ = tf.Transform(ctx, msg)
+	})
+}
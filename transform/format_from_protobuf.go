@@ -0,0 +1,102 @@
+package transform
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"google.golang.org/protobuf/encoding/protodelim"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+)
+
+func newFormatFromProtobuf(ctx context.Context, cfg config.Config) (*formatFromProtobuf, error) {
+	conf := formatProtobufConfig{}
+	if err := conf.Decode(cfg.Settings); err != nil {
+		return nil, fmt.Errorf("transform format_from_protobuf: %v", err)
+	}
+
+	if err := conf.Validate(); err != nil {
+		return nil, fmt.Errorf("transform format_from_protobuf: %v", err)
+	}
+
+	if conf.ID == "" {
+		conf.ID = "format_from_protobuf"
+	}
+
+	tf := formatFromProtobuf{
+		conf: conf,
+	}
+
+	typ, err := fmtProtobufType(ctx, conf.Schema, conf.Message)
+	if err != nil {
+		return nil, fmt.Errorf("transform %s: %v", conf.ID, err)
+	}
+	tf.typ = typ
+
+	return &tf, nil
+}
+
+// formatFromProtobuf converts Protobuf messages to JSON objects. Fields use
+// the names from the Protobuf schema.
+type formatFromProtobuf struct {
+	conf formatProtobufConfig
+	typ  protoreflect.MessageType
+}
+
+func (tf *formatFromProtobuf) Transform(ctx context.Context, msg *message.Message) ([]*message.Message, error) {
+	if msg.IsControl() {
+		return []*message.Message{msg}, nil
+	}
+
+	if !tf.conf.Delimited {
+		pb := tf.typ.New().Interface()
+		if err := proto.Unmarshal(msg.Data(), pb); err != nil {
+			return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, err)
+		}
+
+		b, err := fmtProtobufToJSON(pb)
+		if err != nil {
+			return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, err)
+		}
+
+		msg.SetData(b)
+		return []*message.Message{msg}, nil
+	}
+
+	meta := msg.Metadata()
+	var output []*message.Message
+
+	r := bytes.NewReader(msg.Data())
+	for {
+		pb := tf.typ.New().Interface()
+		err := protodelim.UnmarshalFrom(r, pb)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, err)
+		}
+
+		b, err := fmtProtobufToJSON(pb)
+		if err != nil {
+			return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, err)
+		}
+
+		output = append(output, message.New().SetData(b).SetMetadata(meta))
+	}
+
+	return output, nil
+}
+
+func (tf *formatFromProtobuf) String() string {
+	b, _ := json.Marshal(tf.conf)
+	return string(b)
+}
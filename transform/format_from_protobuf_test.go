@@ -0,0 +1,142 @@
+package transform
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+)
+
+var _ Transformer = &formatFromProtobuf{}
+
+var formatFromProtobufTests = []struct {
+	name     string
+	cfg      config.Config
+	test     []byte
+	expected [][]byte
+}{
+	{
+		"data",
+		config.Config{},
+		[]byte{10, 1, 97, 16, 1, 26, 1, 98, 26, 1, 99},
+		[][]byte{
+			[]byte(`{"name":"a","count":1,"tags":["b","c"]}`),
+		},
+	},
+	{
+		"delimited",
+		config.Config{
+			Settings: map[string]interface{}{
+				"delimited": true,
+			},
+		},
+		[]byte{5, 10, 1, 97, 16, 1, 3, 10, 1, 98},
+		[][]byte{
+			[]byte(`{"name":"a","count":1}`),
+			[]byte(`{"name":"b"}`),
+		},
+	},
+}
+
+func TestFormatFromProtobuf(t *testing.T) {
+	ctx := context.TODO()
+	schema := formatProtobufTestSchemaFile(t)
+
+	for _, test := range formatFromProtobufTests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.cfg.Settings == nil {
+				test.cfg.Settings = map[string]interface{}{}
+			}
+			test.cfg.Settings["schema"] = schema
+			test.cfg.Settings["message"] = "test.Event"
+
+			msg := message.New().SetData(test.test)
+
+			tf, err := newFormatFromProtobuf(ctx, test.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := tf.Transform(ctx, msg)
+			if err != nil {
+				t.Error(err)
+			}
+
+			var data [][]byte
+			for _, c := range result {
+				data = append(data, c.Data())
+			}
+
+			if !reflect.DeepEqual(data, test.expected) {
+				t.Errorf("expected %s, got %s", test.expected, data)
+			}
+		})
+	}
+}
+
+func benchmarkFormatFromProtobuf(b *testing.B, tf *formatFromProtobuf, data []byte) {
+	ctx := context.TODO()
+	for i := 0; i < b.N; i++ {
+		msg := message.New().SetData(data)
+		_, _ = tf.Transform(ctx, msg)
+	}
+}
+
+func BenchmarkFormatFromProtobuf(b *testing.B) {
+	schema := formatProtobufTestSchemaFile(b)
+
+	for _, test := range formatFromProtobufTests {
+		if test.cfg.Settings == nil {
+			test.cfg.Settings = map[string]interface{}{}
+		}
+		test.cfg.Settings["schema"] = schema
+		test.cfg.Settings["message"] = "test.Event"
+
+		tf, err := newFormatFromProtobuf(context.TODO(), test.cfg)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(test.name,
+			func(b *testing.B) {
+				benchmarkFormatFromProtobuf(b, tf, test.test)
+			},
+		)
+	}
+}
+
+func FuzzTestFormatFromProtobuf(f *testing.F) {
+	testcases := [][]byte{
+		{10, 1, 97, 16, 1},
+		{5, 10, 1, 97, 16, 1},
+		[]byte(`a`),
+		[]byte(``),
+	}
+
+	for _, tc := range testcases {
+		f.Add(tc)
+	}
+
+	schema := formatProtobufTestSchemaFile(f)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ctx := context.TODO()
+
+		for _, delimited := range []bool{false, true} {
+			tf, err := newFormatFromProtobuf(ctx, config.Config{
+				Settings: map[string]interface{}{
+					"schema":    schema,
+					"message":   "test.Event",
+					"delimited": delimited,
+				},
+			})
+			if err != nil {
+				return
+			}
+
+			_, _ = tf.Transform(ctx, message.New().SetData(data))
+		}
+	})
+}
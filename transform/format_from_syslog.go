@@ -0,0 +1,93 @@
+package transform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+)
+
+func newFormatFromSyslog(_ context.Context, cfg config.Config) (*formatFromSyslog, error) {
+	conf := formatSyslogConfig{}
+	if err := conf.Decode(cfg.Settings); err != nil {
+		return nil, fmt.Errorf("transform format_from_syslog: %v", err)
+	}
+
+	if conf.ID == "" {
+		conf.ID = "format_from_syslog"
+	}
+
+	if err := conf.Validate(); err != nil {
+		return nil, fmt.Errorf("transform %s: %v", conf.ID, err)
+	}
+
+	loc, err := time.LoadLocation(conf.Location)
+	if err != nil {
+		return nil, fmt.Errorf("transform %s: %v", conf.ID, err)
+	}
+
+	tf := formatFromSyslog{
+		conf:      conf,
+		hasObjSrc: conf.Object.SourceKey != "",
+		hasObjTrg: conf.Object.TargetKey != "",
+		loc:       loc,
+	}
+
+	return &tf, nil
+}
+
+// formatFromSyslog converts RFC 3164 and RFC 5424 syslog messages to a JSON
+// object.
+type formatFromSyslog struct {
+	conf      formatSyslogConfig
+	hasObjSrc bool
+	hasObjTrg bool
+
+	loc *time.Location
+}
+
+func (tf *formatFromSyslog) Transform(ctx context.Context, msg *message.Message) ([]*message.Message, error) {
+	if msg.IsControl() {
+		return []*message.Message{msg}, nil
+	}
+
+	var value message.Value
+	if tf.hasObjSrc {
+		value = msg.GetValue(tf.conf.Object.SourceKey)
+	} else {
+		value = bytesToValue(msg.Data())
+	}
+
+	if !value.Exists() {
+		return []*message.Message{msg}, nil
+	}
+
+	m, err := fmtFromSyslog(value.String(), tf.loc, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, err)
+	}
+
+	if tf.hasObjTrg {
+		if err := msg.SetValue(tf.conf.Object.TargetKey, m); err != nil {
+			return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, err)
+		}
+
+		return []*message.Message{msg}, nil
+	}
+
+	b, err := json.Marshal(m)
+	if err != nil {
+		return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, err)
+	}
+
+	msg.SetData(b)
+	return []*message.Message{msg}, nil
+}
+
+func (tf *formatFromSyslog) String() string {
+	b, _ := json.Marshal(tf.conf)
+	return string(b)
+}
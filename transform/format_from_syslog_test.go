@@ -0,0 +1,233 @@
+package transform
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+)
+
+var _ Transformer = &formatFromSyslog{}
+
+var formatFromSyslogTests = []struct {
+	name     string
+	cfg      config.Config
+	test     []byte
+	expected []byte
+}{
+	{
+		"rfc5424",
+		config.Config{},
+		[]byte(`<165>1 2003-10-11T22:14:15.003Z mymachine.example.com evntslog - ID47 [exampleSDID@32473 iut="3" eventSource="Application" eventID="1011"][examplePriority@32473 class="high"] An application event log entry...`),
+		[]byte(`{"priority":165,"facility":20,"severity":5,"version":1,"timestamp":"2003-10-11T22:14:15.003Z","hostname":"mymachine.example.com","app_name":"evntslog","msg_id":"ID47","structured_data":{"examplePriority@32473":{"class":"high"},"exampleSDID@32473":{"eventID":"1011","eventSource":"Application","iut":"3"}},"message":"An application event log entry..."}`),
+	},
+	{
+		"rfc5424 escaped structured data",
+		config.Config{},
+		[]byte(`<13>1 - - app 1234 - [meta key="a \"quoted\" \] value"]`),
+		[]byte(`{"priority":13,"facility":1,"severity":5,"version":1,"app_name":"app","proc_id":"1234","structured_data":{"meta":{"key":"a \"quoted\" ] value"}}}`),
+	},
+	{
+		"rfc5424 byte order mark",
+		config.Config{},
+		[]byte("<34>1 2003-10-11T22:14:15.003Z mymachine.example.com su - ID47 - \ufeff'su root' failed for lonvick on /dev/pts/8"),
+		[]byte(`{"priority":34,"facility":4,"severity":2,"version":1,"timestamp":"2003-10-11T22:14:15.003Z","hostname":"mymachine.example.com","app_name":"su","msg_id":"ID47","message":"'su root' failed for lonvick on /dev/pts/8"}`),
+	},
+	{
+		"rfc3164 rfc3339 timestamp",
+		config.Config{},
+		[]byte(`<13>2024-01-02T03:04:05Z router1 sshd[2001]: Accepted publickey for admin`),
+		[]byte(`{"priority":13,"facility":1,"severity":5,"timestamp":"2024-01-02T03:04:05Z","hostname":"router1","app_name":"sshd","proc_id":"2001","message":"Accepted publickey for admin"}`),
+	},
+	{
+		"rfc3164 no timestamp",
+		config.Config{},
+		[]byte(`<13>hello world`),
+		[]byte(`{"priority":13,"facility":1,"severity":5,"message":"hello world"}`),
+	},
+	{
+		"object",
+		config.Config{
+			Settings: map[string]interface{}{
+				"object": map[string]interface{}{
+					"source_key": "a",
+					"target_key": "b",
+				},
+			},
+		},
+		[]byte(`{"a":"<13>2024-01-02T03:04:05Z firewall: blocked"}`),
+		[]byte(`{"a":"<13>2024-01-02T03:04:05Z firewall: blocked","b":{"priority":13,"facility":1,"severity":5,"timestamp":"2024-01-02T03:04:05Z","app_name":"firewall","message":"blocked"}}`),
+	},
+}
+
+func TestFormatFromSyslog(t *testing.T) {
+	ctx := context.TODO()
+	for _, test := range formatFromSyslogTests {
+		t.Run(test.name, func(t *testing.T) {
+			msg := message.New().SetData(test.test)
+
+			tf, err := newFormatFromSyslog(ctx, test.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := tf.Transform(ctx, msg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			c := result[0].Data()
+			if !reflect.DeepEqual(c, test.expected) {
+				t.Errorf("expected %s, got %s", test.expected, c)
+			}
+		})
+	}
+}
+
+var fmtFromSyslogRFC3164Tests = []struct {
+	name     string
+	test     string
+	location string
+	now      time.Time
+	expected fmtSyslogMessage
+}{
+	{
+		"hostname and pid",
+		`<34>Oct 11 22:14:15 mymachine su[123]: 'su root' failed for lonvick on /dev/pts/8`,
+		"UTC",
+		time.Date(2024, 10, 12, 0, 0, 0, 0, time.UTC),
+		fmtSyslogMessage{
+			Priority:  34,
+			Facility:  4,
+			Severity:  2,
+			Timestamp: "2024-10-11T22:14:15Z",
+			Hostname:  "mymachine",
+			AppName:   "su",
+			ProcID:    "123",
+			Message:   "'su root' failed for lonvick on /dev/pts/8",
+		},
+	},
+	{
+		"no hostname",
+		`<13>Feb  5 17:32:18 kernel: device eth0 entered promiscuous mode`,
+		"America/New_York",
+		time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		fmtSyslogMessage{
+			Priority:  13,
+			Facility:  1,
+			Severity:  5,
+			Timestamp: "2024-02-05T17:32:18-05:00",
+			AppName:   "kernel",
+			Message:   "device eth0 entered promiscuous mode",
+		},
+	},
+	{
+		"previous year",
+		`<13>Dec 31 23:59:59 host app: message`,
+		"UTC",
+		time.Date(2025, 1, 1, 0, 0, 1, 0, time.UTC),
+		fmtSyslogMessage{
+			Priority:  13,
+			Facility:  1,
+			Severity:  5,
+			Timestamp: "2024-12-31T23:59:59Z",
+			Hostname:  "host",
+			AppName:   "app",
+			Message:   "message",
+		},
+	},
+}
+
+func TestFmtFromSyslogRFC3164(t *testing.T) {
+	for _, test := range fmtFromSyslogRFC3164Tests {
+		t.Run(test.name, func(t *testing.T) {
+			loc, err := time.LoadLocation(test.location)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := fmtFromSyslog(test.test, loc, test.now)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(result, test.expected) {
+				t.Errorf("expected %+v, got %+v", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestFmtFromSyslogInvalid(t *testing.T) {
+	tests := []string{
+		``,
+		`hello world`,
+		`<192>hello`,
+		`<abc>hello`,
+		`<13>1 2003-10-11T22:14:15.003Z host`,
+		`<13>1 - - - - - [id key="value]`,
+		`<13>1 - - - - - message`,
+	}
+
+	for _, test := range tests {
+		if _, err := fmtFromSyslog(test, time.UTC, time.Now()); !errors.Is(err, errFmtSyslogInvalid) {
+			t.Errorf("%q: expected %v, got %v", test, errFmtSyslogInvalid, err)
+		}
+	}
+}
+
+func benchmarkFormatFromSyslog(b *testing.B, tf *formatFromSyslog, data []byte) {
+	ctx := context.TODO()
+	for i := 0; i < b.N; i++ {
+		msg := message.New().SetData(data)
+		_, _ = tf.Transform(ctx, msg)
+	}
+}
+
+func BenchmarkFormatFromSyslog(b *testing.B) {
+	for _, test := range formatFromSyslogTests {
+		tf, err := newFormatFromSyslog(context.TODO(), test.cfg)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(test.name,
+			func(b *testing.B) {
+				benchmarkFormatFromSyslog(b, tf, test.test)
+			},
+		)
+	}
+}
+
+func FuzzTestFormatFromSyslog(f *testing.F) {
+	testcases := [][]byte{
+		[]byte(`<165>1 2003-10-11T22:14:15.003Z host app - ID47 [id a="b"] message`),
+		[]byte(`<34>Oct 11 22:14:15 mymachine su[123]: message`),
+		[]byte(`<13>1 - - - - - [id a="b\`),
+		[]byte(`<13>`),
+		[]byte(``),
+	}
+
+	for _, tc := range testcases {
+		f.Add(tc)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ctx := context.TODO()
+		msg := message.New().SetData(data)
+
+		tf, err := newFormatFromSyslog(ctx, config.Config{})
+		if err != nil {
+			return
+		}
+
+		_, err = tf.Transform(ctx, msg)
+		if err != nil {
+			return
+		}
+	})
+}
@@ -0,0 +1,134 @@
+package transform
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/linkedin/goavro/v2"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+
+	iconfig "github.com/brexhq/substation/v2/internal/config"
+)
+
+func newFormatToAvro(ctx context.Context, cfg config.Config) (*formatToAvro, error) {
+	conf := formatAvroConfig{}
+	if err := conf.Decode(cfg.Settings); err != nil {
+		return nil, fmt.Errorf("transform format_to_avro: %v", err)
+	}
+
+	if err := conf.Validate(); err != nil {
+		return nil, fmt.Errorf("transform format_to_avro: %v", err)
+	}
+
+	if conf.Schema == "" {
+		return nil, fmt.Errorf("transform format_to_avro: schema: %v", iconfig.ErrMissingRequiredOption)
+	}
+
+	if conf.ID == "" {
+		conf.ID = "format_to_avro"
+	}
+
+	tf := formatToAvro{
+		conf: conf,
+	}
+
+	codec, err := fmtAvroCodec(ctx, conf.Schema)
+	if err != nil {
+		return nil, fmt.Errorf("transform %s: %v", conf.ID, err)
+	}
+	tf.codec = codec
+
+	if conf.Format == "confluent" {
+		tf.header = []byte{fmtAvroConfluentMagic}
+		tf.header = binary.BigEndian.AppendUint32(tf.header, uint32(conf.SchemaID))
+	}
+
+	return &tf, nil
+}
+
+// formatToAvro converts JSON objects to Avro data.
+type formatToAvro struct {
+	conf   formatAvroConfig
+	codec  *goavro.Codec
+	header []byte
+
+	// records contains data that is written to the next Object Container
+	// File.
+	mu      sync.Mutex
+	records []interface{}
+}
+
+func (tf *formatToAvro) Transform(ctx context.Context, msg *message.Message) ([]*message.Message, error) {
+	if tf.conf.Format == "ocf" {
+		return tf.toOCF(msg)
+	}
+
+	if msg.IsControl() {
+		return []*message.Message{msg}, nil
+	}
+
+	native, _, err := tf.codec.NativeFromTextual(msg.Data())
+	if err != nil {
+		return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, err)
+	}
+
+	// The header is copied so that it is not modified by the codec.
+	b, err := tf.codec.BinaryFromNative(bytes.Clone(tf.header), native)
+	if err != nil {
+		return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, err)
+	}
+
+	msg.SetData(b)
+	return []*message.Message{msg}, nil
+}
+
+// toOCF writes data to an Object Container File. The file is emitted as a
+// single message when a control message is received.
+func (tf *formatToAvro) toOCF(msg *message.Message) ([]*message.Message, error) {
+	tf.mu.Lock()
+	defer tf.mu.Unlock()
+
+	if !msg.IsControl() {
+		native, _, err := tf.codec.NativeFromTextual(msg.Data())
+		if err != nil {
+			return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, err)
+		}
+
+		tf.records = append(tf.records, native)
+		return nil, nil
+	}
+
+	if len(tf.records) == 0 {
+		return []*message.Message{msg}, nil
+	}
+
+	var buf bytes.Buffer
+	w, err := goavro.NewOCFWriter(goavro.OCFConfig{
+		W:               &buf,
+		Codec:           tf.codec,
+		CompressionName: tf.conf.Compression,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, err)
+	}
+
+	if err := w.Append(tf.records); err != nil {
+		return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, err)
+	}
+
+	tf.records = nil
+
+	outMsg := message.New().SetData(buf.Bytes())
+	return []*message.Message{outMsg, msg}, nil
+}
+
+func (tf *formatToAvro) String() string {
+	b, _ := json.Marshal(tf.conf)
+	return string(b)
+}
@@ -0,0 +1,219 @@
+package transform
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+)
+
+var _ Transformer = &formatToAvro{}
+
+const formatAvroTestSchema = `{"type":"record","name":"event","fields":[{"name":"a","type":"string"},{"name":"b","type":["null","long"],"default":null}]}`
+
+// formatAvroTestSchemaFile writes the test schema to a temporary file and
+// returns the path.
+func formatAvroTestSchemaFile(t testing.TB) string {
+	path := filepath.Join(t.TempDir(), "schema.avsc")
+	if err := os.WriteFile(path, []byte(formatAvroTestSchema), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+var formatToAvroTests = []struct {
+	name     string
+	cfg      config.Config
+	test     []byte
+	expected [][]byte
+}{
+	{
+		"binary",
+		config.Config{},
+		[]byte(`{"a":"c","b":1}`),
+		[][]byte{
+			{2, 99, 2, 2},
+		},
+	},
+	{
+		"binary null",
+		config.Config{},
+		[]byte(`{"a":"c","b":null}`),
+		[][]byte{
+			{2, 99, 0},
+		},
+	},
+	{
+		"confluent",
+		config.Config{
+			Settings: map[string]interface{}{
+				"format":    "confluent",
+				"schema_id": 258,
+			},
+		},
+		[]byte(`{"a":"c","b":1}`),
+		[][]byte{
+			{0, 0, 0, 1, 2, 2, 99, 2, 2},
+		},
+	},
+}
+
+func TestFormatToAvro(t *testing.T) {
+	ctx := context.TODO()
+	schema := formatAvroTestSchemaFile(t)
+
+	for _, test := range formatToAvroTests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.cfg.Settings == nil {
+				test.cfg.Settings = map[string]interface{}{}
+			}
+			test.cfg.Settings["schema"] = schema
+
+			msg := message.New().SetData(test.test)
+
+			tf, err := newFormatToAvro(ctx, test.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := tf.Transform(ctx, msg)
+			if err != nil {
+				t.Error(err)
+			}
+
+			var data [][]byte
+			for _, c := range result {
+				data = append(data, c.Data())
+			}
+
+			if !reflect.DeepEqual(data, test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, data)
+			}
+		})
+	}
+}
+
+func TestFormatToAvroOCF(t *testing.T) {
+	ctx := context.TODO()
+	schema := formatAvroTestSchemaFile(t)
+
+	for _, compression := range []string{"null", "deflate", "snappy"} {
+		t.Run(compression, func(t *testing.T) {
+			to, err := newFormatToAvro(ctx, config.Config{
+				Settings: map[string]interface{}{
+					"schema":      schema,
+					"format":      "ocf",
+					"compression": compression,
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			from, err := newFormatFromAvro(ctx, config.Config{
+				Settings: map[string]interface{}{
+					"format": "ocf",
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := Apply(ctx, []Transformer{to, from},
+				message.New().SetData([]byte(`{"a":"c","b":1}`)),
+				message.New().SetData([]byte(`{"a":"d"}`)),
+				message.New().AsControl(),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var data [][]byte
+			for _, c := range result {
+				if c.IsControl() {
+					continue
+				}
+
+				data = append(data, c.Data())
+			}
+
+			expected := [][]byte{
+				[]byte(`{"a":"c","b":1}`),
+				[]byte(`{"a":"d","b":null}`),
+			}
+
+			if !reflect.DeepEqual(data, expected) {
+				t.Errorf("expected %s, got %s", expected, data)
+			}
+		})
+	}
+}
+
+func benchmarkFormatToAvro(b *testing.B, tf *formatToAvro, data []byte) {
+	ctx := context.TODO()
+	for i := 0; i < b.N; i++ {
+		msg := message.New().SetData(data)
+		_, _ = tf.Transform(ctx, msg)
+	}
+}
+
+func BenchmarkFormatToAvro(b *testing.B) {
+	schema := formatAvroTestSchemaFile(b)
+
+	for _, test := range formatToAvroTests {
+		if test.cfg.Settings == nil {
+			test.cfg.Settings = map[string]interface{}{}
+		}
+		test.cfg.Settings["schema"] = schema
+
+		tf, err := newFormatToAvro(context.TODO(), test.cfg)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(test.name,
+			func(b *testing.B) {
+				benchmarkFormatToAvro(b, tf, test.test)
+			},
+		)
+	}
+}
+
+func FuzzTestFormatToAvro(f *testing.F) {
+	testcases := [][]byte{
+		[]byte(`{"a":"b"}`),
+		[]byte(`{"a":"b","b":1}`),
+		[]byte(`{"b":1}`),
+		[]byte(``),
+	}
+
+	for _, tc := range testcases {
+		f.Add(tc)
+	}
+
+	schema := formatAvroTestSchemaFile(f)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ctx := context.TODO()
+		msg := message.New().SetData(data)
+
+		tf, err := newFormatToAvro(ctx, config.Config{
+			Settings: map[string]interface{}{
+				"schema": schema,
+			},
+		})
+		if err != nil {
+			return
+		}
+
+		_, err = tf.Transform(ctx, msg)
+		if err != nil {
+			return
+		}
+	})
+}
@@ -0,0 +1,135 @@
+package transform
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/internal/aggregate"
+	"github.com/brexhq/substation/v2/message"
+
+	iconfig "github.com/brexhq/substation/v2/internal/config"
+)
+
+func newFormatToCSV(_ context.Context, cfg config.Config) (*formatToCSV, error) {
+	conf := formatCSVConfig{}
+	if err := conf.Decode(cfg.Settings); err != nil {
+		return nil, fmt.Errorf("transform format_to_csv: %v", err)
+	}
+
+	if conf.ID == "" {
+		conf.ID = "format_to_csv"
+	}
+
+	if err := conf.Validate(); err != nil {
+		return nil, fmt.Errorf("transform %s: %v", conf.ID, err)
+	}
+
+	if len(conf.Columns) == 0 {
+		return nil, fmt.Errorf("transform %s: columns: %v", conf.ID, iconfig.ErrMissingRequiredOption)
+	}
+
+	tf := formatToCSV{
+		conf: conf,
+	}
+
+	if conf.Header {
+		header, err := fmtToCSV(conf.delimiter(), conf.Columns)
+		if err != nil {
+			return nil, fmt.Errorf("transform %s: %v", conf.ID, err)
+		}
+
+		tf.header = header
+	}
+
+	agg, err := aggregate.New(aggregate.Config{
+		Count:    conf.Batch.Count,
+		Size:     conf.Batch.Size,
+		Duration: conf.Batch.Duration,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("transform %s: %v", conf.ID, err)
+	}
+	tf.agg = agg
+
+	return &tf, nil
+}
+
+// formatToCSV converts JSON objects to rows of delimited text (e.g., CSV,
+// TSV). Rows are batched and emitted as a single message that contains one
+// row per line, similar to aggregate_to_string.
+type formatToCSV struct {
+	conf   formatCSVConfig
+	header []byte
+
+	mu  sync.Mutex
+	agg *aggregate.Aggregate
+}
+
+func (tf *formatToCSV) Transform(ctx context.Context, msg *message.Message) ([]*message.Message, error) {
+	tf.mu.Lock()
+	defer tf.mu.Unlock()
+
+	if msg.IsControl() {
+		var output []*message.Message
+
+		for _, items := range tf.agg.GetAll() {
+			if items.Count() == 0 {
+				continue
+			}
+
+			outMsg := message.New().SetData(tf.rows(items.Get()))
+			output = append(output, outMsg)
+		}
+
+		tf.agg.ResetAll()
+
+		output = append(output, msg)
+		return output, nil
+	}
+
+	prefix := tf.conf.Object.SourceKey
+	if prefix != "" {
+		prefix += "."
+	}
+
+	record := make([]string, len(tf.conf.Columns))
+	for i, col := range tf.conf.Columns {
+		record[i] = msg.GetValue(prefix + col).String()
+	}
+
+	row, err := fmtToCSV(tf.conf.delimiter(), record)
+	if err != nil {
+		return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, err)
+	}
+
+	// If this value does not exist, then all data is batched together.
+	key := msg.GetValue(tf.conf.Object.BatchKey).String()
+	if ok := tf.agg.Add(key, row); ok {
+		return nil, nil
+	}
+
+	outMsg := message.New().SetData(tf.rows(tf.agg.Get(key)))
+
+	// If data cannot be added after reset, then the batch is misconfgured.
+	tf.agg.Reset(key)
+	if ok := tf.agg.Add(key, row); !ok {
+		return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, errBatchNoMoreData)
+	}
+
+	return []*message.Message{outMsg}, nil
+}
+
+// rows joins the rows into a single value, starting with the header row if
+// it is configured.
+func (tf *formatToCSV) rows(rows [][]byte) []byte {
+	return bytes.Join(append([][]byte{tf.header}, rows...), nil)
+}
+
+func (tf *formatToCSV) String() string {
+	b, _ := json.Marshal(tf.conf)
+	return string(b)
+}
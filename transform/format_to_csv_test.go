@@ -0,0 +1,173 @@
+package transform
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+)
+
+var _ Transformer = &formatToCSV{}
+
+var formatToCSVTests = []struct {
+	name     string
+	cfg      config.Config
+	test     [][]byte
+	expected [][]byte
+}{
+	{
+		"data",
+		config.Config{
+			Settings: map[string]interface{}{
+				"columns": []string{"a", "b"},
+			},
+		},
+		[][]byte{
+			[]byte(`{"a":"c","b":"d,e"}`),
+			[]byte(`{"a":1,"b":{"c":"d"}}`),
+			[]byte(`{"a":"f"}`),
+		},
+		[][]byte{
+			[]byte("c,\"d,e\"\n1,\"{\"\"c\"\":\"\"d\"\"}\"\nf,\n"),
+		},
+	},
+	{
+		"header",
+		config.Config{
+			Settings: map[string]interface{}{
+				"columns":   []string{"a", "b"},
+				"header":    true,
+				"delimiter": "\t",
+				"batch": map[string]interface{}{
+					"count": 1,
+				},
+			},
+		},
+		[][]byte{
+			[]byte(`{"a":"c","b":"d"}`),
+			[]byte(`{"a":"e","b":"f"}`),
+		},
+		[][]byte{
+			[]byte("a\tb\nc\td\n"),
+			[]byte("a\tb\ne\tf\n"),
+		},
+	},
+	{
+		"object",
+		config.Config{
+			Settings: map[string]interface{}{
+				"object": map[string]interface{}{
+					"source_key": "a",
+				},
+				"columns": []string{"b"},
+			},
+		},
+		[][]byte{
+			[]byte(`{"a":{"b":"c"}}`),
+		},
+		[][]byte{
+			[]byte("c\n"),
+		},
+	},
+}
+
+func TestFormatToCSV(t *testing.T) {
+	ctx := context.TODO()
+	for _, test := range formatToCSVTests {
+		t.Run(test.name, func(t *testing.T) {
+			var messages []*message.Message
+			for _, data := range test.test {
+				msg := message.New().SetData(data)
+				messages = append(messages, msg)
+			}
+
+			// aggregate doesn't flush until a control message is received.
+			messages = append(messages, message.New().AsControl())
+
+			tf, err := newFormatToCSV(ctx, test.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := Apply(ctx, []Transformer{tf}, messages...)
+			if err != nil {
+				t.Error(err)
+			}
+
+			var data [][]byte
+			for _, c := range result {
+				if c.IsControl() {
+					continue
+				}
+
+				data = append(data, c.Data())
+			}
+
+			if !reflect.DeepEqual(data, test.expected) {
+				t.Errorf("expected %q, got %q", test.expected, data)
+			}
+		})
+	}
+}
+
+func benchmarkFormatToCSV(b *testing.B, tf *formatToCSV, data [][]byte) {
+	ctx := context.TODO()
+	for i := 0; i < b.N; i++ {
+		var messages []*message.Message
+		for _, d := range data {
+			msg := message.New().SetData(d)
+			messages = append(messages, msg)
+		}
+
+		messages = append(messages, message.New().AsControl())
+		_, _ = Apply(ctx, []Transformer{tf}, messages...)
+	}
+}
+
+func BenchmarkFormatToCSV(b *testing.B) {
+	for _, test := range formatToCSVTests {
+		tf, err := newFormatToCSV(context.TODO(), test.cfg)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(test.name,
+			func(b *testing.B) {
+				benchmarkFormatToCSV(b, tf, test.test)
+			},
+		)
+	}
+}
+
+func FuzzTestFormatToCSV(f *testing.F) {
+	testcases := [][]byte{
+		[]byte(`{"a":"b"}`),
+		[]byte(`{"a":"b,c"}`),
+		[]byte(`a`),
+		[]byte(``),
+	}
+
+	for _, tc := range testcases {
+		f.Add(tc)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ctx := context.TODO()
+
+		tf, err := newFormatToCSV(ctx, config.Config{
+			Settings: map[string]interface{}{
+				"columns": []string{"a"},
+			},
+		})
+		if err != nil {
+			return
+		}
+
+		_, err = Apply(ctx, []Transformer{tf}, message.New().SetData(data), message.New().AsControl())
+		if err != nil {
+			return
+		}
+	})
+}
@@ -0,0 +1,218 @@
+package transform
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/compress"
+	"github.com/tidwall/gjson"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/internal/aggregate"
+	"github.com/brexhq/substation/v2/message"
+)
+
+func newFormatToParquet(_ context.Context, cfg config.Config) (*formatToParquet, error) {
+	conf := formatToParquetConfig{}
+	if err := conf.Decode(cfg.Settings); err != nil {
+		return nil, fmt.Errorf("transform format_to_parquet: %v", err)
+	}
+
+	if err := conf.Validate(); err != nil {
+		return nil, fmt.Errorf("transform format_to_parquet: %v", err)
+	}
+
+	if conf.ID == "" {
+		conf.ID = "format_to_parquet"
+	}
+
+	tf := formatToParquet{
+		conf: conf,
+	}
+
+	switch conf.Compression {
+	case "none":
+		tf.codec = &parquet.Uncompressed
+	case "gzip":
+		tf.codec = &parquet.Gzip
+	case "zstd":
+		tf.codec = &parquet.Zstd
+	default:
+		tf.codec = &parquet.Snappy
+	}
+
+	agg, err := aggregate.New(aggregate.Config{
+		Count:    conf.Batch.Count,
+		Size:     conf.Batch.Size,
+		Duration: conf.Batch.Duration,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("transform %s: %v", conf.ID, err)
+	}
+	tf.agg = *agg
+
+	return &tf, nil
+}
+
+// formatToParquet writes JSON objects to a Parquet file. Objects are batched
+// into row groups and the file is emitted as a single message when a control
+// message is received, so this is best used as an auxiliary transform in send
+// transforms (e.g., send_aws_s3, send_file).
+type formatToParquet struct {
+	conf  formatToParquetConfig
+	codec compress.Codec
+
+	mu  sync.Mutex
+	agg aggregate.Aggregate
+	// schema, buf, and w contain the file that is currently being written.
+	schema map[string]string
+	buf    bytes.Buffer
+	w      *parquet.Writer
+	// groups contains the row groups of the current file if the schema is
+	// inferred. The file is written when it is flushed, so the schema
+	// describes every row in the file.
+	groups [][][]byte
+}
+
+func (tf *formatToParquet) Transform(ctx context.Context, msg *message.Message) ([]*message.Message, error) {
+	tf.mu.Lock()
+	defer tf.mu.Unlock()
+
+	if msg.IsControl() {
+		if err := tf.addRowGroup(tf.agg.Get("")); err != nil {
+			return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, err)
+		}
+		tf.agg.ResetAll()
+
+		if err := tf.writeInferred(); err != nil {
+			return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, err)
+		}
+
+		if tf.w == nil {
+			return []*message.Message{msg}, nil
+		}
+
+		if err := tf.w.Close(); err != nil {
+			return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, err)
+		}
+
+		outMsg := message.New().SetData(bytes.Clone(tf.buf.Bytes()))
+
+		tf.w = nil
+		tf.buf.Reset()
+
+		return []*message.Message{outMsg, msg}, nil
+	}
+
+	if !json.Valid(msg.Data()) || !gjson.ParseBytes(msg.Data()).IsObject() {
+		return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, errMsgInvalidObject)
+	}
+
+	if ok := tf.agg.Add("", msg.Data()); ok {
+		return nil, nil
+	}
+
+	if err := tf.addRowGroup(tf.agg.Get("")); err != nil {
+		return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, err)
+	}
+
+	// If data cannot be added after reset, then the batch is misconfgured.
+	tf.agg.Reset("")
+	if ok := tf.agg.Add("", msg.Data()); !ok {
+		return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, errBatchNoMoreData)
+	}
+
+	return nil, nil
+}
+
+// addRowGroup writes the data to the current file as a row group. If the
+// schema is inferred, then the data is kept until the file is flushed.
+func (tf *formatToParquet) addRowGroup(data [][]byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+
+	if len(tf.conf.Schema) == 0 {
+		// The aggregate reuses its buffers after it is reset.
+		tf.groups = append(tf.groups, slices.Clone(data))
+		return nil
+	}
+
+	if tf.w == nil {
+		tf.schema = tf.conf.Schema
+		if err := tf.newWriter(); err != nil {
+			return err
+		}
+	}
+
+	return tf.writeRowGroup(data)
+}
+
+// writeInferred infers the schema from every row in the current file and
+// writes the row groups.
+func (tf *formatToParquet) writeInferred() error {
+	if len(tf.groups) == 0 {
+		return nil
+	}
+
+	defer func() {
+		tf.groups = nil
+	}()
+
+	tf.schema = fmtParquetInfer(slices.Concat(tf.groups...))
+	if len(tf.schema) == 0 {
+		return fmt.Errorf("schema: %v", errMsgInvalidObject)
+	}
+
+	if err := tf.newWriter(); err != nil {
+		return err
+	}
+
+	for _, g := range tf.groups {
+		if err := tf.writeRowGroup(g); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (tf *formatToParquet) newWriter() error {
+	s, err := fmtParquetSchema(tf.schema)
+	if err != nil {
+		return err
+	}
+
+	tf.w = parquet.NewWriter(&tf.buf, s, parquet.Compression(tf.codec))
+
+	return nil
+}
+
+// writeRowGroup writes the data to the current file as a row group.
+func (tf *formatToParquet) writeRowGroup(data [][]byte) error {
+	rows := make([]parquet.Row, 0, len(data))
+	for _, d := range data {
+		row, err := fmtParquetRow(tf.schema, d)
+		if err != nil {
+			return err
+		}
+
+		rows = append(rows, tf.w.Schema().Deconstruct(nil, row))
+	}
+
+	if _, err := tf.w.WriteRows(rows); err != nil {
+		return err
+	}
+
+	return tf.w.Flush()
+}
+
+func (tf *formatToParquet) String() string {
+	b, _ := json.Marshal(tf.conf)
+	return string(b)
+}
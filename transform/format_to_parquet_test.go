@@ -0,0 +1,269 @@
+package transform
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/parquet-go/parquet-go"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+)
+
+var _ Transformer = &formatToParquet{}
+
+var formatToParquetTests = []struct {
+	name      string
+	cfg       config.Config
+	test      [][]byte
+	expected  [][]byte
+	rowGroups int
+}{
+	{
+		"inferred",
+		config.Config{},
+		[][]byte{
+			[]byte(`{"a":"b","c":1,"d":{"e":"f"}}`),
+			[]byte(`{"a":"g","c":1.5,"h":true}`),
+		},
+		[][]byte{
+			[]byte(`{"a":"b","c":1,"d":{"e":"f"}}`),
+			[]byte(`{"a":"g","c":1.5,"h":true}`),
+		},
+		1,
+	},
+	{
+		"schema",
+		config.Config{
+			Settings: map[string]interface{}{
+				"schema": map[string]interface{}{
+					"a": "string",
+					"c": "int32",
+					"t": "timestamp",
+				},
+				"compression": "zstd",
+			},
+		},
+		[][]byte{
+			[]byte(`{"a":1,"c":"2","t":"2024-01-01T00:00:00Z","x":"y"}`),
+			[]byte(`{"a":"b"}`),
+		},
+		[][]byte{
+			[]byte(`{"a":"1","c":2,"t":"2024-01-01T00:00:00Z"}`),
+			[]byte(`{"a":"b"}`),
+		},
+		1,
+	},
+	{
+		"batch",
+		config.Config{
+			Settings: map[string]interface{}{
+				"batch": map[string]interface{}{
+					"count": 2,
+				},
+			},
+		},
+		[][]byte{
+			[]byte(`{"a":"b"}`),
+			[]byte(`{"a":"c"}`),
+			[]byte(`{"a":"d"}`),
+			[]byte(`{"a":"e"}`),
+			[]byte(`{"a":"f"}`),
+		},
+		[][]byte{
+			[]byte(`{"a":"b"}`),
+			[]byte(`{"a":"c"}`),
+			[]byte(`{"a":"d"}`),
+			[]byte(`{"a":"e"}`),
+			[]byte(`{"a":"f"}`),
+		},
+		3,
+	},
+	{
+		"inferred from all row groups",
+		config.Config{
+			Settings: map[string]interface{}{
+				"batch": map[string]interface{}{
+					"count": 1,
+				},
+			},
+		},
+		[][]byte{
+			[]byte(`{"a":"b","c":1}`),
+			[]byte(`{"a":"d","c":1.5,"e":"f"}`),
+		},
+		[][]byte{
+			[]byte(`{"a":"b","c":1}`),
+			[]byte(`{"a":"d","c":1.5,"e":"f"}`),
+		},
+		2,
+	},
+}
+
+func TestFormatToParquet(t *testing.T) {
+	ctx := context.TODO()
+	for _, test := range formatToParquetTests {
+		t.Run(test.name, func(t *testing.T) {
+			var messages []*message.Message
+			for _, data := range test.test {
+				msg := message.New().SetData(data)
+				messages = append(messages, msg)
+			}
+
+			// aggregate doesn't flush until a control message is received.
+			messages = append(messages, message.New().AsControl())
+
+			tf, err := newFormatToParquet(ctx, test.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := Apply(ctx, []Transformer{tf}, messages...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// The file and the control message are returned.
+			if len(result) != 2 {
+				t.Fatalf("expected 2 messages, got %d", len(result))
+			}
+
+			b := result[0].Data()
+			f, err := parquet.OpenFile(bytes.NewReader(b), int64(len(b)))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(f.RowGroups()) != test.rowGroups {
+				t.Errorf("expected %d row groups, got %d", test.rowGroups, len(f.RowGroups()))
+			}
+
+			from, err := newFormatFromParquet(ctx, config.Config{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rows, err := from.Transform(ctx, result[0])
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var data [][]byte
+			for _, r := range rows {
+				data = append(data, r.Data())
+			}
+
+			if !reflect.DeepEqual(data, test.expected) {
+				t.Errorf("expected %s, got %s", test.expected, data)
+			}
+		})
+	}
+}
+
+func TestFormatToParquetErrors(t *testing.T) {
+	ctx := context.TODO()
+
+	tests := []struct {
+		name string
+		cfg  config.Config
+		test []byte
+	}{
+		{
+			"invalid object",
+			config.Config{},
+			[]byte(`["a"]`),
+		},
+		{
+			"invalid value",
+			config.Config{
+				Settings: map[string]interface{}{
+					"schema": map[string]interface{}{
+						"a": "int64",
+					},
+				},
+			},
+			[]byte(`{"a":"b"}`),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tf, err := newFormatToParquet(ctx, test.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := Apply(ctx, []Transformer{tf}, message.New().SetData(test.test), message.New().AsControl()); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+
+	if _, err := newFormatToParquet(ctx, config.Config{
+		Settings: map[string]interface{}{
+			"schema": map[string]interface{}{
+				"a": "uuid",
+			},
+		},
+	}); err == nil {
+		t.Error("expected error for invalid schema type")
+	}
+}
+
+func benchmarkFormatToParquet(b *testing.B, tf *formatToParquet, data [][]byte) {
+	ctx := context.TODO()
+	for i := 0; i < b.N; i++ {
+		var messages []*message.Message
+		for _, d := range data {
+			msg := message.New().SetData(d)
+			messages = append(messages, msg)
+		}
+
+		messages = append(messages, message.New().AsControl())
+		_, _ = Apply(ctx, []Transformer{tf}, messages...)
+	}
+}
+
+func BenchmarkFormatToParquet(b *testing.B) {
+	for _, test := range formatToParquetTests {
+		tf, err := newFormatToParquet(context.TODO(), test.cfg)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(test.name,
+			func(b *testing.B) {
+				benchmarkFormatToParquet(b, tf, test.test)
+			},
+		)
+	}
+}
+
+func FuzzTestFormatToParquet(f *testing.F) {
+	testcases := [][]byte{
+		[]byte(`{"a":"b"}`),
+		[]byte(`{"a":1,"b":[1,2]}`),
+		[]byte(`a`),
+		[]byte(``),
+	}
+
+	for _, tc := range testcases {
+		f.Add(tc)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ctx := context.TODO()
+
+		tf, err := newFormatToParquet(ctx, config.Config{})
+		if err != nil {
+			return
+		}
+
+		_, err = Apply(ctx, []Transformer{tf}, message.New().SetData(data), message.New().AsControl())
+		if err != nil {
+			return
+		}
+	})
+}
@@ -0,0 +1,88 @@
+package transform
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"google.golang.org/protobuf/encoding/protodelim"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+)
+
+func newFormatToProtobuf(ctx context.Context, cfg config.Config) (*formatToProtobuf, error) {
+	conf := formatProtobufConfig{}
+	if err := conf.Decode(cfg.Settings); err != nil {
+		return nil, fmt.Errorf("transform format_to_protobuf: %v", err)
+	}
+
+	if err := conf.Validate(); err != nil {
+		return nil, fmt.Errorf("transform format_to_protobuf: %v", err)
+	}
+
+	if conf.ID == "" {
+		conf.ID = "format_to_protobuf"
+	}
+
+	tf := formatToProtobuf{
+		conf: conf,
+	}
+
+	typ, err := fmtProtobufType(ctx, conf.Schema, conf.Message)
+	if err != nil {
+		return nil, fmt.Errorf("transform %s: %v", conf.ID, err)
+	}
+	tf.typ = typ
+
+	return &tf, nil
+}
+
+// formatToProtobuf converts JSON objects to Protobuf messages. Fields can use
+// either the names from the Protobuf schema or their JSON names. Messages are
+// marshaled deterministically, otherwise the order of map entries can change
+// between calls and the same message can produce different bytes.
+type formatToProtobuf struct {
+	conf formatProtobufConfig
+	typ  protoreflect.MessageType
+}
+
+func (tf *formatToProtobuf) Transform(ctx context.Context, msg *message.Message) ([]*message.Message, error) {
+	if msg.IsControl() {
+		return []*message.Message{msg}, nil
+	}
+
+	pb := tf.typ.New().Interface()
+	if err := protojson.Unmarshal(msg.Data(), pb); err != nil {
+		return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, err)
+	}
+
+	if !tf.conf.Delimited {
+		b, err := proto.MarshalOptions{Deterministic: true}.Marshal(pb)
+		if err != nil {
+			return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, err)
+		}
+
+		msg.SetData(b)
+		return []*message.Message{msg}, nil
+	}
+
+	var buf bytes.Buffer
+	if _, err := (protodelim.MarshalOptions{
+		MarshalOptions: proto.MarshalOptions{Deterministic: true},
+	}).MarshalTo(&buf, pb); err != nil {
+		return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, err)
+	}
+
+	msg.SetData(buf.Bytes())
+	return []*message.Message{msg}, nil
+}
+
+func (tf *formatToProtobuf) String() string {
+	b, _ := json.Marshal(tf.conf)
+	return string(b)
+}
@@ -0,0 +1,219 @@
+package transform
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+)
+
+var _ Transformer = &formatToProtobuf{}
+
+// formatProtobufTestSchemaFile writes a file descriptor set that contains
+// the message type "test.Event" to a temporary file and returns the path.
+//
+//	message Event {
+//	  string name = 1;
+//	  int32 count = 2;
+//	  repeated string tags = 3;
+//	}
+func formatProtobufTestSchemaFile(t testing.TB) string {
+	set := &descriptorpb.FileDescriptorSet{
+		File: []*descriptorpb.FileDescriptorProto{
+			{
+				Name:    proto.String("test.proto"),
+				Package: proto.String("test"),
+				Syntax:  proto.String("proto3"),
+				MessageType: []*descriptorpb.DescriptorProto{
+					{
+						Name: proto.String("Event"),
+						Field: []*descriptorpb.FieldDescriptorProto{
+							{
+								Name:     proto.String("name"),
+								JsonName: proto.String("name"),
+								Number:   proto.Int32(1),
+								Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+								Type:     descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+							},
+							{
+								Name:     proto.String("count"),
+								JsonName: proto.String("count"),
+								Number:   proto.Int32(2),
+								Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+								Type:     descriptorpb.FieldDescriptorProto_TYPE_INT32.Enum(),
+							},
+							{
+								Name:     proto.String("tags"),
+								JsonName: proto.String("tags"),
+								Number:   proto.Int32(3),
+								Label:    descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum(),
+								Type:     descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	b, err := proto.Marshal(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "schema.binpb")
+	if err := os.WriteFile(path, b, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+var formatToProtobufTests = []struct {
+	name     string
+	cfg      config.Config
+	test     []byte
+	expected [][]byte
+}{
+	{
+		"data",
+		config.Config{},
+		[]byte(`{"name":"a","count":1,"tags":["b","c"]}`),
+		[][]byte{
+			{10, 1, 97, 16, 1, 26, 1, 98, 26, 1, 99},
+		},
+	},
+	{
+		"delimited",
+		config.Config{
+			Settings: map[string]interface{}{
+				"delimited": true,
+			},
+		},
+		[]byte(`{"name":"a","count":1}`),
+		[][]byte{
+			{5, 10, 1, 97, 16, 1},
+		},
+	},
+}
+
+func TestFormatToProtobuf(t *testing.T) {
+	ctx := context.TODO()
+	schema := formatProtobufTestSchemaFile(t)
+
+	for _, test := range formatToProtobufTests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.cfg.Settings == nil {
+				test.cfg.Settings = map[string]interface{}{}
+			}
+			test.cfg.Settings["schema"] = schema
+			test.cfg.Settings["message"] = "test.Event"
+
+			msg := message.New().SetData(test.test)
+
+			tf, err := newFormatToProtobuf(ctx, test.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := tf.Transform(ctx, msg)
+			if err != nil {
+				t.Error(err)
+			}
+
+			var data [][]byte
+			for _, c := range result {
+				data = append(data, c.Data())
+			}
+
+			if !reflect.DeepEqual(data, test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, data)
+			}
+		})
+	}
+}
+
+func TestFormatToProtobufInvalidMessage(t *testing.T) {
+	_, err := newFormatToProtobuf(context.TODO(), config.Config{
+		Settings: map[string]interface{}{
+			"schema":  formatProtobufTestSchemaFile(t),
+			"message": "test.Missing",
+		},
+	})
+	if err == nil {
+		t.Error("expected error")
+	}
+}
+
+func benchmarkFormatToProtobuf(b *testing.B, tf *formatToProtobuf, data []byte) {
+	ctx := context.TODO()
+	for i := 0; i < b.N; i++ {
+		msg := message.New().SetData(data)
+		_, _ = tf.Transform(ctx, msg)
+	}
+}
+
+func BenchmarkFormatToProtobuf(b *testing.B) {
+	schema := formatProtobufTestSchemaFile(b)
+
+	for _, test := range formatToProtobufTests {
+		if test.cfg.Settings == nil {
+			test.cfg.Settings = map[string]interface{}{}
+		}
+		test.cfg.Settings["schema"] = schema
+		test.cfg.Settings["message"] = "test.Event"
+
+		tf, err := newFormatToProtobuf(context.TODO(), test.cfg)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(test.name,
+			func(b *testing.B) {
+				benchmarkFormatToProtobuf(b, tf, test.test)
+			},
+		)
+	}
+}
+
+func FuzzTestFormatToProtobuf(f *testing.F) {
+	testcases := [][]byte{
+		[]byte(`{"name":"a"}`),
+		[]byte(`{"count":1}`),
+		[]byte(`{"a":"b"}`),
+		[]byte(``),
+	}
+
+	for _, tc := range testcases {
+		f.Add(tc)
+	}
+
+	schema := formatProtobufTestSchemaFile(f)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ctx := context.TODO()
+		msg := message.New().SetData(data)
+
+		tf, err := newFormatToProtobuf(ctx, config.Config{
+			Settings: map[string]interface{}{
+				"schema":  schema,
+				"message": "test.Event",
+			},
+		})
+		if err != nil {
+			return
+		}
+
+		_, err = tf.Transform(ctx, msg)
+		if err != nil {
+			return
+		}
+	})
+}
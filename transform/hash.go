@@ -3,9 +3,16 @@ package transform
 import (
 	"fmt"
 
+	"github.com/brexhq/substation/v2/message"
+
 	iconfig "github.com/brexhq/substation/v2/internal/config"
 )
 
+// errHashInputTooSmall is returned by fuzzy hashes when the input is too small
+// to produce a meaningful hash. These inputs are not hashed, and the hash is
+// an empty string.
+var errHashInputTooSmall = fmt.Errorf("input is too small")
+
 type hashConfig struct {
 	ID     string         `json:"id"`
 	Object iconfig.Object `json:"object"`
@@ -26,3 +33,35 @@ func (c *hashConfig) Validate() error {
 
 	return nil
 }
+
+// hashApply replaces the message data, or sets the value in Object.TargetKey,
+// with the hash of the message data or the value in Object.SourceKey. If the
+// input is too small to be hashed, then the data or value is set to an empty
+// string, so the input is never mistaken for a hash.
+func hashApply(msg *message.Message, conf hashConfig, isObject bool, sum func([]byte) (string, error)) ([]*message.Message, error) {
+	if !isObject {
+		str, err := sum(msg.Data())
+		if err != nil && err != errHashInputTooSmall {
+			return nil, fmt.Errorf("transform %s: %v", conf.ID, err)
+		}
+
+		msg.SetData([]byte(str))
+		return []*message.Message{msg}, nil
+	}
+
+	value := msg.GetValue(conf.Object.SourceKey)
+	if !value.Exists() {
+		return []*message.Message{msg}, nil
+	}
+
+	str, err := sum(value.Bytes())
+	if err != nil && err != errHashInputTooSmall {
+		return nil, fmt.Errorf("transform %s: %v", conf.ID, err)
+	}
+
+	if err := msg.SetValue(conf.Object.TargetKey, str); err != nil {
+		return nil, fmt.Errorf("transform %s: %v", conf.ID, err)
+	}
+
+	return []*message.Message{msg}, nil
+}
@@ -0,0 +1,57 @@
+package transform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"golang.org/x/crypto/blake2b"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+)
+
+func newHashBLAKE2b(_ context.Context, cfg config.Config) (*hashBLAKE2b, error) {
+	conf := hashConfig{}
+	if err := conf.Decode(cfg.Settings); err != nil {
+		return nil, fmt.Errorf("transform hash_blake2b: %v", err)
+	}
+
+	if conf.ID == "" {
+		conf.ID = "hash_blake2b"
+	}
+
+	if err := conf.Validate(); err != nil {
+		return nil, fmt.Errorf("transform %s: %v", conf.ID, err)
+	}
+
+	tf := hashBLAKE2b{
+		conf:     conf,
+		isObject: conf.Object.SourceKey != "" && conf.Object.TargetKey != "",
+	}
+
+	return &tf, nil
+}
+
+// hashBLAKE2b hashes values with BLAKE2b-256.
+type hashBLAKE2b struct {
+	conf     hashConfig
+	isObject bool
+}
+
+func (tf *hashBLAKE2b) Transform(ctx context.Context, msg *message.Message) ([]*message.Message, error) {
+	if msg.IsControl() {
+		return []*message.Message{msg}, nil
+	}
+
+	return hashApply(msg, tf.conf, tf.isObject, hashBLAKE2bSum)
+}
+
+func (tf *hashBLAKE2b) String() string {
+	b, _ := json.Marshal(tf.conf)
+	return string(b)
+}
+
+func hashBLAKE2bSum(b []byte) (string, error) {
+	return fmt.Sprintf("%x", blake2b.Sum256(b)), nil
+}
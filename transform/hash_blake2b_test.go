@@ -0,0 +1,140 @@
+package transform
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+)
+
+var _ Transformer = &hashBLAKE2b{}
+
+var hashBLAKE2bTests = []struct {
+	name     string
+	cfg      config.Config
+	test     []byte
+	expected [][]byte
+}{
+	{
+		"data",
+		config.Config{},
+		[]byte(`a`),
+		[][]byte{
+			[]byte(`8928aae63c84d87ea098564d1e03ad813f107add474e56aedd286349c0c03ea4`),
+		},
+	},
+	{
+		"object",
+		config.Config{
+			Settings: map[string]interface{}{
+				"object": map[string]interface{}{
+					"source_key": "a",
+					"target_key": "a",
+				},
+			},
+		},
+		[]byte(`{"a":"b"}`),
+		[][]byte{
+			[]byte(`{"a":"6e5c1f45cbaf19f94230ba3501c378a5335af71a331b5b5aed62792332288dc3"}`),
+		},
+	},
+}
+
+func TestHashBLAKE2b(t *testing.T) {
+	ctx := context.TODO()
+	for _, test := range hashBLAKE2bTests {
+		t.Run(test.name, func(t *testing.T) {
+			tf, err := newHashBLAKE2b(ctx, test.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			msg := message.New().SetData(test.test)
+			result, err := tf.Transform(ctx, msg)
+			if err != nil {
+				t.Error(err)
+			}
+
+			var data [][]byte
+			for _, c := range result {
+				data = append(data, c.Data())
+			}
+
+			if !reflect.DeepEqual(data, test.expected) {
+				t.Errorf("expected %s, got %s", test.expected, data)
+			}
+		})
+	}
+}
+
+func benchmarkHashBLAKE2b(b *testing.B, tf *hashBLAKE2b, data []byte) {
+	ctx := context.TODO()
+	for i := 0; i < b.N; i++ {
+		msg := message.New().SetData(data)
+		_, _ = tf.Transform(ctx, msg)
+	}
+}
+
+func BenchmarkHashBLAKE2b(b *testing.B) {
+	for _, test := range hashBLAKE2bTests {
+		tf, err := newHashBLAKE2b(context.TODO(), test.cfg)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(test.name,
+			func(b *testing.B) {
+				benchmarkHashBLAKE2b(b, tf, test.test)
+			},
+		)
+	}
+}
+
+func FuzzTestHashBLAKE2b(f *testing.F) {
+	testcases := [][]byte{
+		[]byte(`a`),
+		[]byte(`{"a":"b"}`),
+		[]byte(``),
+		[]byte(`{"a":""}`),
+	}
+
+	for _, tc := range testcases {
+		f.Add(tc)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ctx := context.TODO()
+		msg := message.New().SetData(data)
+
+		// Test with default settings
+		tf, err := newHashBLAKE2b(ctx, config.Config{})
+		if err != nil {
+			return
+		}
+
+		_, err = tf.Transform(ctx, msg)
+		if err != nil {
+			return
+		}
+
+		// Test with object settings
+		tf, err = newHashBLAKE2b(ctx, config.Config{
+			Settings: map[string]interface{}{
+				"object": map[string]interface{}{
+					"source_key": "a",
+					"target_key": "a",
+				},
+			},
+		})
+		if err != nil {
+			return
+		}
+
+		_, err = tf.Transform(ctx, msg)
+		if err != nil {
+			return
+		}
+	})
+}
@@ -0,0 +1,57 @@
+package transform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/zeebo/blake3"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+)
+
+func newHashBLAKE3(_ context.Context, cfg config.Config) (*hashBLAKE3, error) {
+	conf := hashConfig{}
+	if err := conf.Decode(cfg.Settings); err != nil {
+		return nil, fmt.Errorf("transform hash_blake3: %v", err)
+	}
+
+	if conf.ID == "" {
+		conf.ID = "hash_blake3"
+	}
+
+	if err := conf.Validate(); err != nil {
+		return nil, fmt.Errorf("transform %s: %v", conf.ID, err)
+	}
+
+	tf := hashBLAKE3{
+		conf:     conf,
+		isObject: conf.Object.SourceKey != "" && conf.Object.TargetKey != "",
+	}
+
+	return &tf, nil
+}
+
+// hashBLAKE3 hashes values with BLAKE3 (256-bit output).
+type hashBLAKE3 struct {
+	conf     hashConfig
+	isObject bool
+}
+
+func (tf *hashBLAKE3) Transform(ctx context.Context, msg *message.Message) ([]*message.Message, error) {
+	if msg.IsControl() {
+		return []*message.Message{msg}, nil
+	}
+
+	return hashApply(msg, tf.conf, tf.isObject, hashBLAKE3Sum)
+}
+
+func (tf *hashBLAKE3) String() string {
+	b, _ := json.Marshal(tf.conf)
+	return string(b)
+}
+
+func hashBLAKE3Sum(b []byte) (string, error) {
+	return fmt.Sprintf("%x", blake3.Sum256(b)), nil
+}
@@ -0,0 +1,57 @@
+package transform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+)
+
+func newHashCRC32(_ context.Context, cfg config.Config) (*hashCRC32, error) {
+	conf := hashConfig{}
+	if err := conf.Decode(cfg.Settings); err != nil {
+		return nil, fmt.Errorf("transform hash_crc32: %v", err)
+	}
+
+	if conf.ID == "" {
+		conf.ID = "hash_crc32"
+	}
+
+	if err := conf.Validate(); err != nil {
+		return nil, fmt.Errorf("transform %s: %v", conf.ID, err)
+	}
+
+	tf := hashCRC32{
+		conf:     conf,
+		isObject: conf.Object.SourceKey != "" && conf.Object.TargetKey != "",
+	}
+
+	return &tf, nil
+}
+
+// hashCRC32 hashes values with CRC-32 (IEEE). This is not a cryptographic
+// hash and is intended for checksums.
+type hashCRC32 struct {
+	conf     hashConfig
+	isObject bool
+}
+
+func (tf *hashCRC32) Transform(ctx context.Context, msg *message.Message) ([]*message.Message, error) {
+	if msg.IsControl() {
+		return []*message.Message{msg}, nil
+	}
+
+	return hashApply(msg, tf.conf, tf.isObject, hashCRC32Sum)
+}
+
+func (tf *hashCRC32) String() string {
+	b, _ := json.Marshal(tf.conf)
+	return string(b)
+}
+
+func hashCRC32Sum(b []byte) (string, error) {
+	return fmt.Sprintf("%08x", crc32.ChecksumIEEE(b)), nil
+}
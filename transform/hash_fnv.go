@@ -0,0 +1,60 @@
+package transform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+)
+
+func newHashFNV(_ context.Context, cfg config.Config) (*hashFNV, error) {
+	conf := hashConfig{}
+	if err := conf.Decode(cfg.Settings); err != nil {
+		return nil, fmt.Errorf("transform hash_fnv: %v", err)
+	}
+
+	if conf.ID == "" {
+		conf.ID = "hash_fnv"
+	}
+
+	if err := conf.Validate(); err != nil {
+		return nil, fmt.Errorf("transform %s: %v", conf.ID, err)
+	}
+
+	tf := hashFNV{
+		conf:     conf,
+		isObject: conf.Object.SourceKey != "" && conf.Object.TargetKey != "",
+	}
+
+	return &tf, nil
+}
+
+// hashFNV hashes values with FNV-1a (64-bit). This is not a cryptographic
+// hash and is intended for partition keys.
+type hashFNV struct {
+	conf     hashConfig
+	isObject bool
+}
+
+func (tf *hashFNV) Transform(ctx context.Context, msg *message.Message) ([]*message.Message, error) {
+	if msg.IsControl() {
+		return []*message.Message{msg}, nil
+	}
+
+	return hashApply(msg, tf.conf, tf.isObject, hashFNVSum)
+}
+
+func (tf *hashFNV) String() string {
+	b, _ := json.Marshal(tf.conf)
+	return string(b)
+}
+
+func hashFNVSum(b []byte) (string, error) {
+	h := fnv.New64a()
+	_, _ = h.Write(b)
+
+	return fmt.Sprintf("%016x", h.Sum64()), nil
+}
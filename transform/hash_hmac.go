@@ -0,0 +1,143 @@
+package transform
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/json"
+	"fmt"
+	"hash"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+
+	iconfig "github.com/brexhq/substation/v2/internal/config"
+	"github.com/brexhq/substation/v2/internal/secrets"
+)
+
+type hashHMACConfig struct {
+	// Key is the secret key used to hash values. Secrets can be used with the
+	// utility_secret transform (e.g., "${SECRET:KEY}").
+	Key string `json:"key"`
+	// Algorithm is the hash function used by HMAC. Must be one of: sha256,
+	// sha512.
+	//
+	// This is optional and defaults to sha256.
+	Algorithm string `json:"algorithm"`
+
+	ID     string         `json:"id"`
+	Object iconfig.Object `json:"object"`
+}
+
+func (c *hashHMACConfig) Decode(in interface{}) error {
+	return iconfig.Decode(in, c)
+}
+
+func (c *hashHMACConfig) Validate() error {
+	if c.Object.SourceKey == "" && c.Object.TargetKey != "" {
+		return fmt.Errorf("object_source_key: %v", iconfig.ErrMissingRequiredOption)
+	}
+
+	if c.Object.SourceKey != "" && c.Object.TargetKey == "" {
+		return fmt.Errorf("object_target_key: %v", iconfig.ErrMissingRequiredOption)
+	}
+
+	if c.Key == "" {
+		return fmt.Errorf("key: %v", iconfig.ErrMissingRequiredOption)
+	}
+
+	if _, ok := hashHMACAlgorithms[c.Algorithm]; !ok {
+		return fmt.Errorf("algorithm %s: %v", c.Algorithm, iconfig.ErrInvalidOption)
+	}
+
+	return nil
+}
+
+var hashHMACAlgorithms = map[string]func() hash.Hash{
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+func newHashHMAC(ctx context.Context, cfg config.Config) (*hashHMAC, error) {
+	conf := hashHMACConfig{}
+	if err := conf.Decode(cfg.Settings); err != nil {
+		return nil, fmt.Errorf("transform hash_hmac: %v", err)
+	}
+
+	if conf.ID == "" {
+		conf.ID = "hash_hmac"
+	}
+
+	if conf.Algorithm == "" {
+		conf.Algorithm = "sha256"
+	}
+
+	if err := conf.Validate(); err != nil {
+		return nil, fmt.Errorf("transform %s: %v", conf.ID, err)
+	}
+
+	// Retrieve secret and interpolate with the key.
+	key, err := secrets.Interpolate(ctx, conf.Key)
+	if err != nil {
+		return nil, fmt.Errorf("transform %s: %v", conf.ID, err)
+	}
+
+	tf := hashHMAC{
+		conf:     conf,
+		isObject: conf.Object.SourceKey != "" && conf.Object.TargetKey != "",
+		key:      []byte(key),
+		hash:     hashHMACAlgorithms[conf.Algorithm],
+	}
+
+	return &tf, nil
+}
+
+// hashHMAC hashes values with a secret key, which prevents dictionary attacks
+// against the hashes of known values (e.g., email addresses). The same key
+// always produces the same hash, so hashes can be used as tokens.
+type hashHMAC struct {
+	conf     hashHMACConfig
+	isObject bool
+
+	key  []byte
+	hash func() hash.Hash
+}
+
+func (tf *hashHMAC) Transform(ctx context.Context, msg *message.Message) ([]*message.Message, error) {
+	if msg.IsControl() {
+		return []*message.Message{msg}, nil
+	}
+
+	if !tf.isObject {
+		str := hashHMACSum(tf.hash, tf.key, msg.Data())
+
+		msg.SetData([]byte(str))
+		return []*message.Message{msg}, nil
+	}
+
+	value := msg.GetValue(tf.conf.Object.SourceKey)
+	if !value.Exists() {
+		return []*message.Message{msg}, nil
+	}
+
+	str := hashHMACSum(tf.hash, tf.key, value.Bytes())
+	if err := msg.SetValue(tf.conf.Object.TargetKey, str); err != nil {
+		return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, err)
+	}
+
+	return []*message.Message{msg}, nil
+}
+
+func (tf *hashHMAC) String() string {
+	b, _ := json.Marshal(tf.conf)
+	return string(b)
+}
+
+// hashHMACSum returns the hex encoded HMAC of the data.
+func hashHMACSum(h func() hash.Hash, key, data []byte) string {
+	mac := hmac.New(h, key)
+	_, _ = mac.Write(data)
+
+	return fmt.Sprintf("%x", mac.Sum(nil))
+}
@@ -0,0 +1,151 @@
+package transform
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+)
+
+var _ Transformer = &hashHMAC{}
+
+var hashHMACTests = []struct {
+	name     string
+	cfg      config.Config
+	test     []byte
+	expected [][]byte
+}{
+	{
+		"data",
+		config.Config{
+			Settings: map[string]interface{}{
+				"key": "secret",
+			},
+		},
+		[]byte(`a`),
+		[][]byte{
+			[]byte(`4048c44911916043ff626895ff78c5262764685b6cb9a03ce07886a9effb924c`),
+		},
+	},
+	{
+		"object",
+		config.Config{
+			Settings: map[string]interface{}{
+				"object": map[string]interface{}{
+					"source_key": "a",
+					"target_key": "a",
+				},
+				"key": "secret",
+			},
+		},
+		[]byte(`{"a":"b"}`),
+		[][]byte{
+			[]byte(`{"a":"8caf295837e09c876c0c5ef729581d5e75ef93adc10420ce71aab05636ac63ed"}`),
+		},
+	},
+	{
+		"object sha512",
+		config.Config{
+			Settings: map[string]interface{}{
+				"object": map[string]interface{}{
+					"source_key": "a",
+					"target_key": "a",
+				},
+				"key":       "secret",
+				"algorithm": "sha512",
+			},
+		},
+		[]byte(`{"a":"b"}`),
+		[][]byte{
+			[]byte(`{"a":"8bb72febe874317820eb92334fa8f5838fe2b8d13001034fc85909cf760f252243f675785da9c20de5235d2c726f7c979c0d6e53a49427c8873fdddbe194c6bf"}`),
+		},
+	},
+}
+
+func TestHashHMAC(t *testing.T) {
+	ctx := context.TODO()
+	for _, test := range hashHMACTests {
+		t.Run(test.name, func(t *testing.T) {
+			tf, err := newHashHMAC(ctx, test.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			msg := message.New().SetData(test.test)
+			result, err := tf.Transform(ctx, msg)
+			if err != nil {
+				t.Error(err)
+			}
+
+			var data [][]byte
+			for _, c := range result {
+				data = append(data, c.Data())
+			}
+
+			if !reflect.DeepEqual(data, test.expected) {
+				t.Errorf("expected %s, got %s", test.expected, data)
+			}
+		})
+	}
+}
+
+func benchmarkHashHMAC(b *testing.B, tf *hashHMAC, data []byte) {
+	ctx := context.TODO()
+	for i := 0; i < b.N; i++ {
+		msg := message.New().SetData(data)
+		_, _ = tf.Transform(ctx, msg)
+	}
+}
+
+func BenchmarkHashHMAC(b *testing.B) {
+	for _, test := range hashHMACTests {
+		tf, err := newHashHMAC(context.TODO(), test.cfg)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(test.name,
+			func(b *testing.B) {
+				benchmarkHashHMAC(b, tf, test.test)
+			},
+		)
+	}
+}
+
+func FuzzTestHashHMAC(f *testing.F) {
+	testcases := [][]byte{
+		[]byte(`a`),
+		[]byte(`{"a":"b"}`),
+		[]byte(``),
+		[]byte(`{"a":""}`),
+	}
+
+	for _, tc := range testcases {
+		f.Add(tc)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ctx := context.TODO()
+		msg := message.New().SetData(data)
+
+		tf, err := newHashHMAC(ctx, config.Config{
+			Settings: map[string]interface{}{
+				"object": map[string]interface{}{
+					"source_key": "a",
+					"target_key": "a",
+				},
+				"key": "secret",
+			},
+		})
+		if err != nil {
+			return
+		}
+
+		_, err = tf.Transform(ctx, msg)
+		if err != nil {
+			return
+		}
+	})
+}
@@ -0,0 +1,55 @@
+package transform
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+)
+
+func newHashSHA1(_ context.Context, cfg config.Config) (*hashSHA1, error) {
+	conf := hashConfig{}
+	if err := conf.Decode(cfg.Settings); err != nil {
+		return nil, fmt.Errorf("transform hash_sha1: %v", err)
+	}
+
+	if conf.ID == "" {
+		conf.ID = "hash_sha1"
+	}
+
+	if err := conf.Validate(); err != nil {
+		return nil, fmt.Errorf("transform %s: %v", conf.ID, err)
+	}
+
+	tf := hashSHA1{
+		conf:     conf,
+		isObject: conf.Object.SourceKey != "" && conf.Object.TargetKey != "",
+	}
+
+	return &tf, nil
+}
+
+type hashSHA1 struct {
+	conf     hashConfig
+	isObject bool
+}
+
+func (tf *hashSHA1) Transform(ctx context.Context, msg *message.Message) ([]*message.Message, error) {
+	if msg.IsControl() {
+		return []*message.Message{msg}, nil
+	}
+
+	return hashApply(msg, tf.conf, tf.isObject, hashSHA1Sum)
+}
+
+func (tf *hashSHA1) String() string {
+	b, _ := json.Marshal(tf.conf)
+	return string(b)
+}
+
+func hashSHA1Sum(b []byte) (string, error) {
+	return fmt.Sprintf("%x", sha1.Sum(b)), nil
+}
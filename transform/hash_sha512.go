@@ -0,0 +1,55 @@
+package transform
+
+import (
+	"context"
+	"crypto/sha512"
+	"encoding/json"
+	"fmt"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+)
+
+func newHashSHA512(_ context.Context, cfg config.Config) (*hashSHA512, error) {
+	conf := hashConfig{}
+	if err := conf.Decode(cfg.Settings); err != nil {
+		return nil, fmt.Errorf("transform hash_sha512: %v", err)
+	}
+
+	if conf.ID == "" {
+		conf.ID = "hash_sha512"
+	}
+
+	if err := conf.Validate(); err != nil {
+		return nil, fmt.Errorf("transform %s: %v", conf.ID, err)
+	}
+
+	tf := hashSHA512{
+		conf:     conf,
+		isObject: conf.Object.SourceKey != "" && conf.Object.TargetKey != "",
+	}
+
+	return &tf, nil
+}
+
+type hashSHA512 struct {
+	conf     hashConfig
+	isObject bool
+}
+
+func (tf *hashSHA512) Transform(ctx context.Context, msg *message.Message) ([]*message.Message, error) {
+	if msg.IsControl() {
+		return []*message.Message{msg}, nil
+	}
+
+	return hashApply(msg, tf.conf, tf.isObject, hashSHA512Sum)
+}
+
+func (tf *hashSHA512) String() string {
+	b, _ := json.Marshal(tf.conf)
+	return string(b)
+}
+
+func hashSHA512Sum(b []byte) (string, error) {
+	return fmt.Sprintf("%x", sha512.Sum512(b)), nil
+}
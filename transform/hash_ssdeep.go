@@ -0,0 +1,64 @@
+package transform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/glaslos/ssdeep"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+)
+
+func newHashSSDeep(_ context.Context, cfg config.Config) (*hashSSDeep, error) {
+	conf := hashConfig{}
+	if err := conf.Decode(cfg.Settings); err != nil {
+		return nil, fmt.Errorf("transform hash_ssdeep: %v", err)
+	}
+
+	if conf.ID == "" {
+		conf.ID = "hash_ssdeep"
+	}
+
+	if err := conf.Validate(); err != nil {
+		return nil, fmt.Errorf("transform %s: %v", conf.ID, err)
+	}
+
+	tf := hashSSDeep{
+		conf:     conf,
+		isObject: conf.Object.SourceKey != "" && conf.Object.TargetKey != "",
+	}
+
+	return &tf, nil
+}
+
+// hashSSDeep hashes values with ssdeep, a fuzzy hash that is used to find
+// similar files. Values that are 4096 bytes or smaller are not hashed and are
+// replaced with an empty string.
+type hashSSDeep struct {
+	conf     hashConfig
+	isObject bool
+}
+
+func (tf *hashSSDeep) Transform(ctx context.Context, msg *message.Message) ([]*message.Message, error) {
+	if msg.IsControl() {
+		return []*message.Message{msg}, nil
+	}
+
+	return hashApply(msg, tf.conf, tf.isObject, hashSSDeepSum)
+}
+
+func (tf *hashSSDeep) String() string {
+	b, _ := json.Marshal(tf.conf)
+	return string(b)
+}
+
+func hashSSDeepSum(b []byte) (string, error) {
+	s, err := ssdeep.FuzzyBytes(b)
+	if err == ssdeep.ErrFileTooSmall {
+		return "", errHashInputTooSmall
+	}
+
+	return s, err
+}
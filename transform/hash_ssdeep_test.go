@@ -0,0 +1,182 @@
+package transform
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+)
+
+var _ Transformer = &hashSSDeep{}
+
+// hashFuzzyTestData is large enough to be hashed by all fuzzy hashes.
+var hashFuzzyTestData = func() []byte {
+	var b bytes.Buffer
+	for i := 0; i < 500; i++ {
+		fmt.Fprintf(&b, "line %d: the quick brown fox jumps over the lazy dog %d\n", i, i*i)
+	}
+
+	return b.Bytes()
+}()
+
+// hashFuzzyTestObject contains hashFuzzyTestData in the key "a".
+var hashFuzzyTestObject = func() []byte {
+	b, _ := json.Marshal(map[string]string{"a": string(hashFuzzyTestData)})
+	return b
+}()
+
+var hashSSDeepTests = []struct {
+	name     string
+	cfg      config.Config
+	test     []byte
+	expected [][]byte
+}{
+	{
+		"data",
+		config.Config{},
+		hashFuzzyTestData,
+		[][]byte{
+			[]byte(`192:wotsKq+WY/ObeQc9F5KiXNRRAy/UsGId6XIHHm3bWIYfua0sxG:tT1ObeQcJKiXNROy/UMHTIiukG`),
+		},
+	},
+	{
+		"object",
+		config.Config{
+			Settings: map[string]interface{}{
+				"object": map[string]interface{}{
+					"source_key": "a",
+					"target_key": "a",
+				},
+			},
+		},
+		hashFuzzyTestObject,
+		[][]byte{
+			[]byte(`{"a":"192:wotsKq+WY/ObeQc9F5KiXNRRAy/UsGId6XIHHm3bWIYfua0sxG:tT1ObeQcJKiXNROy/UMHTIiukG"}`),
+		},
+	},
+	{
+		"data too small",
+		config.Config{},
+		[]byte(`a`),
+		[][]byte{
+			[]byte(``),
+		},
+	},
+	{
+		"object too small",
+		config.Config{
+			Settings: map[string]interface{}{
+				"object": map[string]interface{}{
+					"source_key": "a",
+					"target_key": "a",
+				},
+			},
+		},
+		[]byte(`{"a":"b"}`),
+		[][]byte{
+			[]byte(`{"a":""}`),
+		},
+	},
+}
+
+func TestHashSSDeep(t *testing.T) {
+	ctx := context.TODO()
+	for _, test := range hashSSDeepTests {
+		t.Run(test.name, func(t *testing.T) {
+			tf, err := newHashSSDeep(ctx, test.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			msg := message.New().SetData(test.test)
+			result, err := tf.Transform(ctx, msg)
+			if err != nil {
+				t.Error(err)
+			}
+
+			var data [][]byte
+			for _, c := range result {
+				data = append(data, c.Data())
+			}
+
+			if !reflect.DeepEqual(data, test.expected) {
+				t.Errorf("expected %s, got %s", test.expected, data)
+			}
+		})
+	}
+}
+
+func benchmarkHashSSDeep(b *testing.B, tf *hashSSDeep, data []byte) {
+	ctx := context.TODO()
+	for i := 0; i < b.N; i++ {
+		msg := message.New().SetData(data)
+		_, _ = tf.Transform(ctx, msg)
+	}
+}
+
+func BenchmarkHashSSDeep(b *testing.B) {
+	for _, test := range hashSSDeepTests {
+		tf, err := newHashSSDeep(context.TODO(), test.cfg)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(test.name,
+			func(b *testing.B) {
+				benchmarkHashSSDeep(b, tf, test.test)
+			},
+		)
+	}
+}
+
+func FuzzTestHashSSDeep(f *testing.F) {
+	testcases := [][]byte{
+		[]byte(`a`),
+		[]byte(`{"a":"b"}`),
+		[]byte(``),
+		[]byte(`{"a":""}`),
+	}
+
+	for _, tc := range testcases {
+		f.Add(tc)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ctx := context.TODO()
+		msg := message.New().SetData(data)
+
+		// Test with default settings
+		tf, err := newHashSSDeep(ctx, config.Config{})
+		if err != nil {
+			return
+		}
+
+		_, err = tf.Transform(ctx, msg)
+		if err != nil {
+			return
+		}
+
+		// Test with object settings
+		tf, err = newHashSSDeep(ctx, config.Config{
+			Settings: map[string]interface{}{
+				"object": map[string]interface{}{
+					"source_key": "a",
+					"target_key": "a",
+				},
+			},
+		})
+		if err != nil {
+			return
+		}
+
+		_, err = tf.Transform(ctx, msg)
+		if err != nil {
+			return
+		}
+	})
+}
@@ -0,0 +1,74 @@
+package transform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/glaslos/tlsh"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+)
+
+func newHashTLSH(_ context.Context, cfg config.Config) (*hashTLSH, error) {
+	conf := hashConfig{}
+	if err := conf.Decode(cfg.Settings); err != nil {
+		return nil, fmt.Errorf("transform hash_tlsh: %v", err)
+	}
+
+	if conf.ID == "" {
+		conf.ID = "hash_tlsh"
+	}
+
+	if err := conf.Validate(); err != nil {
+		return nil, fmt.Errorf("transform %s: %v", conf.ID, err)
+	}
+
+	tf := hashTLSH{
+		conf:     conf,
+		isObject: conf.Object.SourceKey != "" && conf.Object.TargetKey != "",
+	}
+
+	return &tf, nil
+}
+
+// hashTLSH hashes values with TLSH, a fuzzy hash that is used to find similar
+// files. Values that are smaller than 50 bytes are not hashed and are replaced
+// with an empty string. Hashes use the TLSH version 4 format (prefixed with
+// "T1"), which is used by threat intelligence feeds.
+type hashTLSH struct {
+	conf     hashConfig
+	isObject bool
+}
+
+func (tf *hashTLSH) Transform(ctx context.Context, msg *message.Message) ([]*message.Message, error) {
+	if msg.IsControl() {
+		return []*message.Message{msg}, nil
+	}
+
+	return hashApply(msg, tf.conf, tf.isObject, hashTLSHSum)
+}
+
+func (tf *hashTLSH) String() string {
+	b, _ := json.Marshal(tf.conf)
+	return string(b)
+}
+
+// hashTLSHMinSize is the minimum number of bytes that TLSH requires to
+// produce a meaningful hash.
+const hashTLSHMinSize = 50
+
+func hashTLSHSum(b []byte) (string, error) {
+	if len(b) < hashTLSHMinSize {
+		return "", errHashInputTooSmall
+	}
+
+	t, err := tlsh.HashBytes(b)
+	if err != nil {
+		return "", err
+	}
+
+	return "T1" + strings.ToUpper(t.String()), nil
+}
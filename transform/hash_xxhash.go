@@ -0,0 +1,58 @@
+package transform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/cespare/xxhash/v2"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+)
+
+func newHashXXHash(_ context.Context, cfg config.Config) (*hashXXHash, error) {
+	conf := hashConfig{}
+	if err := conf.Decode(cfg.Settings); err != nil {
+		return nil, fmt.Errorf("transform hash_xxhash: %v", err)
+	}
+
+	if conf.ID == "" {
+		conf.ID = "hash_xxhash"
+	}
+
+	if err := conf.Validate(); err != nil {
+		return nil, fmt.Errorf("transform %s: %v", conf.ID, err)
+	}
+
+	tf := hashXXHash{
+		conf:     conf,
+		isObject: conf.Object.SourceKey != "" && conf.Object.TargetKey != "",
+	}
+
+	return &tf, nil
+}
+
+// hashXXHash hashes values with XXH64. This is not a cryptographic hash and
+// is intended for partition keys and checksums.
+type hashXXHash struct {
+	conf     hashConfig
+	isObject bool
+}
+
+func (tf *hashXXHash) Transform(ctx context.Context, msg *message.Message) ([]*message.Message, error) {
+	if msg.IsControl() {
+		return []*message.Message{msg}, nil
+	}
+
+	return hashApply(msg, tf.conf, tf.isObject, hashXXHashSum)
+}
+
+func (tf *hashXXHash) String() string {
+	b, _ := json.Marshal(tf.conf)
+	return string(b)
+}
+
+func hashXXHashSum(b []byte) (string, error) {
+	return fmt.Sprintf("%016x", xxhash.Sum64(b)), nil
+}
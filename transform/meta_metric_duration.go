@@ -78,6 +78,10 @@ func (tf *metaMetricDuration) Transform(ctx context.Context, msg *message.Messag
 			return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, err)
 		}
 
+		if err := metrics.Flush(ctx, tf.metric); err != nil {
+			return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, err)
+		}
+
 		msgs, err := Apply(ctx, tf.tfs, msg)
 		if err != nil {
 			return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, err)
@@ -0,0 +1,288 @@
+package transform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/brexhq/substation/v2/condition"
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+
+	iconfig "github.com/brexhq/substation/v2/internal/config"
+	"github.com/brexhq/substation/v2/internal/kv"
+)
+
+type metaThresholdConfig struct {
+	// Condition that must be true for a message to be counted.
+	//
+	// This is optional and defaults to counting all messages.
+	Condition config.Config `json:"condition"`
+	// Threshold is the number of messages that must be exceeded within the
+	// duration for an alert to be emitted.
+	Threshold int `json:"threshold"`
+	// Duration is the amount of time that messages are counted for (e.g.,
+	// "5m"). Messages are counted in a sliding window that ends when each
+	// message is received.
+	//
+	// This is optional and defaults to "1m".
+	Duration string `json:"duration"`
+	// Cooldown is the amount of time that alerts are suppressed for after an
+	// alert is emitted for a key.
+	//
+	// This is optional and defaults to Duration.
+	Cooldown string `json:"cooldown"`
+	// Prefix is prepended to keys and can be used to simplify
+	// data management within a KV store.
+	//
+	// This is optional and defaults to an empty string.
+	Prefix string `json:"prefix"`
+
+	ID string `json:"id"`
+	// Object.BatchKey retrieves the value that messages are grouped by (e.g.,
+	// "user.name"), and Object.TargetKey is where the alert is placed in the
+	// alert message.
+	Object iconfig.Object `json:"object"`
+	// KVStore stores the counts and cooldowns. The store must support counters
+	// and locking (aws_dynamodb or memory).
+	KVStore config.Config `json:"kv_store"`
+}
+
+func (c *metaThresholdConfig) Decode(in interface{}) error {
+	return iconfig.Decode(in, c)
+}
+
+func (c *metaThresholdConfig) Validate() error {
+	if c.Threshold < 1 {
+		return fmt.Errorf("threshold: %v", iconfig.ErrMissingRequiredOption)
+	}
+
+	if c.KVStore.Type == "" {
+		return fmt.Errorf("kv_store: %v", iconfig.ErrMissingRequiredOption)
+	}
+
+	return nil
+}
+
+func newMetaThreshold(ctx context.Context, cfg config.Config) (*metaThreshold, error) {
+	conf := metaThresholdConfig{}
+	if err := conf.Decode(cfg.Settings); err != nil {
+		return nil, fmt.Errorf("transform meta_threshold: %v", err)
+	}
+
+	if conf.ID == "" {
+		conf.ID = "meta_threshold"
+	}
+
+	if conf.Duration == "" {
+		conf.Duration = "1m"
+	}
+
+	if conf.Cooldown == "" {
+		conf.Cooldown = conf.Duration
+	}
+
+	if err := conf.Validate(); err != nil {
+		return nil, fmt.Errorf("transform %s: %v", conf.ID, err)
+	}
+
+	dur, err := time.ParseDuration(conf.Duration)
+	if err != nil {
+		return nil, fmt.Errorf("transform %s: duration: %v", conf.ID, err)
+	}
+
+	if dur < time.Second {
+		return nil, fmt.Errorf("transform %s: duration %s: %v", conf.ID, conf.Duration, iconfig.ErrInvalidOption)
+	}
+
+	cooldown, err := time.ParseDuration(conf.Cooldown)
+	if err != nil {
+		return nil, fmt.Errorf("transform %s: cooldown: %v", conf.ID, err)
+	}
+
+	if cooldown < time.Second {
+		return nil, fmt.Errorf("transform %s: cooldown %s: %v", conf.ID, conf.Cooldown, iconfig.ErrInvalidOption)
+	}
+
+	tf := metaThreshold{
+		conf:     conf,
+		dur:      dur,
+		cooldown: cooldown,
+		now:      time.Now,
+	}
+
+	if conf.Condition.Type != "" {
+		cnd, err := condition.New(ctx, conf.Condition)
+		if err != nil {
+			return nil, fmt.Errorf("transform %s: %v", conf.ID, err)
+		}
+
+		tf.cnd = cnd
+	}
+
+	counter, err := kv.GetCounter(conf.KVStore)
+	if err != nil {
+		return nil, fmt.Errorf("transform %s: %v", conf.ID, err)
+	}
+
+	if err := counter.Setup(ctx); err != nil {
+		return nil, fmt.Errorf("transform %s: %v", conf.ID, err)
+	}
+	tf.counter = counter
+
+	locker, err := kv.GetLocker(conf.KVStore)
+	if err != nil {
+		return nil, fmt.Errorf("transform %s: %v", conf.ID, err)
+	}
+
+	if err := locker.Setup(ctx); err != nil {
+		return nil, fmt.Errorf("transform %s: %v", conf.ID, err)
+	}
+	tf.locker = locker
+
+	return &tf, nil
+}
+
+// metaThreshold emits an alert when the number of messages that match a
+// condition exceeds a threshold within a duration. Alerts are emitted once
+// per key until the cooldown ends.
+//
+// Messages are counted by incrementing counters in the KV store. Each counter
+// contains the messages received during one duration, and the count in the
+// sliding window is estimated from the current and previous counters. The
+// previous counter is weighted by how much of it overlaps the window, which
+// assumes that its messages were received at an even rate.
+type metaThreshold struct {
+	conf     metaThresholdConfig
+	cnd      condition.Conditioner
+	counter  kv.Counter
+	locker   kv.Locker
+	dur      time.Duration
+	cooldown time.Duration
+	now      func() time.Time
+}
+
+type metaThresholdAlert struct {
+	Key       string `json:"key,omitempty"`
+	Count     int    `json:"count"`
+	Threshold int    `json:"threshold"`
+	// Start and End are Unix nanoseconds.
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+}
+
+func (tf *metaThreshold) Transform(ctx context.Context, msg *message.Message) ([]*message.Message, error) {
+	if msg.IsControl() {
+		return []*message.Message{msg}, nil
+	}
+
+	if tf.cnd != nil {
+		ok, err := tf.cnd.Condition(ctx, msg)
+		if err != nil {
+			return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, err)
+		}
+
+		if !ok {
+			return []*message.Message{msg}, nil
+		}
+	}
+
+	now := tf.now()
+	group := msg.GetValue(tf.conf.Object.BatchKey).String()
+
+	count, err := tf.add(ctx, group, now)
+	if err != nil {
+		return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, err)
+	}
+
+	if count <= tf.conf.Threshold {
+		return []*message.Message{msg}, nil
+	}
+
+	// The lock is held until the cooldown ends, so only one alert is emitted
+	// per cooldown even if the store is shared by many processes.
+	if err := tf.locker.Lock(ctx, tf.key(group, "cooldown"), now.Add(tf.cooldown).Unix()); err != nil {
+		if err == kv.ErrNoLock {
+			return []*message.Message{msg}, nil
+		}
+
+		return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, err)
+	}
+
+	alert := metaThresholdAlert{
+		Key:       group,
+		Count:     count,
+		Threshold: tf.conf.Threshold,
+		Start:     now.Add(-tf.dur).UnixNano(),
+		End:       now.UnixNano(),
+	}
+
+	outMsg := message.New()
+	if tf.conf.Object.TargetKey != "" {
+		if err := outMsg.SetValue(tf.conf.Object.TargetKey, alert); err != nil {
+			return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, err)
+		}
+	} else {
+		b, err := json.Marshal(alert)
+		if err != nil {
+			return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, err)
+		}
+
+		outMsg.SetData(b)
+	}
+
+	return []*message.Message{outMsg, msg}, nil
+}
+
+func (tf *metaThreshold) String() string {
+	b, _ := json.Marshal(tf.conf)
+	return string(b)
+}
+
+// add counts the message and returns the estimated number of messages
+// received for the group during the duration.
+func (tf *metaThreshold) add(ctx context.Context, group string, now time.Time) (int, error) {
+	bucket := now.Truncate(tf.dur)
+
+	ttl := bucket.Add(2 * tf.dur).Unix()
+	cur, err := tf.counter.IncrementWithTTL(ctx, tf.key(group, strconv.FormatInt(bucket.Unix(), 10)), 1, ttl)
+	if err != nil {
+		return 0, err
+	}
+
+	v, err := tf.counter.Get(ctx, tf.key(group, strconv.FormatInt(bucket.Add(-tf.dur).Unix(), 10)))
+	if err != nil {
+		return 0, err
+	}
+
+	weight := 1 - float64(now.Sub(bucket))/float64(tf.dur)
+	count := float64(cur) + float64(metaThresholdInt(v))*weight
+
+	return int(math.Round(count)), nil
+}
+
+func (tf *metaThreshold) key(group, suffix string) string {
+	if tf.conf.Prefix != "" {
+		return fmt.Sprint(tf.conf.Prefix, ":", group, ":", suffix)
+	}
+
+	return fmt.Sprint(group, ":", suffix)
+}
+
+// metaThresholdInt converts a counter that is retrieved from a KV store to an
+// integer. Stores return numbers as different types.
+func metaThresholdInt(v interface{}) int64 {
+	switch v := v.(type) {
+	case int64:
+		return v
+	case int:
+		return int64(v)
+	case float64:
+		return int64(v)
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,220 @@
+package transform
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+)
+
+var _ Transformer = &metaThreshold{}
+
+var metaThresholdTests = []struct {
+	name string
+	cfg  config.Config
+	data []aggWindowTestMessage
+	// expected are the keys and counts of alerts (e.g., "a:3").
+	expected []string
+}{
+	{
+		"threshold",
+		config.Config{
+			Settings: map[string]interface{}{
+				"prefix":    "threshold",
+				"threshold": 2,
+				"duration":  "1m",
+				"cooldown":  "5m",
+				"condition": map[string]interface{}{
+					"type": "string_equal_to",
+					"settings": map[string]interface{}{
+						"object": map[string]interface{}{
+							"source_key": "outcome",
+						},
+						"value": "failure",
+					},
+				},
+				"object": map[string]interface{}{
+					"batch_key": "user",
+				},
+				"kv_store": map[string]interface{}{
+					"type": "memory",
+				},
+			},
+		},
+		[]aggWindowTestMessage{
+			{0, `{"user":"a","outcome":"failure"}`},
+			{time.Second, `{"user":"a","outcome":"success"}`},
+			{2 * time.Second, `{"user":"b","outcome":"failure"}`},
+			{3 * time.Second, `{"user":"a","outcome":"failure"}`},
+			// The threshold is exceeded.
+			{4 * time.Second, `{"user":"a","outcome":"failure"}`},
+			// The cooldown suppresses alerts.
+			{5 * time.Second, `{"user":"a","outcome":"failure"}`},
+			{6 * time.Second, `{"user":"b","outcome":"failure"}`},
+			{7 * time.Second, `{"user":"b","outcome":"failure"}`},
+		},
+		[]string{"a:3", "b:3"},
+	},
+	{
+		"sliding",
+		config.Config{
+			Settings: map[string]interface{}{
+				"prefix":    "sliding",
+				"threshold": 2,
+				"duration":  "1m",
+				"object": map[string]interface{}{
+					"target_key": "alert",
+				},
+				"kv_store": map[string]interface{}{
+					"type": "memory",
+				},
+			},
+		},
+		[]aggWindowTestMessage{
+			// The first two messages are in a different bucket than the
+			// third message, but are in the same duration.
+			{50 * time.Second, `{"a":"b"}`},
+			{55 * time.Second, `{"a":"b"}`},
+			{65 * time.Second, `{"a":"b"}`},
+		},
+		[]string{":3"},
+	},
+	{
+		"weighted",
+		config.Config{
+			Settings: map[string]interface{}{
+				"prefix":    "weighted",
+				"threshold": 4,
+				"duration":  "1m",
+				"kv_store": map[string]interface{}{
+					"type": "memory",
+				},
+			},
+		},
+		[]aggWindowTestMessage{
+			{0, `{"a":"b"}`},
+			{time.Second, `{"a":"b"}`},
+			{2 * time.Second, `{"a":"b"}`},
+			{3 * time.Second, `{"a":"b"}`},
+			// The previous bucket is weighted by 0.75, so the estimate is
+			// 1 + 4 * 0.75 = 4.
+			{75 * time.Second, `{"a":"b"}`},
+			// The estimate is 2 + 4 * (2/3) = 4.67.
+			{80 * time.Second, `{"a":"b"}`},
+		},
+		[]string{":5"},
+	},
+	{
+		"expired",
+		config.Config{
+			Settings: map[string]interface{}{
+				"prefix":    "expired",
+				"threshold": 2,
+				"duration":  "1m",
+				"kv_store": map[string]interface{}{
+					"type": "memory",
+				},
+			},
+		},
+		[]aggWindowTestMessage{
+			{0, `{"a":"b"}`},
+			{30 * time.Second, `{"a":"b"}`},
+			{90 * time.Second, `{"a":"b"}`},
+		},
+		nil,
+	},
+}
+
+func TestMetaThreshold(t *testing.T) {
+	ctx := context.TODO()
+	for _, test := range metaThresholdTests {
+		t.Run(test.name, func(t *testing.T) {
+			tf, err := newMetaThreshold(ctx, test.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// The memory store uses the current time for TTLs, so the clock
+			// starts at the current minute.
+			start := time.Now().Truncate(time.Minute)
+
+			var now time.Time
+			tf.now = func() time.Time { return now }
+
+			var result []string
+			for _, d := range test.data {
+				now = start.Add(d.offset)
+
+				msg := message.New().SetData([]byte(d.data))
+				msgs, err := tf.Transform(ctx, msg)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if msgs[len(msgs)-1] != msg {
+					t.Fatalf("expected the message to be returned last")
+				}
+
+				for _, m := range msgs[:len(msgs)-1] {
+					b := m.Data()
+					if tf.conf.Object.TargetKey != "" {
+						b = m.GetValue(tf.conf.Object.TargetKey).Bytes()
+					}
+
+					alert := message.New().SetData(b)
+					if d := alert.GetValue("end").Int() - alert.GetValue("start").Int(); d != int64(time.Minute) {
+						t.Errorf("expected alert duration of 1m, got %v", time.Duration(d))
+					}
+
+					result = append(result, fmt.Sprintf("%s:%d", alert.GetValue("key"), alert.GetValue("count").Int()))
+				}
+			}
+
+			if fmt.Sprint(result) != fmt.Sprint(test.expected) {
+				t.Errorf("expected %s, got %s", test.expected, result)
+			}
+		})
+	}
+}
+
+func FuzzTestMetaThreshold(f *testing.F) {
+	testcases := [][]byte{
+		[]byte(`{"user":"a"}`),
+		[]byte(`{"user":"a"}`),
+		[]byte(`{"user":"b"}`),
+		[]byte(``),
+	}
+
+	for _, tc := range testcases {
+		f.Add(tc)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ctx := context.TODO()
+		msg := message.New().SetData(data)
+
+		tf, err := newMetaThreshold(ctx, config.Config{
+			Settings: map[string]interface{}{
+				"prefix":    "fuzz",
+				"threshold": 1,
+				"object": map[string]interface{}{
+					"batch_key": "user",
+				},
+				"kv_store": map[string]interface{}{
+					"type": "memory",
+				},
+			},
+		})
+		if err != nil {
+			return
+		}
+
+		_, err = tf.Transform(ctx, msg)
+		if err != nil {
+			return
+		}
+	})
+}
@@ -0,0 +1,383 @@
+package transform
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+	"gopkg.in/yaml.v3"
+
+	"github.com/brexhq/substation/v2/message"
+
+	iconfig "github.com/brexhq/substation/v2/internal/config"
+	"github.com/brexhq/substation/v2/internal/file"
+)
+
+// schemaMappings contains the built-in mappings for each schema. Mappings are
+// stored as "schema/<schema>/<source>.json".
+//
+//go:embed schema
+var schemaMappings embed.FS
+
+type schemaConfig struct {
+	// Source is the name of a built-in mapping. Must be one of:
+	// aws_cloudtrail, aws_guardduty, aws_vpc_flow, okta.
+	//
+	// This is optional if Mapping is set.
+	Source string `json:"source"`
+	// Mapping is the location of a mapping file (JSON or YAML). The file can
+	// be a local file, an HTTP(S) URL, or an AWS S3 object. If Source is also
+	// set, then the fields in the file are applied after the built-in fields,
+	// which can add or replace fields.
+	//
+	// This is optional if Source is set.
+	Mapping string `json:"mapping"`
+	// Unmapped determines if the original event is stored in the "unmapped"
+	// key of the result.
+	//
+	// This is optional and defaults to false.
+	Unmapped bool `json:"unmapped"`
+
+	ID string `json:"id"`
+	// Object.SourceKey retrieves the event and Object.TargetKey is where the
+	// result is stored. If these are not set, then the message data is
+	// replaced with the result.
+	Object iconfig.Object `json:"object"`
+}
+
+func (c *schemaConfig) Decode(in interface{}) error {
+	return iconfig.Decode(in, c)
+}
+
+func (c *schemaConfig) Validate() error {
+	if c.Object.SourceKey == "" && c.Object.TargetKey != "" {
+		return fmt.Errorf("object_source_key: %v", iconfig.ErrMissingRequiredOption)
+	}
+
+	if c.Object.SourceKey != "" && c.Object.TargetKey == "" {
+		return fmt.Errorf("object_target_key: %v", iconfig.ErrMissingRequiredOption)
+	}
+
+	if c.Source == "" && c.Mapping == "" {
+		return fmt.Errorf("source: %v", iconfig.ErrMissingRequiredOption)
+	}
+
+	return nil
+}
+
+// schemaMapping describes how an event is converted to a schema.
+type schemaMapping struct {
+	// Columns are the names of values in delimited text (e.g., VPC Flow Logs).
+	// If this is set, then events that are not JSON objects are split by
+	// Delimiter and converted to objects before they are mapped.
+	Columns []string `json:"columns"`
+	// Delimiter separates values in delimited text. If this is a space, then
+	// values are separated by any amount of whitespace.
+	//
+	// This is optional and defaults to a space.
+	Delimiter string `json:"delimiter"`
+	// Null is a value in delimited text that is treated as missing (e.g., "-").
+	Null string `json:"null"`
+	// Fields are applied in order, so later fields can replace earlier fields.
+	Fields []schemaField `json:"fields"`
+}
+
+type schemaField struct {
+	// Target is the key in the result that the value is set to. Keys that
+	// start with "@" must be escaped (e.g., "\\@timestamp").
+	Target string `json:"target"`
+	// Source is the key in the event that the value is retrieved from. If
+	// this is not set, then Value is used.
+	Source string `json:"source"`
+	// Value is a constant that is set to the target.
+	Value interface{} `json:"value"`
+	// Cases replace the source value with the value of the first case that
+	// matches. If no case matches, then Default is used.
+	Cases []schemaCase `json:"cases"`
+	// Default is used if the source value is missing or no case matches. If
+	// this is not set, then the target is not set.
+	Default interface{} `json:"default"`
+	// Type converts the source value. Must be one of: int, float, string,
+	// time (Unix milliseconds), timestamp (ISO 8601), array.
+	//
+	// This is optional and defaults to the type of the source value.
+	Type string `json:"type"`
+}
+
+type schemaCase struct {
+	// Match is a pattern that the source value must match. "*" matches any
+	// number of characters.
+	Match string `json:"match"`
+	re    *regexp.Regexp
+	// GTE is a number that the source value must be greater than or equal to.
+	GTE *float64 `json:"gte"`
+	// Value is set to the target if the case matches. If Match and GTE are
+	// not set, then the case matches any value.
+	Value interface{} `json:"value"`
+}
+
+// newSchemaMapping returns the built-in mapping for the source and adds the
+// fields from the mapping file.
+func newSchemaMapping(ctx context.Context, schema string, conf schemaConfig) (*schemaMapping, error) {
+	mapping := &schemaMapping{}
+	if conf.Source != "" {
+		b, err := schemaMappings.ReadFile(path.Join("schema", schema, conf.Source+".json"))
+		if err != nil {
+			return nil, fmt.Errorf("source %s: %v", conf.Source, iconfig.ErrInvalidOption)
+		}
+
+		if err := json.Unmarshal(b, mapping); err != nil {
+			return nil, fmt.Errorf("source %s: %v", conf.Source, err)
+		}
+	}
+
+	if conf.Mapping != "" {
+		m, err := schemaReadMapping(ctx, conf.Mapping)
+		if err != nil {
+			return nil, fmt.Errorf("mapping %s: %v", conf.Mapping, err)
+		}
+
+		if len(m.Columns) > 0 {
+			mapping.Columns = m.Columns
+			mapping.Delimiter = m.Delimiter
+			mapping.Null = m.Null
+		}
+
+		mapping.Fields = append(mapping.Fields, m.Fields...)
+	}
+
+	if mapping.Delimiter == "" {
+		mapping.Delimiter = " "
+	}
+
+	for i, f := range mapping.Fields {
+		if f.Target == "" {
+			return nil, fmt.Errorf("fields %d: target: %v", i, iconfig.ErrMissingRequiredOption)
+		}
+
+		switch f.Type {
+		case "", "int", "float", "string", "time", "timestamp", "array":
+		default:
+			return nil, fmt.Errorf("fields %d: type %s: %v", i, f.Type, iconfig.ErrInvalidOption)
+		}
+
+		for j, c := range f.Cases {
+			if c.Match == "" {
+				continue
+			}
+
+			parts := strings.Split(c.Match, "*")
+			for k, p := range parts {
+				parts[k] = regexp.QuoteMeta(p)
+			}
+
+			mapping.Fields[i].Cases[j].re = regexp.MustCompile("^" + strings.Join(parts, ".*") + "$")
+		}
+	}
+
+	return mapping, nil
+}
+
+// schemaReadMapping reads a mapping file. YAML is a superset of JSON, so both
+// are decoded as YAML.
+func schemaReadMapping(ctx context.Context, location string) (*schemaMapping, error) {
+	p, err := file.Get(ctx, location)
+	defer os.Remove(p)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := os.ReadFile(p)
+	if err != nil {
+		return nil, err
+	}
+
+	var v interface{}
+	if err := yaml.Unmarshal(b, &v); err != nil {
+		return nil, err
+	}
+
+	mapping := &schemaMapping{}
+	if err := iconfig.Decode(v, mapping); err != nil {
+		return nil, err
+	}
+
+	return mapping, nil
+}
+
+// Map converts the event to the schema and returns false if the event is not
+// an object. If keepUnmapped is true, then the event is stored in the
+// "unmapped" key of the result.
+func (m *schemaMapping) Map(event []byte, keepUnmapped bool) ([]byte, bool, error) {
+	if len(m.Columns) > 0 && !gjson.ValidBytes(event) {
+		b, err := m.columns(string(event))
+		if err != nil {
+			return nil, false, err
+		}
+
+		event = b
+	}
+
+	res := gjson.ParseBytes(event)
+	if len(m.Columns) > 0 && res.Type == gjson.String {
+		b, err := m.columns(res.String())
+		if err != nil {
+			return nil, false, err
+		}
+
+		res = gjson.ParseBytes(b)
+	}
+
+	if !gjson.Valid(res.Raw) || !res.IsObject() {
+		return nil, false, nil
+	}
+
+	out := []byte(`{}`)
+	for _, f := range m.Fields {
+		v, ok := f.value(res)
+		if !ok {
+			continue
+		}
+
+		var err error
+		if out, err = sjson.SetBytes(out, f.Target, v); err != nil {
+			return nil, false, err
+		}
+	}
+
+	if keepUnmapped {
+		var err error
+		if out, err = sjson.SetRawBytes(out, "unmapped", []byte(res.Raw)); err != nil {
+			return nil, false, err
+		}
+	}
+
+	return out, true, nil
+}
+
+// columns converts delimited text to an object.
+func (m *schemaMapping) columns(s string) ([]byte, error) {
+	var values []string
+	if m.Delimiter == " " {
+		values = strings.Fields(s)
+	} else {
+		values = strings.Split(s, m.Delimiter)
+	}
+
+	out := []byte(`{}`)
+	for i, v := range values {
+		if i >= len(m.Columns) {
+			break
+		}
+
+		if v == m.Null {
+			continue
+		}
+
+		var err error
+		if out, err = sjson.SetBytes(out, m.Columns[i], v); err != nil {
+			return nil, err
+		}
+	}
+
+	return out, nil
+}
+
+// value returns the value of the field and false if the target is not set.
+func (f *schemaField) value(event gjson.Result) (interface{}, bool) {
+	if f.Source == "" {
+		return f.Value, f.Value != nil
+	}
+
+	v := event.Get(f.Source)
+	if !v.Exists() || v.Type == gjson.Null {
+		return f.Default, f.Default != nil
+	}
+
+	if len(f.Cases) > 0 {
+		for _, c := range f.Cases {
+			if c.re != nil && !c.re.MatchString(v.String()) {
+				continue
+			}
+
+			if c.GTE != nil && v.Float() < *c.GTE {
+				continue
+			}
+
+			return c.Value, true
+		}
+
+		return f.Default, f.Default != nil
+	}
+
+	out, ok := schemaConvert(v, f.Type)
+	if !ok {
+		return f.Default, f.Default != nil
+	}
+
+	return out, true
+}
+
+// schemaConvert converts a value to a type. Values that cannot be converted
+// return false.
+func schemaConvert(v gjson.Result, typ string) (interface{}, bool) {
+	switch typ {
+	case "int":
+		f, err := strconv.ParseFloat(v.String(), 64)
+		return int64(f), err == nil
+	case "float":
+		f, err := strconv.ParseFloat(v.String(), 64)
+		return f, err == nil
+	case "string":
+		return v.String(), true
+	case "time", "timestamp":
+		t, err := timeParse(v.String(), "auto", time.UTC, time.Now())
+		if err != nil {
+			return nil, false
+		}
+
+		if typ == "time" {
+			return t.UnixMilli(), true
+		}
+
+		return t.UTC().Format(timeDefaultFmt), true
+	case "array":
+		if v.IsArray() {
+			return v.Value(), true
+		}
+
+		return []interface{}{v.Value()}, true
+	default:
+		return v.Value(), true
+	}
+}
+
+// schemaEvent returns the event from the message data or Object.SourceKey.
+func schemaEvent(msg *message.Message, conf schemaConfig) ([]byte, bool) {
+	if conf.Object.SourceKey == "" {
+		return msg.Data(), true
+	}
+
+	value := msg.GetValue(conf.Object.SourceKey)
+	if !value.Exists() {
+		return nil, false
+	}
+
+	switch value.Value().(type) {
+	case map[string]interface{}:
+		return value.Bytes(), true
+	default:
+		// Delimited text is stored as a JSON string.
+		b, _ := json.Marshal(value.String())
+		return b, true
+	}
+}
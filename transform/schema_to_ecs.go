@@ -0,0 +1,82 @@
+package transform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+)
+
+func newSchemaToECS(ctx context.Context, cfg config.Config) (*schemaToECS, error) {
+	conf := schemaConfig{}
+	if err := conf.Decode(cfg.Settings); err != nil {
+		return nil, fmt.Errorf("transform schema_to_ecs: %v", err)
+	}
+
+	if conf.ID == "" {
+		conf.ID = "schema_to_ecs"
+	}
+
+	if err := conf.Validate(); err != nil {
+		return nil, fmt.Errorf("transform %s: %v", conf.ID, err)
+	}
+
+	mapping, err := newSchemaMapping(ctx, "ecs", conf)
+	if err != nil {
+		return nil, fmt.Errorf("transform %s: %v", conf.ID, err)
+	}
+
+	tf := schemaToECS{
+		conf:     conf,
+		isObject: conf.Object.SourceKey != "" && conf.Object.TargetKey != "",
+		mapping:  mapping,
+	}
+
+	return &tf, nil
+}
+
+// schemaToECS converts events to the Elastic Common Schema (ECS).
+type schemaToECS struct {
+	conf     schemaConfig
+	isObject bool
+
+	mapping *schemaMapping
+}
+
+func (tf *schemaToECS) Transform(ctx context.Context, msg *message.Message) ([]*message.Message, error) {
+	if msg.IsControl() {
+		return []*message.Message{msg}, nil
+	}
+
+	event, ok := schemaEvent(msg, tf.conf)
+	if !ok {
+		return []*message.Message{msg}, nil
+	}
+
+	out, ok, err := tf.mapping.Map(event, tf.conf.Unmapped)
+	if err != nil {
+		return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, err)
+	}
+
+	if !ok {
+		return []*message.Message{msg}, nil
+	}
+
+	if !tf.isObject {
+		msg.SetData(out)
+		return []*message.Message{msg}, nil
+	}
+
+	if err := msg.SetValue(tf.conf.Object.TargetKey, out); err != nil {
+		return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, err)
+	}
+
+	return []*message.Message{msg}, nil
+}
+
+func (tf *schemaToECS) String() string {
+	b, _ := json.Marshal(tf.conf)
+	return string(b)
+}
@@ -0,0 +1,158 @@
+package transform
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+)
+
+var _ Transformer = &schemaToECS{}
+
+var schemaToECSTests = []struct {
+	name     string
+	cfg      config.Config
+	test     []byte
+	expected [][]byte
+}{
+	// data tests
+	{
+		"data aws_cloudtrail",
+		config.Config{
+			Settings: map[string]interface{}{
+				"source": "aws_cloudtrail",
+			},
+		},
+		schemaTestCloudTrail,
+		[][]byte{
+			[]byte(`{"ecs":{"version":"8.11.0"},"@timestamp":"2024-01-02T03:04:05.000Z","event":{"kind":"event","module":"aws","dataset":"aws.cloudtrail","id":"E1","action":"GetObject","provider":"s3.amazonaws.com","outcome":"failure"},"error":{"code":"AccessDenied","message":"Access Denied"},"user":{"id":"AIDAEXAMPLE","name":"alice"},"source":{"address":"192.0.2.1"},"user_agent":{"original":"aws-cli/2.0"},"cloud":{"provider":"aws","region":"us-east-1","account":{"id":"123456789012"}}}`),
+		},
+	},
+	{
+		"data aws_vpc_flow",
+		config.Config{
+			Settings: map[string]interface{}{
+				"source": "aws_vpc_flow",
+			},
+		},
+		schemaTestVPCFlow,
+		[][]byte{
+			[]byte(`{"ecs":{"version":"8.11.0"},"@timestamp":"2021-05-04T15:06:01.000Z","event":{"kind":"event","module":"aws","dataset":"aws.vpcflow","category":["network"],"type":["connection"],"start":"2021-05-04T15:06:01.000Z","end":"2021-05-04T15:07:01.000Z","action":"reject","outcome":"failure"},"source":{"ip":"10.0.0.1","port":443},"destination":{"ip":"10.0.0.2","port":49152},"network":{"iana_number":"6","transport":"tcp","packets":10,"bytes":840},"cloud":{"provider":"aws","account":{"id":"123456789012"}}}`),
+		},
+	},
+	{
+		"data okta unmapped",
+		config.Config{
+			Settings: map[string]interface{}{
+				"source":   "okta",
+				"unmapped": true,
+			},
+		},
+		schemaTestOkta,
+		[][]byte{
+			[]byte(`{"ecs":{"version":"8.11.0"},"@timestamp":"2024-01-02T03:04:05.678Z","event":{"kind":"event","module":"okta","dataset":"okta.system","id":"U1","action":"user.session.start","outcome":"failure","reason":"INVALID_CREDENTIALS"},"message":"User login to Okta","user":{"id":"00u1","name":"alice@example.com","full_name":"Alice"},"source":{"ip":"192.0.2.1","geo":{"city_name":"Austin","region_name":"Texas","country_name":"United States"}},"user_agent":{"original":"Mozilla/5.0"},"unmapped":{"uuid":"U1","published":"2024-01-02T03:04:05.678Z","eventType":"user.session.start","displayMessage":"User login to Okta","severity":"INFO","actor":{"id":"00u1","type":"User","alternateId":"alice@example.com","displayName":"Alice"},"client":{"ipAddress":"192.0.2.1","userAgent":{"rawUserAgent":"Mozilla/5.0"},"geographicalContext":{"city":"Austin","state":"Texas","country":"United States"}},"outcome":{"result":"FAILURE","reason":"INVALID_CREDENTIALS"},"authenticationContext":{"externalSessionId":"S1"}}}`),
+		},
+	},
+	// object tests
+	{
+		"object aws_guardduty",
+		config.Config{
+			Settings: map[string]interface{}{
+				"object": map[string]interface{}{
+					"source_key": "detail",
+					"target_key": "detail",
+				},
+				"source": "aws_guardduty",
+			},
+		},
+		schemaTestGuardDuty,
+		[][]byte{
+			[]byte(`{"detail":{"ecs":{"version":"8.11.0"},"@timestamp":"2024-01-02T04:04:05.000Z","event":{"kind":"alert","module":"aws","dataset":"aws.guardduty","id":"F1","created":"2024-01-02T03:04:05.000Z","severity":5},"message":"Unprotected port is being probed.","rule":{"name":"Recon:EC2/PortProbeUnprotectedPort","description":"Port 22 is being probed."},"cloud":{"provider":"aws","region":"us-east-1","account":{"id":"123456789012"},"instance":{"id":"i-0123456789abcdef0"}}}}`),
+		},
+	},
+}
+
+func TestSchemaToECS(t *testing.T) {
+	ctx := context.TODO()
+	for _, test := range schemaToECSTests {
+		t.Run(test.name, func(t *testing.T) {
+			tf, err := newSchemaToECS(ctx, test.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			msg := message.New().SetData(test.test)
+			result, err := tf.Transform(ctx, msg)
+			if err != nil {
+				t.Error(err)
+			}
+
+			var data [][]byte
+			for _, c := range result {
+				data = append(data, c.Data())
+			}
+
+			if !reflect.DeepEqual(data, test.expected) {
+				t.Errorf("expected %s, got %s", test.expected, data)
+			}
+		})
+	}
+}
+
+func benchmarkSchemaToECS(b *testing.B, tf *schemaToECS, data []byte) {
+	ctx := context.TODO()
+	for i := 0; i < b.N; i++ {
+		msg := message.New().SetData(data)
+		_, _ = tf.Transform(ctx, msg)
+	}
+}
+
+func BenchmarkSchemaToECS(b *testing.B) {
+	for _, test := range schemaToECSTests {
+		tf, err := newSchemaToECS(context.TODO(), test.cfg)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(test.name,
+			func(b *testing.B) {
+				benchmarkSchemaToECS(b, tf, test.test)
+			},
+		)
+	}
+}
+
+func FuzzTestSchemaToECS(f *testing.F) {
+	testcases := [][]byte{
+		schemaTestCloudTrail,
+		schemaTestOkta,
+		[]byte(`{"published":"a"}`),
+		[]byte(`a`),
+		[]byte(``),
+	}
+
+	for _, tc := range testcases {
+		f.Add(tc)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ctx := context.TODO()
+		msg := message.New().SetData(data)
+
+		tf, err := newSchemaToECS(ctx, config.Config{
+			Settings: map[string]interface{}{
+				"source": "okta",
+			},
+		})
+		if err != nil {
+			return
+		}
+
+		_, err = tf.Transform(ctx, msg)
+		if err != nil {
+			return
+		}
+	})
+}
@@ -0,0 +1,94 @@
+package transform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+)
+
+func newSchemaToOCSF(ctx context.Context, cfg config.Config) (*schemaToOCSF, error) {
+	conf := schemaConfig{}
+	if err := conf.Decode(cfg.Settings); err != nil {
+		return nil, fmt.Errorf("transform schema_to_ocsf: %v", err)
+	}
+
+	if conf.ID == "" {
+		conf.ID = "schema_to_ocsf"
+	}
+
+	if err := conf.Validate(); err != nil {
+		return nil, fmt.Errorf("transform %s: %v", conf.ID, err)
+	}
+
+	mapping, err := newSchemaMapping(ctx, "ocsf", conf)
+	if err != nil {
+		return nil, fmt.Errorf("transform %s: %v", conf.ID, err)
+	}
+
+	tf := schemaToOCSF{
+		conf:     conf,
+		isObject: conf.Object.SourceKey != "" && conf.Object.TargetKey != "",
+		mapping:  mapping,
+	}
+
+	return &tf, nil
+}
+
+// schemaToOCSF converts events to the Open Cybersecurity Schema Framework
+// (OCSF). If the result has a class_uid and activity_id, then the type_uid is
+// derived from them.
+type schemaToOCSF struct {
+	conf     schemaConfig
+	isObject bool
+
+	mapping *schemaMapping
+}
+
+func (tf *schemaToOCSF) Transform(ctx context.Context, msg *message.Message) ([]*message.Message, error) {
+	if msg.IsControl() {
+		return []*message.Message{msg}, nil
+	}
+
+	event, ok := schemaEvent(msg, tf.conf)
+	if !ok {
+		return []*message.Message{msg}, nil
+	}
+
+	out, ok, err := tf.mapping.Map(event, tf.conf.Unmapped)
+	if err != nil {
+		return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, err)
+	}
+
+	if !ok {
+		return []*message.Message{msg}, nil
+	}
+
+	class, activity := gjson.GetBytes(out, "class_uid"), gjson.GetBytes(out, "activity_id")
+	if class.Exists() && activity.Exists() && !gjson.GetBytes(out, "type_uid").Exists() {
+		if out, err = sjson.SetBytes(out, "type_uid", class.Int()*100+activity.Int()); err != nil {
+			return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, err)
+		}
+	}
+
+	if !tf.isObject {
+		msg.SetData(out)
+		return []*message.Message{msg}, nil
+	}
+
+	if err := msg.SetValue(tf.conf.Object.TargetKey, out); err != nil {
+		return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, err)
+	}
+
+	return []*message.Message{msg}, nil
+}
+
+func (tf *schemaToOCSF) String() string {
+	b, _ := json.Marshal(tf.conf)
+	return string(b)
+}
@@ -0,0 +1,262 @@
+package transform
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+)
+
+var _ Transformer = &schemaToOCSF{}
+
+var (
+	schemaTestCloudTrail = []byte(`{"eventVersion":"1.08","userIdentity":{"type":"IAMUser","principalId":"AIDAEXAMPLE","arn":"arn:aws:iam::123456789012:user/alice","accountId":"123456789012","accessKeyId":"AKIAEXAMPLE","userName":"alice"},"eventTime":"2024-01-02T03:04:05Z","eventSource":"s3.amazonaws.com","eventName":"GetObject","awsRegion":"us-east-1","sourceIPAddress":"192.0.2.1","userAgent":"aws-cli/2.0","errorCode":"AccessDenied","errorMessage":"Access Denied","requestID":"R1","eventID":"E1","eventType":"AwsApiCall","recipientAccountId":"123456789012"}`)
+	schemaTestVPCFlow    = []byte(`2 123456789012 eni-0123456789abcdef0 10.0.0.1 10.0.0.2 443 49152 6 10 840 1620140761 1620140821 REJECT OK`)
+	schemaTestGuardDuty  = []byte(`{"detail":{"schemaVersion":"2.0","accountId":"123456789012","region":"us-east-1","id":"F1","type":"Recon:EC2/PortProbeUnprotectedPort","resource":{"resourceType":"Instance","instanceDetails":{"instanceId":"i-0123456789abcdef0"}},"severity":5.0,"createdAt":"2024-01-02T03:04:05.000Z","updatedAt":"2024-01-02T04:04:05.000Z","title":"Unprotected port is being probed.","description":"Port 22 is being probed.","service":{"count":3,"eventFirstSeen":"2024-01-02T03:00:00.000Z","eventLastSeen":"2024-01-02T04:00:00.000Z"}}}`)
+	schemaTestOkta       = []byte(`{"uuid":"U1","published":"2024-01-02T03:04:05.678Z","eventType":"user.session.start","displayMessage":"User login to Okta","severity":"INFO","actor":{"id":"00u1","type":"User","alternateId":"alice@example.com","displayName":"Alice"},"client":{"ipAddress":"192.0.2.1","userAgent":{"rawUserAgent":"Mozilla/5.0"},"geographicalContext":{"city":"Austin","state":"Texas","country":"United States"}},"outcome":{"result":"FAILURE","reason":"INVALID_CREDENTIALS"},"authenticationContext":{"externalSessionId":"S1"}}`)
+)
+
+var schemaToOCSFTests = []struct {
+	name     string
+	cfg      config.Config
+	test     []byte
+	expected [][]byte
+}{
+	// data tests
+	{
+		"data aws_cloudtrail",
+		config.Config{
+			Settings: map[string]interface{}{
+				"source": "aws_cloudtrail",
+			},
+		},
+		schemaTestCloudTrail,
+		[][]byte{
+			[]byte(`{"metadata":{"version":"1.1.0","product":{"name":"CloudTrail","vendor_name":"AWS","version":"1.08"},"uid":"E1","event_code":"AwsApiCall"},"category_uid":6,"category_name":"Application Activity","class_uid":6003,"class_name":"API Activity","activity_id":2,"time":1704164645000,"severity_id":1,"severity":"Informational","status_id":2,"status":"Failure","status_code":"AccessDenied","status_detail":"Access Denied","api":{"operation":"GetObject","service":{"name":"s3.amazonaws.com"},"request":{"uid":"R1"}},"actor":{"user":{"type":"IAMUser","uid":"AIDAEXAMPLE","uid_alt":"arn:aws:iam::123456789012:user/alice","name":"alice","account":{"uid":"123456789012"},"credential_uid":"AKIAEXAMPLE"}},"src_endpoint":{"ip":"192.0.2.1"},"http_request":{"user_agent":"aws-cli/2.0"},"cloud":{"provider":"AWS","region":"us-east-1","account":{"uid":"123456789012"}},"type_uid":600302}`),
+		},
+	},
+	{
+		"data aws_vpc_flow",
+		config.Config{
+			Settings: map[string]interface{}{
+				"source": "aws_vpc_flow",
+			},
+		},
+		schemaTestVPCFlow,
+		[][]byte{
+			[]byte(`{"metadata":{"version":"1.1.0","product":{"name":"Amazon VPC","vendor_name":"AWS","version":"2"}},"category_uid":4,"category_name":"Network Activity","class_uid":4001,"class_name":"Network Activity","activity_id":6,"activity_name":"Traffic","time":1620140761000,"start_time":1620140761000,"end_time":1620140821000,"severity_id":1,"severity":"Informational","action_id":2,"action":"Denied","disposition_id":2,"disposition":"Blocked","status_code":"OK","src_endpoint":{"ip":"10.0.0.1","port":443,"interface_uid":"eni-0123456789abcdef0"},"dst_endpoint":{"ip":"10.0.0.2","port":49152},"connection_info":{"protocol_num":6,"protocol_name":"tcp"},"traffic":{"packets":10,"bytes":840},"cloud":{"provider":"AWS","account":{"uid":"123456789012"}},"type_uid":400106}`),
+		},
+	},
+	{
+		"data okta",
+		config.Config{
+			Settings: map[string]interface{}{
+				"source": "okta",
+			},
+		},
+		schemaTestOkta,
+		[][]byte{
+			[]byte(`{"metadata":{"version":"1.1.0","product":{"name":"Okta System Log","vendor_name":"Okta"},"uid":"U1","event_code":"user.session.start"},"category_uid":3,"category_name":"Identity & Access Management","class_uid":3002,"class_name":"Authentication","activity_id":1,"time":1704164645678,"message":"User login to Okta","severity_id":1,"severity":"Informational","status_id":2,"status":"Failure","status_detail":"INVALID_CREDENTIALS","user":{"uid":"00u1","name":"alice@example.com","full_name":"Alice","type":"User"},"session":{"uid":"S1"},"src_endpoint":{"ip":"192.0.2.1","location":{"city":"Austin","region":"Texas","country":"United States"}},"http_request":{"user_agent":"Mozilla/5.0"},"type_uid":300201}`),
+		},
+	},
+	{
+		"data unmapped",
+		config.Config{
+			Settings: map[string]interface{}{
+				"source":   "okta",
+				"unmapped": true,
+			},
+		},
+		[]byte(`{"eventType":"user.session.end","outcome":{"result":"SUCCESS"}}`),
+		[][]byte{
+			[]byte(`{"metadata":{"version":"1.1.0","product":{"name":"Okta System Log","vendor_name":"Okta"},"event_code":"user.session.end"},"category_uid":3,"category_name":"Identity & Access Management","class_uid":3002,"class_name":"Authentication","activity_id":2,"severity_id":0,"severity":"Unknown","status_id":1,"status":"Success","unmapped":{"eventType":"user.session.end","outcome":{"result":"SUCCESS"}},"type_uid":300202}`),
+		},
+	},
+	// object tests
+	{
+		"object aws_guardduty",
+		config.Config{
+			Settings: map[string]interface{}{
+				"object": map[string]interface{}{
+					"source_key": "detail",
+					"target_key": "ocsf",
+				},
+				"source": "aws_guardduty",
+			},
+		},
+		schemaTestGuardDuty,
+		[][]byte{
+			[]byte(`{"detail":{"schemaVersion":"2.0","accountId":"123456789012","region":"us-east-1","id":"F1","type":"Recon:EC2/PortProbeUnprotectedPort","resource":{"resourceType":"Instance","instanceDetails":{"instanceId":"i-0123456789abcdef0"}},"severity":5.0,"createdAt":"2024-01-02T03:04:05.000Z","updatedAt":"2024-01-02T04:04:05.000Z","title":"Unprotected port is being probed.","description":"Port 22 is being probed.","service":{"count":3,"eventFirstSeen":"2024-01-02T03:00:00.000Z","eventLastSeen":"2024-01-02T04:00:00.000Z"}},"ocsf":{"metadata":{"version":"1.1.0","product":{"name":"GuardDuty","vendor_name":"AWS","version":"2.0"},"uid":"F1"},"category_uid":2,"category_name":"Findings","class_uid":2004,"class_name":"Detection Finding","activity_id":1,"activity_name":"Create","time":1704168245000,"severity_id":3,"severity":"Medium","finding_info":{"uid":"F1","title":"Unprotected port is being probed.","desc":"Port 22 is being probed.","types":["Recon:EC2/PortProbeUnprotectedPort"],"created_time":1704164645000,"modified_time":1704168245000,"first_seen_time":1704164400000,"last_seen_time":1704168000000},"count":3,"resources":[{"type":"Instance","uid":"i-0123456789abcdef0"}],"cloud":{"provider":"AWS","region":"us-east-1","account":{"uid":"123456789012"}},"type_uid":200401}}`),
+		},
+	},
+	{
+		"object aws_vpc_flow",
+		config.Config{
+			Settings: map[string]interface{}{
+				"object": map[string]interface{}{
+					"source_key": "message",
+					"target_key": "message",
+				},
+				"source": "aws_vpc_flow",
+			},
+		},
+		[]byte(`{"message":"2 123456789012 eni-0123456789abcdef0 - - - - - - - 1620140761 1620140821 - NODATA"}`),
+		[][]byte{
+			[]byte(`{"message":{"metadata":{"version":"1.1.0","product":{"name":"Amazon VPC","vendor_name":"AWS","version":"2"}},"category_uid":4,"category_name":"Network Activity","class_uid":4001,"class_name":"Network Activity","activity_id":6,"activity_name":"Traffic","time":1620140761000,"start_time":1620140761000,"end_time":1620140821000,"severity_id":1,"severity":"Informational","action_id":0,"action":"Unknown","disposition_id":0,"disposition":"Unknown","status_code":"NODATA","src_endpoint":{"interface_uid":"eni-0123456789abcdef0"},"cloud":{"provider":"AWS","account":{"uid":"123456789012"}},"type_uid":400106}}`),
+		},
+	},
+}
+
+func TestSchemaToOCSF(t *testing.T) {
+	ctx := context.TODO()
+	for _, test := range schemaToOCSFTests {
+		t.Run(test.name, func(t *testing.T) {
+			tf, err := newSchemaToOCSF(ctx, test.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			msg := message.New().SetData(test.test)
+			result, err := tf.Transform(ctx, msg)
+			if err != nil {
+				t.Error(err)
+			}
+
+			var data [][]byte
+			for _, c := range result {
+				data = append(data, c.Data())
+			}
+
+			if !reflect.DeepEqual(data, test.expected) {
+				t.Errorf("expected %s, got %s", test.expected, data)
+			}
+		})
+	}
+}
+
+func TestSchemaToOCSFMapping(t *testing.T) {
+	ctx := context.TODO()
+
+	path := filepath.Join(t.TempDir(), "mapping.yaml")
+	mapping := `fields:
+  - target: metadata.product.name
+    value: Example
+  - target: user.name
+    source: actor.alternateId
+  - target: severity_id
+    source: severity
+    cases:
+      - match: WARN
+        value: 3
+    default: 1
+`
+	if err := os.WriteFile(path, []byte(mapping), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		settings map[string]interface{}
+		expected []byte
+	}{
+		{
+			"mapping",
+			map[string]interface{}{
+				"mapping": path,
+			},
+			[]byte(`{"metadata":{"product":{"name":"Example"}},"user":{"name":"alice@example.com"},"severity_id":3}`),
+		},
+		{
+			"source and mapping",
+			map[string]interface{}{
+				"source":  "okta",
+				"mapping": path,
+			},
+			[]byte(`{"metadata":{"version":"1.1.0","product":{"name":"Example","vendor_name":"Okta"},"event_code":"user.session.start"},"category_uid":3,"category_name":"Identity & Access Management","class_uid":3002,"class_name":"Authentication","activity_id":1,"time":1704164645678,"severity_id":3,"severity":"Medium","status_id":99,"status":"Other","user":{"name":"alice@example.com"},"type_uid":300201}`),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tf, err := newSchemaToOCSF(ctx, config.Config{Settings: test.settings})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			msg := message.New().SetData([]byte(`{"published":"2024-01-02T03:04:05.678Z","eventType":"user.session.start","severity":"WARN","actor":{"alternateId":"alice@example.com"}}`))
+			result, err := tf.Transform(ctx, msg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(result[0].Data(), test.expected) {
+				t.Errorf("expected %s, got %s", test.expected, result[0].Data())
+			}
+		})
+	}
+
+	if _, err := newSchemaToOCSF(ctx, config.Config{Settings: map[string]interface{}{"source": "example"}}); err == nil {
+		t.Error("expected error for unknown source")
+	}
+}
+
+func benchmarkSchemaToOCSF(b *testing.B, tf *schemaToOCSF, data []byte) {
+	ctx := context.TODO()
+	for i := 0; i < b.N; i++ {
+		msg := message.New().SetData(data)
+		_, _ = tf.Transform(ctx, msg)
+	}
+}
+
+func BenchmarkSchemaToOCSF(b *testing.B) {
+	for _, test := range schemaToOCSFTests {
+		tf, err := newSchemaToOCSF(context.TODO(), test.cfg)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(test.name,
+			func(b *testing.B) {
+				benchmarkSchemaToOCSF(b, tf, test.test)
+			},
+		)
+	}
+}
+
+func FuzzTestSchemaToOCSF(f *testing.F) {
+	testcases := [][]byte{
+		schemaTestCloudTrail,
+		schemaTestVPCFlow,
+		[]byte(`{"eventName":"GetObject"}`),
+		[]byte(`a`),
+		[]byte(``),
+	}
+
+	for _, tc := range testcases {
+		f.Add(tc)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ctx := context.TODO()
+		msg := message.New().SetData(data)
+
+		tf, err := newSchemaToOCSF(ctx, config.Config{
+			Settings: map[string]interface{}{
+				"source":   "aws_vpc_flow",
+				"unmapped": true,
+			},
+		})
+		if err != nil {
+			return
+		}
+
+		_, err = tf.Transform(ctx, msg)
+		if err != nil {
+			return
+		}
+	})
+}
@@ -0,0 +1,211 @@
+package transform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+
+	"github.com/brexhq/substation/v2/internal/aggregate"
+	iconfig "github.com/brexhq/substation/v2/internal/config"
+)
+
+// Records greater than 1 MB in size cannot be put into a Kafka topic
+// that uses the default broker configuration (message.max.bytes).
+const sendKafkaMessageSizeLimit = 1024 * 1024
+
+// errSendKafkaMessageSizeLimit is returned when data exceeds the Kafka
+// record size limit. If this error occurs, then conditions or transforms
+// should be applied to either drop or reduce the size of the data.
+var errSendKafkaMessageSizeLimit = fmt.Errorf("data exceeded size limit")
+
+type sendKafkaConfig struct {
+	// Brokers are the addresses of the Kafka brokers that are used to
+	// discover the cluster (e.g., "localhost:9092").
+	Brokers []string `json:"brokers"`
+	// Topic is the Kafka topic that data is sent to.
+	Topic string `json:"topic"`
+	// UseBatchKeyAsPartitionKey determines if the batch key should be used as
+	// the record key. Records with the same key are sent to the same partition.
+	//
+	// This is optional and defaults to false (records are evenly distributed
+	// across partitions).
+	UseBatchKeyAsPartitionKey bool `json:"use_batch_key_as_partition_key"`
+	// AuxTransforms are applied to batched data before it is sent.
+	AuxTransforms []config.Config `json:"auxiliary_transforms"`
+
+	ID     string         `json:"id"`
+	Object iconfig.Object `json:"object"`
+	Batch  iconfig.Batch  `json:"batch"`
+}
+
+func (c *sendKafkaConfig) Decode(in interface{}) error {
+	return iconfig.Decode(in, c)
+}
+
+func (c *sendKafkaConfig) Validate() error {
+	if len(c.Brokers) == 0 {
+		return fmt.Errorf("brokers: %v", iconfig.ErrMissingRequiredOption)
+	}
+
+	if c.Topic == "" {
+		return fmt.Errorf("topic: %v", iconfig.ErrMissingRequiredOption)
+	}
+
+	return nil
+}
+
+func newSendKafka(_ context.Context, cfg config.Config) (*sendKafka, error) {
+	conf := sendKafkaConfig{}
+	if err := conf.Decode(cfg.Settings); err != nil {
+		return nil, fmt.Errorf("transform send_kafka: %v", err)
+	}
+
+	if conf.ID == "" {
+		conf.ID = "send_kafka"
+	}
+
+	if err := conf.Validate(); err != nil {
+		return nil, fmt.Errorf("transform %s: %v", conf.ID, err)
+	}
+
+	tf := sendKafka{
+		conf: conf,
+	}
+
+	// Kafka limits produce requests to 1MB by default.
+	size := sendKafkaMessageSizeLimit
+	if conf.Batch.Size > 0 && conf.Batch.Size <= size {
+		size = conf.Batch.Size
+	}
+
+	agg, err := aggregate.New(aggregate.Config{
+		Count:    conf.Batch.Count,
+		Size:     size,
+		Duration: conf.Batch.Duration,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("transform %s: %v", conf.ID, err)
+	}
+	tf.agg = agg
+
+	if len(conf.AuxTransforms) > 0 {
+		tf.tforms = make([]Transformer, len(conf.AuxTransforms))
+		for i, c := range conf.AuxTransforms {
+			t, err := New(context.Background(), c)
+			if err != nil {
+				return nil, fmt.Errorf("transform %s: %v", conf.ID, err)
+			}
+
+			tf.tforms[i] = t
+		}
+	}
+
+	var balancer kafka.Balancer = &kafka.RoundRobin{}
+	if conf.UseBatchKeyAsPartitionKey {
+		balancer = &kafka.Hash{}
+	}
+
+	// Data is batched by the transform, so the writer is configured to
+	// send each batch in as few requests as possible.
+	tf.writer = &kafka.Writer{
+		Addr:         kafka.TCP(conf.Brokers...),
+		Topic:        conf.Topic,
+		Balancer:     balancer,
+		RequiredAcks: kafka.RequireAll,
+		BatchSize:    max(conf.Batch.Count, 1),
+		BatchBytes:   int64(sendKafkaMessageSizeLimit),
+		BatchTimeout: 10 * time.Millisecond,
+	}
+
+	return &tf, nil
+}
+
+type sendKafka struct {
+	conf   sendKafkaConfig
+	writer *kafka.Writer
+
+	mu     sync.Mutex
+	agg    *aggregate.Aggregate
+	tforms []Transformer
+}
+
+func (tf *sendKafka) Transform(ctx context.Context, msg *message.Message) ([]*message.Message, error) {
+	tf.mu.Lock()
+	defer tf.mu.Unlock()
+
+	if msg.IsControl() {
+		for key := range tf.agg.GetAll() {
+			if tf.agg.Count(key) == 0 {
+				continue
+			}
+
+			if err := tf.send(ctx, key); err != nil {
+				return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, err)
+			}
+		}
+
+		tf.agg.ResetAll()
+		return []*message.Message{msg}, nil
+	}
+
+	if len(msg.Data()) > sendKafkaMessageSizeLimit {
+		return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, errSendKafkaMessageSizeLimit)
+	}
+
+	// If this value does not exist, then all data is batched together.
+	key := msg.GetValue(tf.conf.Object.BatchKey).String()
+	if ok := tf.agg.Add(key, msg.Data()); ok {
+		return []*message.Message{msg}, nil
+	}
+
+	if err := tf.send(ctx, key); err != nil {
+		return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, err)
+	}
+
+	// If data cannot be added after reset, then the batch is misconfgured.
+	tf.agg.Reset(key)
+	if ok := tf.agg.Add(key, msg.Data()); !ok {
+		return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, errBatchNoMoreData)
+	}
+
+	return []*message.Message{msg}, nil
+}
+
+func (tf *sendKafka) String() string {
+	b, _ := json.Marshal(tf.conf)
+	return string(b)
+}
+
+func (tf *sendKafka) send(ctx context.Context, key string) error {
+	data, err := withTransforms(ctx, tf.tforms, tf.agg.Get(key))
+	if err != nil {
+		return err
+	}
+
+	if len(data) == 0 {
+		return nil
+	}
+
+	var partitionKey []byte
+	if tf.conf.UseBatchKeyAsPartitionKey {
+		partitionKey = []byte(key)
+	}
+
+	records := make([]kafka.Message, len(data))
+	for i, d := range data {
+		records[i] = kafka.Message{
+			Key:   partitionKey,
+			Value: d,
+		}
+	}
+
+	ctx = context.WithoutCancel(ctx)
+	return tf.writer.WriteMessages(ctx, records...)
+}
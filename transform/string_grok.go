@@ -0,0 +1,181 @@
+package transform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+
+	iconfig "github.com/brexhq/substation/v2/internal/config"
+	"github.com/brexhq/substation/v2/internal/file"
+	"github.com/brexhq/substation/v2/internal/grok"
+)
+
+type stringGrokConfig struct {
+	// Patterns are the Grok expressions used to capture values (e.g.,
+	// "%{IP:client} %{WORD:method} %{NUMBER:bytes:int}"). Expressions are tried
+	// in order and the first expression that matches is used.
+	//
+	// Values are captured into the named fields of the expression. Fields can
+	// have a type of int, float, or string (the default).
+	Patterns []string `json:"patterns"`
+	// PatternDefinitions are named patterns that are added to the built-in
+	// pattern library (e.g., {"PORT": "%{POSINT}"}).
+	//
+	// This is optional and defaults to an empty map.
+	PatternDefinitions map[string]string `json:"pattern_definitions"`
+	// PatternFiles are the locations of files that contain named patterns. Each
+	// line of a file contains a name and a pattern separated by whitespace. The
+	// files can be stored locally, in an HTTP(S) URL, or in AWS S3.
+	//
+	// This is optional and defaults to an empty list.
+	PatternFiles []string `json:"pattern_files"`
+
+	ID     string         `json:"id"`
+	Object iconfig.Object `json:"object"`
+}
+
+func (c *stringGrokConfig) Decode(in interface{}) error {
+	return iconfig.Decode(in, c)
+}
+
+func (c *stringGrokConfig) Validate() error {
+	if c.Object.SourceKey == "" && c.Object.TargetKey != "" {
+		return fmt.Errorf("object_source_key: %v", iconfig.ErrMissingRequiredOption)
+	}
+
+	if c.Object.SourceKey != "" && c.Object.TargetKey == "" {
+		return fmt.Errorf("object_target_key: %v", iconfig.ErrMissingRequiredOption)
+	}
+
+	if len(c.Patterns) == 0 {
+		return fmt.Errorf("patterns: %v", iconfig.ErrMissingRequiredOption)
+	}
+
+	return nil
+}
+
+func newStringGrok(ctx context.Context, cfg config.Config) (*stringGrok, error) {
+	conf := stringGrokConfig{}
+	if err := conf.Decode(cfg.Settings); err != nil {
+		return nil, fmt.Errorf("transform string_grok: %v", err)
+	}
+
+	if conf.ID == "" {
+		conf.ID = "string_grok"
+	}
+
+	if err := conf.Validate(); err != nil {
+		return nil, fmt.Errorf("transform %s: %v", conf.ID, err)
+	}
+
+	g := grok.New()
+	for _, location := range conf.PatternFiles {
+		if err := strGrokAddPatterns(ctx, g, location); err != nil {
+			return nil, fmt.Errorf("transform %s: pattern_files: %v", conf.ID, err)
+		}
+	}
+
+	// Definitions in the configuration replace definitions from files.
+	for name, pattern := range conf.PatternDefinitions {
+		g.AddPattern(name, pattern)
+	}
+
+	tf := stringGrok{
+		conf:     conf,
+		isObject: conf.Object.SourceKey != "" && conf.Object.TargetKey != "",
+	}
+
+	for _, expr := range conf.Patterns {
+		p, err := g.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("transform %s: patterns: %v", conf.ID, err)
+		}
+
+		tf.patterns = append(tf.patterns, p)
+	}
+
+	return &tf, nil
+}
+
+type stringGrok struct {
+	conf     stringGrokConfig
+	isObject bool
+
+	patterns []*grok.Pattern
+}
+
+func (tf *stringGrok) Transform(_ context.Context, msg *message.Message) ([]*message.Message, error) {
+	if msg.IsControl() {
+		return []*message.Message{msg}, nil
+	}
+
+	if !tf.isObject {
+		captures, ok := tf.match(string(msg.Data()))
+		if !ok {
+			return []*message.Message{msg}, nil
+		}
+
+		outMsg := message.New().SetMetadata(msg.Metadata())
+		for _, c := range captures {
+			if err := outMsg.SetValue(c.Name, c.Value); err != nil {
+				return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, err)
+			}
+		}
+
+		return []*message.Message{outMsg}, nil
+	}
+
+	value := msg.GetValue(tf.conf.Object.SourceKey)
+	if !value.Exists() {
+		return []*message.Message{msg}, nil
+	}
+
+	captures, ok := tf.match(value.String())
+	if !ok {
+		return []*message.Message{msg}, nil
+	}
+
+	for _, c := range captures {
+		if err := msg.SetValue(tf.conf.Object.TargetKey+"."+c.Name, c.Value); err != nil {
+			return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, err)
+		}
+	}
+
+	return []*message.Message{msg}, nil
+}
+
+// match returns the captures from the first pattern that matches the string.
+func (tf *stringGrok) match(s string) ([]grok.Capture, bool) {
+	for _, p := range tf.patterns {
+		if captures, ok := p.Match(s); ok {
+			return captures, true
+		}
+	}
+
+	return nil, false
+}
+
+func (tf *stringGrok) String() string {
+	b, _ := json.Marshal(tf.conf)
+	return string(b)
+}
+
+func strGrokAddPatterns(ctx context.Context, g *grok.Grok, location string) error {
+	path, err := file.Get(ctx, location)
+	defer os.Remove(path)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return g.AddPatterns(f)
+}
@@ -0,0 +1,228 @@
+package transform
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+)
+
+var _ Transformer = &stringGrok{}
+
+var stringGrokTests = []struct {
+	name     string
+	cfg      config.Config
+	test     []byte
+	expected [][]byte
+}{
+	// data tests
+	{
+		"data",
+		config.Config{
+			Settings: map[string]interface{}{
+				"patterns": []string{"%{IP:client} %{WORD:method} %{INT:bytes:int} %{NUMBER:duration:float}"},
+			},
+		},
+		[]byte(`10.0.0.1 GET 1024 0.043`),
+		[][]byte{
+			[]byte(`{"client":"10.0.0.1","method":"GET","bytes":1024,"duration":0.043}`),
+		},
+	},
+	{
+		"data multiple patterns",
+		config.Config{
+			Settings: map[string]interface{}{
+				"patterns": []string{
+					"^%{INT:code:int}$",
+					"^%{WORD:word}$",
+				},
+			},
+		},
+		[]byte(`abc`),
+		[][]byte{
+			[]byte(`{"word":"abc"}`),
+		},
+	},
+	{
+		"data no match",
+		config.Config{
+			Settings: map[string]interface{}{
+				"patterns": []string{"^%{INT:code:int}$"},
+			},
+		},
+		[]byte(`abc`),
+		[][]byte{
+			[]byte(`abc`),
+		},
+	},
+	// object tests
+	{
+		"object",
+		config.Config{
+			Settings: map[string]interface{}{
+				"object": map[string]interface{}{
+					"source_key": "a",
+					"target_key": "b",
+				},
+				"patterns": []string{"%{COMMONAPACHELOG}"},
+			},
+		},
+		[]byte(`{"a":"127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] \"GET /apache_pb.gif HTTP/1.0\" 200 2326"}`),
+		[][]byte{
+			[]byte(`{"a":"127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] \"GET /apache_pb.gif HTTP/1.0\" 200 2326","b":{"clientip":"127.0.0.1","ident":"-","auth":"frank","timestamp":"10/Oct/2000:13:55:36 -0700","verb":"GET","request":"/apache_pb.gif","httpversion":"1.0","response":200,"bytes":2326}}`),
+		},
+	},
+	{
+		"object pattern_definitions",
+		config.Config{
+			Settings: map[string]interface{}{
+				"object": map[string]interface{}{
+					"source_key": "a",
+					"target_key": "b",
+				},
+				"pattern_definitions": map[string]interface{}{
+					"ENDPOINT": "%{IPORHOST:host}:%{POSINT:port:int}",
+				},
+				"patterns": []string{"%{ENDPOINT:endpoint}"},
+			},
+		},
+		[]byte(`{"a":"example.com:443"}`),
+		[][]byte{
+			[]byte(`{"a":"example.com:443","b":{"endpoint":"example.com:443","host":"example.com","port":443}}`),
+		},
+	},
+	{
+		"object no match",
+		config.Config{
+			Settings: map[string]interface{}{
+				"object": map[string]interface{}{
+					"source_key": "a",
+					"target_key": "b",
+				},
+				"patterns": []string{"^%{INT:code:int}$"},
+			},
+		},
+		[]byte(`{"a":"abc"}`),
+		[][]byte{
+			[]byte(`{"a":"abc"}`),
+		},
+	},
+}
+
+func TestStringGrok(t *testing.T) {
+	ctx := context.TODO()
+	for _, test := range stringGrokTests {
+		t.Run(test.name, func(t *testing.T) {
+			tf, err := newStringGrok(ctx, test.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			msg := message.New().SetData(test.test)
+			result, err := tf.Transform(ctx, msg)
+			if err != nil {
+				t.Error(err)
+			}
+
+			var data [][]byte
+			for _, c := range result {
+				data = append(data, c.Data())
+			}
+
+			if !reflect.DeepEqual(data, test.expected) {
+				t.Errorf("expected %s, got %s", test.expected, data)
+			}
+		})
+	}
+}
+
+func TestStringGrokPatternFiles(t *testing.T) {
+	ctx := context.TODO()
+
+	path := filepath.Join(t.TempDir(), "patterns")
+	if err := os.WriteFile(path, []byte("# Custom patterns.\nSTATUS (?:ok|fail)\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tf, err := newStringGrok(ctx, config.Config{
+		Settings: map[string]interface{}{
+			"pattern_files": []string{path},
+			"patterns":      []string{"%{WORD:name}: %{STATUS:status}"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := tf.Transform(ctx, message.New().SetData([]byte(`job: ok`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"name":"job","status":"ok"}`
+	if string(result[0].Data()) != expected {
+		t.Errorf("expected %s, got %s", expected, result[0].Data())
+	}
+}
+
+func benchmarkStringGrok(b *testing.B, tf *stringGrok, data []byte) {
+	ctx := context.TODO()
+	for i := 0; i < b.N; i++ {
+		msg := message.New().SetData(data)
+		_, _ = tf.Transform(ctx, msg)
+	}
+}
+
+func BenchmarkStringGrok(b *testing.B) {
+	for _, test := range stringGrokTests {
+		tf, err := newStringGrok(context.TODO(), test.cfg)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(test.name,
+			func(b *testing.B) {
+				benchmarkStringGrok(b, tf, test.test)
+			},
+		)
+	}
+}
+
+func FuzzTestStringGrok(f *testing.F) {
+	testcases := [][]byte{
+		[]byte(`10.0.0.1 GET 1024 0.043`),
+		[]byte(`Oct 11 22:14:15 mymachine su[123]: 'su root' failed`),
+		[]byte(`abc`),
+		[]byte(``),
+	}
+
+	for _, tc := range testcases {
+		f.Add(tc)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ctx := context.TODO()
+		msg := message.New().SetData(data)
+
+		tf, err := newStringGrok(ctx, config.Config{
+			Settings: map[string]interface{}{
+				"patterns": []string{
+					"%{SYSLOGLINE}",
+					"%{IP:client} %{WORD:method} %{INT:bytes:int} %{NUMBER:duration:float}",
+				},
+			},
+		})
+		if err != nil {
+			return
+		}
+
+		_, err = tf.Transform(ctx, msg)
+		if err != nil {
+			return
+		}
+	})
+}
@@ -0,0 +1,368 @@
+package transform
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/tidwall/gjson"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+
+	iconfig "github.com/brexhq/substation/v2/internal/config"
+	"github.com/brexhq/substation/v2/internal/secrets"
+)
+
+type stringRedactDetector struct {
+	re *regexp.Regexp
+	// valid removes false positives from matches. If this is nil, then all
+	// matches are redacted.
+	valid func(string) bool
+}
+
+// stringRedactDetectors are applied in the order of stringRedactDefaults.
+var stringRedactDetectors = map[string]stringRedactDetector{
+	"email": {
+		re: regexp.MustCompile(`[a-zA-Z0-9._%+\-]+@[a-zA-Z0-9.\-]+\.[a-zA-Z]{2,}`),
+	},
+	"jwt": {
+		re: regexp.MustCompile(`\beyJ[a-zA-Z0-9_\-]+\.eyJ[a-zA-Z0-9_\-]+\.[a-zA-Z0-9_\-]*`),
+	},
+	"aws_access_key": {
+		re: regexp.MustCompile(`\b(?:AKIA|ASIA|ABIA|ACCA)[A-Z0-9]{16}\b`),
+	},
+	"credit_card": {
+		re:    regexp.MustCompile(`\b(?:\d[ \-]?){12,18}\d\b`),
+		valid: stringRedactLuhn,
+	},
+	"ssn": {
+		re:    regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`),
+		valid: stringRedactSSN,
+	},
+	"ipv4": {
+		re: regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b`),
+		valid: func(s string) bool {
+			_, err := netip.ParseAddr(s)
+			return err == nil
+		},
+	},
+	"ipv6": {
+		re: regexp.MustCompile(`[0-9a-fA-F]*:[0-9a-fA-F:]*:[0-9a-fA-F:.]*`),
+		valid: func(s string) bool {
+			addr, err := netip.ParseAddr(s)
+			return err == nil && addr.Is6()
+		},
+	},
+}
+
+// stringRedactDefaults is the order that detectors are applied. Detectors that
+// match more specific values are applied first.
+var stringRedactDefaults = []string{"email", "jwt", "aws_access_key", "credit_card", "ssn", "ipv4", "ipv6"}
+
+type stringRedactConfig struct {
+	// Detectors are the types of values that are redacted. Must be one or
+	// more of: email, jwt, aws_access_key, credit_card, ssn, ipv4, ipv6.
+	//
+	// This is optional and defaults to all detectors.
+	Detectors []string `json:"detectors"`
+	// Action determines how matches are redacted. Must be one of:
+	//
+	// - mask: letters and numbers are replaced with Mask, which preserves
+	// the format of the value.
+	//
+	// - hash: the value is replaced with its SHA-256 hash, or its HMAC-SHA256
+	// hash if Key is set.
+	//
+	// - remove: the value is removed.
+	//
+	// This is optional and defaults to mask.
+	Action string `json:"action"`
+	// Mask is the character that replaces letters and numbers.
+	//
+	// This is optional and defaults to "*".
+	Mask string `json:"mask"`
+	// Key is the secret key used by the hash action. Secrets can be used with
+	// the utility_secret transform (e.g., "${SECRET:KEY}").
+	//
+	// This is optional and defaults to an unkeyed hash.
+	Key string `json:"key"`
+
+	ID string `json:"id"`
+	// Object.SourceKey and Object.TargetKey are the value that is redacted and
+	// where the result is stored. If the value is an object or array, then
+	// all strings in it are redacted. If these are not set, then the message
+	// data is redacted.
+	Object iconfig.Object `json:"object"`
+}
+
+func (c *stringRedactConfig) Decode(in interface{}) error {
+	return iconfig.Decode(in, c)
+}
+
+func (c *stringRedactConfig) Validate() error {
+	if c.Object.SourceKey == "" && c.Object.TargetKey != "" {
+		return fmt.Errorf("object_source_key: %v", iconfig.ErrMissingRequiredOption)
+	}
+
+	if c.Object.SourceKey != "" && c.Object.TargetKey == "" {
+		return fmt.Errorf("object_target_key: %v", iconfig.ErrMissingRequiredOption)
+	}
+
+	for _, d := range c.Detectors {
+		if _, ok := stringRedactDetectors[d]; !ok {
+			return fmt.Errorf("detectors %s: %v", d, iconfig.ErrInvalidOption)
+		}
+	}
+
+	switch c.Action {
+	case "mask", "hash", "remove":
+	default:
+		return fmt.Errorf("action %s: %v", c.Action, iconfig.ErrInvalidOption)
+	}
+
+	if len([]rune(c.Mask)) != 1 {
+		return fmt.Errorf("mask %s: %v", c.Mask, iconfig.ErrInvalidOption)
+	}
+
+	return nil
+}
+
+func newStringRedact(ctx context.Context, cfg config.Config) (*stringRedact, error) {
+	conf := stringRedactConfig{}
+	if err := conf.Decode(cfg.Settings); err != nil {
+		return nil, fmt.Errorf("transform string_redact: %v", err)
+	}
+
+	if conf.ID == "" {
+		conf.ID = "string_redact"
+	}
+
+	if conf.Action == "" {
+		conf.Action = "mask"
+	}
+
+	if conf.Mask == "" {
+		conf.Mask = "*"
+	}
+
+	if err := conf.Validate(); err != nil {
+		return nil, fmt.Errorf("transform %s: %v", conf.ID, err)
+	}
+
+	tf := stringRedact{
+		conf:     conf,
+		isObject: conf.Object.SourceKey != "" && conf.Object.TargetKey != "",
+		mask:     []rune(conf.Mask)[0],
+	}
+
+	// Detectors are always applied in the default order, so the result does
+	// not depend on the order of the configuration.
+	for _, d := range stringRedactDefaults {
+		if len(conf.Detectors) == 0 || slices.Contains(conf.Detectors, d) {
+			tf.detectors = append(tf.detectors, stringRedactDetectors[d])
+		}
+	}
+
+	if conf.Key != "" {
+		// Retrieve secret and interpolate with the key.
+		key, err := secrets.Interpolate(ctx, conf.Key)
+		if err != nil {
+			return nil, fmt.Errorf("transform %s: %v", conf.ID, err)
+		}
+
+		tf.key = []byte(key)
+	}
+
+	return &tf, nil
+}
+
+// stringRedact redacts sensitive values (e.g., email addresses, credit card
+// numbers) that are found anywhere in strings.
+type stringRedact struct {
+	conf     stringRedactConfig
+	isObject bool
+
+	detectors []stringRedactDetector
+	mask      rune
+	key       []byte
+}
+
+func (tf *stringRedact) Transform(ctx context.Context, msg *message.Message) ([]*message.Message, error) {
+	if msg.IsControl() {
+		return []*message.Message{msg}, nil
+	}
+
+	if !tf.isObject {
+		if json.Valid(msg.Data()) {
+			msg.SetData(tf.redactJSON(gjson.ParseBytes(msg.Data())))
+		} else {
+			msg.SetData([]byte(tf.redact(string(msg.Data()))))
+		}
+
+		return []*message.Message{msg}, nil
+	}
+
+	value := msg.GetValue(tf.conf.Object.SourceKey)
+	if !value.Exists() {
+		return []*message.Message{msg}, nil
+	}
+
+	var v interface{}
+	switch value.Value().(type) {
+	case map[string]interface{}, []interface{}:
+		v = tf.redactJSON(gjson.ParseBytes(value.Bytes()))
+	default:
+		v = tf.redact(value.String())
+	}
+
+	if err := msg.SetValue(tf.conf.Object.TargetKey, v); err != nil {
+		return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, err)
+	}
+
+	return []*message.Message{msg}, nil
+}
+
+func (tf *stringRedact) String() string {
+	b, _ := json.Marshal(tf.conf)
+	return string(b)
+}
+
+// redact replaces all matches from the detectors in the string.
+func (tf *stringRedact) redact(s string) string {
+	for _, d := range tf.detectors {
+		s = d.re.ReplaceAllStringFunc(s, func(m string) string {
+			if d.valid != nil && !d.valid(m) {
+				return m
+			}
+
+			return tf.replace(m)
+		})
+	}
+
+	return s
+}
+
+func (tf *stringRedact) replace(s string) string {
+	switch tf.conf.Action {
+	case "hash":
+		if tf.key != nil {
+			return hashHMACSum(sha256.New, tf.key, []byte(s))
+		}
+
+		return fmt.Sprintf("%x", sha256.Sum256([]byte(s)))
+	case "remove":
+		return ""
+	default:
+		return strings.Map(func(r rune) rune {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				return tf.mask
+			}
+
+			return r
+		}, s)
+	}
+}
+
+// redactJSON redacts all strings and numbers in a JSON value. The order of
+// keys and the values that are not redacted are not changed.
+func (tf *stringRedact) redactJSON(res gjson.Result) []byte {
+	switch {
+	case res.IsObject():
+		b := []byte{'{'}
+		res.ForEach(func(k, v gjson.Result) bool {
+			if len(b) > 1 {
+				b = append(b, ',')
+			}
+
+			b = append(b, k.Raw...)
+			b = append(b, ':')
+			b = append(b, tf.redactJSON(v)...)
+
+			return true
+		})
+
+		return append(b, '}')
+	case res.IsArray():
+		b := []byte{'['}
+		res.ForEach(func(_, v gjson.Result) bool {
+			if len(b) > 1 {
+				b = append(b, ',')
+			}
+
+			b = append(b, tf.redactJSON(v)...)
+
+			return true
+		})
+
+		return append(b, ']')
+	case res.Type == gjson.String:
+		s := tf.redact(res.String())
+		if s == res.String() {
+			return []byte(res.Raw)
+		}
+
+		b, _ := json.Marshal(s)
+		return b
+	case res.Type == gjson.Number:
+		// Numbers that are redacted are converted to strings (e.g., credit
+		// card numbers).
+		s := tf.redact(res.Raw)
+		if s == res.Raw {
+			return []byte(res.Raw)
+		}
+
+		b, _ := json.Marshal(s)
+		return b
+	default:
+		return []byte(res.Raw)
+	}
+}
+
+// stringRedactLuhn returns true if the digits in the string pass the Luhn
+// checksum, which is used by credit card numbers. Card numbers start with 2
+// through 6, which also prevents most timestamps from matching.
+func stringRedactLuhn(s string) bool {
+	if s[0] < '2' || s[0] > '6' {
+		return false
+	}
+
+	var sum, n int
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+
+		d := int(c - '0')
+		if n%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+
+		sum += d
+		n++
+	}
+
+	return n >= 13 && sum%10 == 0
+}
+
+// stringRedactSSN returns true if the string is a valid US Social Security
+// number. Area numbers cannot be 000, 666, or 900-999, group numbers cannot
+// be 00, and serial numbers cannot be 0000.
+func stringRedactSSN(s string) bool {
+	area, group, serial := s[0:3], s[4:6], s[7:11]
+	if area == "000" || area == "666" || area[0] == '9' {
+		return false
+	}
+
+	return group != "00" && serial != "0000"
+}
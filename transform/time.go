@@ -2,6 +2,8 @@ package transform
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	iconfig "github.com/brexhq/substation/v2/internal/config"
@@ -80,28 +82,213 @@ func timeUnixToStr(ts int64, timeFmt string, loc string) (string, error) {
 	return timeDate.Format(timeFmt), nil
 }
 
-func timeStrToUnix(timeStr, timeFmt string, loc string) (time.Time, error) {
-	var timeDate time.Time
-	if loc != "" {
-		ll, err := time.LoadLocation(loc)
-		if err != nil {
-			return timeDate, fmt.Errorf("location %s: %v", loc, err)
+// timeAutoLayouts are the layouts that are tried, in order, when the format
+// is "auto". Fractional seconds are parsed even if the layout does not
+// include them.
+var timeAutoLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05 -0700 MST",
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC850,
+	time.RFC822Z,
+	time.RFC822,
+	time.RubyDate,
+	time.UnixDate,
+	time.ANSIC,
+	// Common Log Format (e.g., Apache).
+	"02/Jan/2006:15:04:05 -0700",
+	// RFC 3164 (BSD syslog).
+	time.Stamp,
+}
+
+// timeStrftimeLayouts maps strftime directives to Go layouts.
+var timeStrftimeLayouts = map[string]string{
+	"a":  "Mon",
+	"A":  "Monday",
+	"b":  "Jan",
+	"B":  "January",
+	"c":  time.ANSIC,
+	"d":  "02",
+	"-d": "2",
+	"D":  "01/02/06",
+	"e":  "_2",
+	"f":  "000000",
+	"F":  "2006-01-02",
+	"h":  "Jan",
+	"H":  "15",
+	"I":  "03",
+	"-I": "3",
+	"j":  "002",
+	"m":  "01",
+	"-m": "1",
+	"M":  "04",
+	"-M": "4",
+	"p":  "PM",
+	"R":  "15:04",
+	"S":  "05",
+	"-S": "5",
+	"T":  "15:04:05",
+	"y":  "06",
+	"Y":  "2006",
+	"z":  "Z0700",
+	"Z":  "MST",
+	"%":  "%",
+}
+
+// timeLayout converts a format to a layout that is supported by timeParse.
+// Formats that contain "%" are strftime patterns, all other formats are
+// returned unchanged.
+func timeLayout(format string) (string, error) {
+	if !strings.Contains(format, "%") {
+		return format, nil
+	}
+
+	// Epoch seconds are only supported as the entire pattern.
+	if format == "%s" {
+		return "unix", nil
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			b.WriteByte(format[i])
+			continue
 		}
 
-		pil, err := time.ParseInLocation(timeFmt, timeStr, ll)
-		if err != nil {
-			return timeDate, fmt.Errorf("format %s location %s: %v", timeFmt, loc, err)
+		// Directives are one character, or two if padding is removed (e.g., "%-d").
+		n := 1
+		if i+1 < len(format) && format[i+1] == '-' {
+			n = 2
 		}
 
-		timeDate = pil
-	} else {
-		p, err := time.Parse(timeFmt, timeStr)
+		if i+n >= len(format) {
+			return "", fmt.Errorf("format %s: %v", format, iconfig.ErrInvalidOption)
+		}
+
+		d := format[i+1 : i+1+n]
+		l, ok := timeStrftimeLayouts[d]
+		if !ok {
+			return "", fmt.Errorf("format %s: directive %%%s: %v", format, d, iconfig.ErrInvalidOption)
+		}
+
+		b.WriteString(l)
+		i += n
+	}
+
+	return b.String(), nil
+}
+
+// timeParse parses a string with a layout from timeLayout. In addition to Go
+// layouts, these values are supported:
+//
+// - auto: the string is parsed as an epoch if it is numeric, otherwise
+// timeAutoLayouts are tried in order.
+//
+// - unix, unix_milli, unix_micro, unix_nano: the string is parsed as an
+// epoch. Seconds can include a fraction (e.g., "1639877490.123").
+//
+// If the layout does not include a year (e.g., syslog timestamps), then the
+// year is inferred relative to now.
+func timeParse(s, layout string, loc *time.Location, now time.Time) (time.Time, error) {
+	switch layout {
+	case "auto":
+		return timeParseAuto(s, loc, now)
+	case "unix":
+		return timeParseEpoch(s, time.Second)
+	case "unix_milli":
+		return timeParseEpoch(s, time.Millisecond)
+	case "unix_micro":
+		return timeParseEpoch(s, time.Microsecond)
+	case "unix_nano":
+		return timeParseEpoch(s, time.Nanosecond)
+	}
+
+	t, err := time.ParseInLocation(layout, s, loc)
+	if err != nil {
+		return t, err
+	}
+
+	if t.Year() == 0 {
+		t = fmtSyslogYear(t, now.In(t.Location()))
+	}
+
+	return t, nil
+}
+
+func timeParseAuto(s string, loc *time.Location, now time.Time) (time.Time, error) {
+	if unit, ok := timeEpochUnit(s); ok {
+		return timeParseEpoch(s, unit)
+	}
+
+	for _, l := range timeAutoLayouts {
+		if t, err := timeParse(s, l, loc, now); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("parsing time %q: unknown format", s)
+}
+
+// timeEpochUnit returns the unit of a numeric string based on the number of
+// integer digits (e.g., 13 digits are milliseconds).
+func timeEpochUnit(s string) (time.Duration, bool) {
+	i, f, _ := strings.Cut(s, ".")
+	if i == "" || strings.Trim(i, "0123456789") != "" || strings.Trim(f, "0123456789") != "" {
+		return 0, false
+	}
+
+	switch {
+	case len(i) <= 10:
+		return time.Second, true
+	case len(i) <= 13:
+		return time.Millisecond, true
+	case len(i) <= 16:
+		return time.Microsecond, true
+	default:
+		return time.Nanosecond, true
+	}
+}
+
+func timeParseEpoch(s string, unit time.Duration) (time.Time, error) {
+	i, f, hasFrac := strings.Cut(s, ".")
+
+	n, err := strconv.ParseInt(i, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("parsing time %q: %v", s, err)
+	}
+
+	var t time.Time
+	switch unit {
+	case time.Second:
+		t = time.Unix(n, 0)
+	case time.Millisecond:
+		t = time.UnixMilli(n)
+	case time.Microsecond:
+		t = time.UnixMicro(n)
+	default:
+		t = time.Unix(0, n)
+	}
+
+	if hasFrac {
+		frac, err := strconv.ParseFloat("0."+f, 64)
 		if err != nil {
-			return timeDate, fmt.Errorf("format %s: %v", timeFmt, err)
+			return time.Time{}, fmt.Errorf("parsing time %q: %v", s, err)
+		}
+
+		d := time.Duration(frac * float64(unit))
+		if strings.HasPrefix(i, "-") {
+			d = -d
 		}
 
-		timeDate = p
+		t = t.Add(d)
 	}
 
-	return timeDate, nil
+	return t.UTC(), nil
 }
@@ -4,13 +4,62 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/brexhq/substation/v2/config"
 	"github.com/brexhq/substation/v2/message"
+
+	iconfig "github.com/brexhq/substation/v2/internal/config"
 )
 
+type timeFromStringConfig struct {
+	// Format is the format of the string. This can be a Go layout (e.g.,
+	// "2006-01-02T15:04:05Z07:00"), a strftime pattern (e.g.,
+	// "%Y-%m-%dT%H:%M:%S"), an epoch ("unix", "unix_milli", "unix_micro",
+	// or "unix_nano"), or "auto" to detect common formats.
+	//
+	// If the format does not include a year (e.g., syslog timestamps), then
+	// the year is inferred relative to the current time.
+	//
+	// This is optional if Formats is set.
+	Format string `json:"format"`
+	// Formats are tried in order until one of them parses the string. If
+	// Format is also set, then it is tried first.
+	//
+	// This is optional if Format is set.
+	Formats []string `json:"formats"`
+	// Location is the time zone used for strings that do not include one
+	// (e.g., "America/New_York").
+	//
+	// This is optional and defaults to UTC.
+	Location string `json:"location"`
+
+	ID     string         `json:"id"`
+	Object iconfig.Object `json:"object"`
+}
+
+func (c *timeFromStringConfig) Decode(in interface{}) error {
+	return iconfig.Decode(in, c)
+}
+
+func (c *timeFromStringConfig) Validate() error {
+	if c.Object.SourceKey == "" && c.Object.TargetKey != "" {
+		return fmt.Errorf("object_source_key: %v", iconfig.ErrMissingRequiredOption)
+	}
+
+	if c.Object.SourceKey != "" && c.Object.TargetKey == "" {
+		return fmt.Errorf("object_target_key: %v", iconfig.ErrMissingRequiredOption)
+	}
+
+	if c.Format == "" && len(c.Formats) == 0 {
+		return fmt.Errorf("format: %v", iconfig.ErrMissingRequiredOption)
+	}
+
+	return nil
+}
+
 func newTimeFromString(_ context.Context, cfg config.Config) (*timeFromString, error) {
-	conf := timePatternConfig{}
+	conf := timeFromStringConfig{}
 	if err := conf.Decode(cfg.Settings); err != nil {
 		return nil, fmt.Errorf("transform time_from_string: %v", err)
 	}
@@ -26,14 +75,47 @@ func newTimeFromString(_ context.Context, cfg config.Config) (*timeFromString, e
 	tf := timeFromString{
 		conf:     conf,
 		isObject: conf.Object.SourceKey != "" && conf.Object.TargetKey != "",
+		loc:      time.UTC,
+		now:      time.Now,
+	}
+
+	if conf.Location != "" {
+		loc, err := time.LoadLocation(conf.Location)
+		if err != nil {
+			return nil, fmt.Errorf("transform %s: location %s: %v", conf.ID, conf.Location, err)
+		}
+
+		tf.loc = loc
+	}
+
+	formats := conf.Formats
+	if conf.Format != "" {
+		formats = append([]string{conf.Format}, formats...)
+	}
+
+	for _, f := range formats {
+		if f == "" {
+			return nil, fmt.Errorf("transform %s: formats: %v", conf.ID, iconfig.ErrInvalidOption)
+		}
+
+		l, err := timeLayout(f)
+		if err != nil {
+			return nil, fmt.Errorf("transform %s: %v", conf.ID, err)
+		}
+
+		tf.layouts = append(tf.layouts, l)
 	}
 
 	return &tf, nil
 }
 
 type timeFromString struct {
-	conf     timePatternConfig
+	conf     timeFromStringConfig
 	isObject bool
+
+	layouts []string
+	loc     *time.Location
+	now     func() time.Time
 }
 
 func (tf *timeFromString) Transform(ctx context.Context, msg *message.Message) ([]*message.Message, error) {
@@ -52,7 +134,7 @@ func (tf *timeFromString) Transform(ctx context.Context, msg *message.Message) (
 		return []*message.Message{msg}, nil
 	}
 
-	date, err := timeStrToUnix(value.String(), tf.conf.Format, tf.conf.Location)
+	date, err := tf.parse(value.String())
 	if err != nil {
 		return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, err)
 	}
@@ -73,3 +155,23 @@ func (tf *timeFromString) String() string {
 	b, _ := json.Marshal(tf.conf)
 	return string(b)
 }
+
+// parse returns the time from the first layout that parses the string. If no
+// layout parses the string, then the error from the last layout is returned.
+func (tf *timeFromString) parse(s string) (time.Time, error) {
+	now := tf.now()
+
+	var err error
+	for _, l := range tf.layouts {
+		var t time.Time
+		if t, err = timeParse(s, l, tf.loc, now); err == nil {
+			return t, nil
+		}
+	}
+
+	if len(tf.layouts) == 1 {
+		return time.Time{}, fmt.Errorf("format %s: %v", tf.layouts[0], err)
+	}
+
+	return time.Time{}, fmt.Errorf("formats %v: %v", tf.layouts, err)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/brexhq/substation/v2/config"
 	"github.com/brexhq/substation/v2/message"
@@ -61,6 +62,86 @@ var timeFromStringTests = []struct {
 			[]byte(`{"a":1639877490000000000}`),
 		},
 	},
+	{
+		"object formats",
+		config.Config{
+			Settings: map[string]interface{}{
+				"object": map[string]interface{}{
+					"source_key": "a",
+					"target_key": "a",
+				},
+				"formats": []string{time.RFC3339, "2006-01-02 15:04:05"},
+			},
+		},
+		[]byte(`{"a":"2021-12-19 01:31:30"}`),
+		[][]byte{
+			[]byte(`{"a":1639877490000000000}`),
+		},
+	},
+	{
+		"object strftime",
+		config.Config{
+			Settings: map[string]interface{}{
+				"object": map[string]interface{}{
+					"source_key": "a",
+					"target_key": "a",
+				},
+				"format": "%d/%b/%Y:%H:%M:%S %z",
+			},
+		},
+		[]byte(`{"a":"19/Dec/2021:01:31:30 +0000"}`),
+		[][]byte{
+			[]byte(`{"a":1639877490000000000}`),
+		},
+	},
+	{
+		"object unix_milli",
+		config.Config{
+			Settings: map[string]interface{}{
+				"object": map[string]interface{}{
+					"source_key": "a",
+					"target_key": "a",
+				},
+				"format": "unix_milli",
+			},
+		},
+		[]byte(`{"a":"1639877490123"}`),
+		[][]byte{
+			[]byte(`{"a":1639877490123000000}`),
+		},
+	},
+	{
+		"object auto",
+		config.Config{
+			Settings: map[string]interface{}{
+				"object": map[string]interface{}{
+					"source_key": "a",
+					"target_key": "a",
+				},
+				"format": "auto",
+			},
+		},
+		[]byte(`{"a":"Sun, 19 Dec 2021 01:31:30 +0000"}`),
+		[][]byte{
+			[]byte(`{"a":1639877490000000000}`),
+		},
+	},
+	{
+		"object auto epoch",
+		config.Config{
+			Settings: map[string]interface{}{
+				"object": map[string]interface{}{
+					"source_key": "a",
+					"target_key": "a",
+				},
+				"format": "auto",
+			},
+		},
+		[]byte(`{"a":"1639877490.5"}`),
+		[][]byte{
+			[]byte(`{"a":1639877490500000000}`),
+		},
+	},
 }
 
 func TestTimeFromString(t *testing.T) {
@@ -90,6 +171,81 @@ func TestTimeFromString(t *testing.T) {
 	}
 }
 
+func TestTimeFromStringYear(t *testing.T) {
+	tests := []struct {
+		name     string
+		now      time.Time
+		test     string
+		expected time.Time
+	}{
+		{
+			"same year",
+			time.Date(2021, 12, 19, 0, 0, 0, 0, time.UTC),
+			"Dec 18 01:31:30",
+			time.Date(2021, 12, 18, 1, 31, 30, 0, time.UTC),
+		},
+		{
+			"previous year",
+			time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+			"Dec 31 23:59:59",
+			time.Date(2021, 12, 31, 23, 59, 59, 0, time.UTC),
+		},
+	}
+
+	ctx := context.TODO()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tf, err := newTimeFromString(ctx, config.Config{
+				Settings: map[string]interface{}{
+					"format": "auto",
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			tf.now = func() time.Time { return test.now }
+
+			result, err := tf.parse(test.test)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !result.Equal(test.expected) {
+				t.Errorf("expected %s, got %s", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestTimeLayout(t *testing.T) {
+	tests := []struct {
+		format   string
+		expected string
+		err      bool
+	}{
+		{"2006-01-02", "2006-01-02", false},
+		{"%Y-%m-%dT%H:%M:%S.%f%z", "2006-01-02T15:04:05.000000Z0700", false},
+		{"%b %-d %T", "Jan 2 15:04:05", false},
+		{"%s", "unix", false},
+		{"%Y-%Q", "", true},
+		{"%Y%", "", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.format, func(t *testing.T) {
+			result, err := timeLayout(test.format)
+			if (err != nil) != test.err {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if result != test.expected {
+				t.Errorf("expected %s, got %s", test.expected, result)
+			}
+		})
+	}
+}
+
 func benchmarkTimeFromString(b *testing.B, tf *timeFromString, data []byte) {
 	ctx := context.TODO()
 	for i := 0; i < b.N; i++ {
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"math"
 
+	"golang.org/x/sync/errgroup"
+
 	"github.com/brexhq/substation/v2/config"
 	"github.com/brexhq/substation/v2/message"
 
@@ -35,11 +37,28 @@ func New(ctx context.Context, cfg config.Config) (Transformer, error) { //nolint
 		return newAggregateFromString(ctx, cfg)
 	case "aggregate_to_string":
 		return newAggregateToString(ctx, cfg)
+	case "aggregate_window_count":
+		return newAggregateWindowCount(ctx, cfg)
+	case "aggregate_window_distinct_count":
+		return newAggregateWindowDistinctCount(ctx, cfg)
+	case "aggregate_window_max":
+		return newAggregateWindowMax(ctx, cfg)
+	case "aggregate_window_min":
+		return newAggregateWindowMin(ctx, cfg)
+	case "aggregate_window_percentile":
+		return newAggregateWindowPercentile(ctx, cfg)
+	case "aggregate_window_sum":
+		return newAggregateWindowSum(ctx, cfg)
 	// Array transforms.
 	case "array_join":
 		return newArrayJoin(ctx, cfg)
 	case "array_zip":
 		return newArrayZip(ctx, cfg)
+	// Crypto transforms.
+	case "crypto_decrypt":
+		return newCryptoDecrypt(ctx, cfg)
+	case "crypto_encrypt":
+		return newCryptoEncrypt(ctx, cfg)
 	// Enrichment transforms.
 	case "enrich_aws_dynamodb_query":
 		return newEnrichAWSDynamoDBQuery(ctx, cfg)
@@ -68,23 +87,63 @@ func New(ctx context.Context, cfg config.Config) (Transformer, error) { //nolint
 	case "enrich_kv_store_set_add":
 		return newEnrichKVStoreSetAdd(ctx, cfg)
 	// Format transforms.
+	case "format_from_avro":
+		return newFormatFromAvro(ctx, cfg)
+	case "format_to_avro":
+		return newFormatToAvro(ctx, cfg)
 	case "format_from_base64":
 		return newFormatFromBase64(ctx, cfg)
 	case "format_to_base64":
 		return newFormatToBase64(ctx, cfg)
+	case "format_from_csv":
+		return newFormatFromCSV(ctx, cfg)
+	case "format_to_csv":
+		return newFormatToCSV(ctx, cfg)
 	case "format_from_gzip":
 		return newFormatFromGzip(ctx, cfg)
 	case "format_to_gzip":
 		return newFormatToGzip(ctx, cfg)
+	case "format_from_kv":
+		return newFormatFromKV(ctx, cfg)
+	case "format_from_parquet":
+		return newFormatFromParquet(ctx, cfg)
+	case "format_to_parquet":
+		return newFormatToParquet(ctx, cfg)
 	case "format_from_pretty_print":
 		return newFormatFromPrettyPrint(ctx, cfg)
+	case "format_from_protobuf":
+		return newFormatFromProtobuf(ctx, cfg)
+	case "format_to_protobuf":
+		return newFormatToProtobuf(ctx, cfg)
+	case "format_from_syslog":
+		return newFormatFromSyslog(ctx, cfg)
 	case "format_from_zip":
 		return newFormatFromZip(ctx, cfg)
 	// Hash transforms.
+	case "hash_blake2b":
+		return newHashBLAKE2b(ctx, cfg)
+	case "hash_blake3":
+		return newHashBLAKE3(ctx, cfg)
+	case "hash_crc32":
+		return newHashCRC32(ctx, cfg)
+	case "hash_fnv":
+		return newHashFNV(ctx, cfg)
+	case "hash_hmac":
+		return newHashHMAC(ctx, cfg)
 	case "hash_md5":
 		return newHashMD5(ctx, cfg)
+	case "hash_sha1":
+		return newHashSHA1(ctx, cfg)
 	case "hash_sha256":
 		return newHashSHA256(ctx, cfg)
+	case "hash_sha512":
+		return newHashSHA512(ctx, cfg)
+	case "hash_ssdeep":
+		return newHashSSDeep(ctx, cfg)
+	case "hash_tlsh":
+		return newHashTLSH(ctx, cfg)
+	case "hash_xxhash":
+		return newHashXXHash(ctx, cfg)
 	// Meta transforms.
 	case "meta_err":
 		return newMetaErr(ctx, cfg)
@@ -98,6 +157,8 @@ func New(ctx context.Context, cfg config.Config) (Transformer, error) { //nolint
 		return newMetaRetry(ctx, cfg)
 	case "meta_switch":
 		return newMetaSwitch(ctx, cfg)
+	case "meta_threshold":
+		return newMetaThreshold(ctx, cfg)
 	// Number transforms.
 	case "number_maximum":
 		return newNumberMaximum(ctx, cfg)
@@ -137,6 +198,11 @@ func New(ctx context.Context, cfg config.Config) (Transformer, error) { //nolint
 		return newObjectToString(ctx, cfg)
 	case "object_to_unsigned_integer":
 		return newObjectToUnsignedInteger(ctx, cfg)
+	// Schema transforms.
+	case "schema_to_ecs":
+		return newSchemaToECS(ctx, cfg)
+	case "schema_to_ocsf":
+		return newSchemaToOCSF(ctx, cfg)
 	// Send transforms.
 	case "send_aws_dynamodb_put":
 		return newSendAWSDynamoDBPut(ctx, cfg)
@@ -158,6 +224,8 @@ func New(ctx context.Context, cfg config.Config) (Transformer, error) { //nolint
 		return newSendFile(ctx, cfg)
 	case "send_http_post":
 		return newSendHTTPPost(ctx, cfg)
+	case "send_kafka":
+		return newSendKafka(ctx, cfg)
 	case "send_stdout":
 		return newSendStdout(ctx, cfg)
 	// String transforms.
@@ -165,12 +233,16 @@ func New(ctx context.Context, cfg config.Config) (Transformer, error) { //nolint
 		return newStringAppend(ctx, cfg)
 	case "string_capture":
 		return newStringCapture(ctx, cfg)
+	case "string_grok":
+		return newStringGrok(ctx, cfg)
 	case "string_to_lower":
 		return newStringToLower(ctx, cfg)
 	case "string_to_snake":
 		return newStringToSnake(ctx, cfg)
 	case "string_to_upper":
 		return newStringToUpper(ctx, cfg)
+	case "string_redact":
+		return newStringRedact(ctx, cfg)
 	case "string_replace":
 		return newStringReplace(ctx, cfg)
 	case "string_split":
@@ -200,6 +272,8 @@ func New(ctx context.Context, cfg config.Config) (Transformer, error) { //nolint
 		return newUtilityControl(ctx, cfg)
 	case "utility_delay":
 		return newUtilityDelay(ctx, cfg)
+	case "utility_dedupe":
+		return newUtilityDedupe(ctx, cfg)
 	case "utility_drop":
 		return newUtilityDrop(ctx, cfg)
 	case "utility_err":
@@ -210,6 +284,8 @@ func New(ctx context.Context, cfg config.Config) (Transformer, error) { //nolint
 		return newUtilityMetricCount(ctx, cfg)
 	case "utility_metric_freshness":
 		return newUtilityMetricFreshness(ctx, cfg)
+	case "utility_rate_limit":
+		return newUtilityRateLimit(ctx, cfg)
 	case "utility_secret":
 		return newUtilitySecret(ctx, cfg)
 	default:
@@ -239,6 +315,162 @@ func Apply(ctx context.Context, tf []Transformer, msgs ...*message.Message) ([]*
 	return resultMsgs, nil
 }
 
+// ApplyConcurrent applies one or more transform functions to one or more
+// messages using up to concurrency goroutines. The result is the same as
+// Apply, including the order of the messages.
+//
+// Consecutive transforms that do not depend on the order of messages are
+// applied to each message in parallel. Transforms that depend on the order
+// of messages (e.g., aggregate_to_array, utility_control, send_*), and meta
+// transforms that contain them, receive messages one at a time in order.
+// Control messages are barriers: they are transformed after all previous
+// messages and before all later messages. Transforms that wrap another
+// transform can implement Unwrap() Transformer so that the wrapped transform
+// is inspected.
+//
+// If concurrency is less than 2, then this is the same as Apply.
+func ApplyConcurrent(ctx context.Context, tf []Transformer, concurrency int, msgs ...*message.Message) ([]*message.Message, error) {
+	if concurrency < 2 {
+		return Apply(ctx, tf, msgs...)
+	}
+
+	resultMsgs := make([]*message.Message, len(msgs))
+	copy(resultMsgs, msgs)
+
+	for i := 0; len(resultMsgs) > 0 && i < len(tf); {
+		if isOrdered(tf[i]) {
+			var err error
+			if resultMsgs, err = Apply(ctx, tf[i:i+1], resultMsgs...); err != nil {
+				return nil, err
+			}
+
+			i++
+			continue
+		}
+
+		// Finds the end of the run of transforms that can be applied in parallel.
+		j := i + 1
+		for j < len(tf) && !isOrdered(tf[j]) {
+			j++
+		}
+
+		var err error
+		if resultMsgs, err = applyParallel(ctx, tf[i:j], concurrency, resultMsgs); err != nil {
+			return nil, err
+		}
+
+		i = j
+	}
+
+	return resultMsgs, nil
+}
+
+// applyParallel applies the transforms to each data message in parallel and
+// returns the results in the same order as the messages. Control messages are
+// applied after all previous data messages are complete.
+func applyParallel(ctx context.Context, tf []Transformer, concurrency int, msgs []*message.Message) ([]*message.Message, error) {
+	var resultMsgs []*message.Message
+
+	for start := 0; start < len(msgs); {
+		if msgs[start].IsControl() {
+			res, err := Apply(ctx, tf, msgs[start])
+			if err != nil {
+				return nil, err
+			}
+
+			resultMsgs = append(resultMsgs, res...)
+			start++
+
+			continue
+		}
+
+		end := start + 1
+		for end < len(msgs) && !msgs[end].IsControl() {
+			end++
+		}
+
+		results := make([][]*message.Message, end-start)
+		group, gCtx := errgroup.WithContext(ctx)
+		group.SetLimit(concurrency)
+
+		for k, m := range msgs[start:end] {
+			group.Go(func() error {
+				res, err := Apply(gCtx, tf, m)
+				if err != nil {
+					return err
+				}
+
+				results[k] = res
+				return nil
+			})
+		}
+
+		if err := group.Wait(); err != nil {
+			return nil, err
+		}
+
+		for _, res := range results {
+			resultMsgs = append(resultMsgs, res...)
+		}
+
+		start = end
+	}
+
+	return resultMsgs, nil
+}
+
+// isOrdered returns true if the transform depends on the order of messages.
+//
+//nolint:cyclop // ignore cyclomatic complexity
+func isOrdered(tf Transformer) bool {
+	switch t := tf.(type) {
+	case *aggregateToArray, *aggregateToString, *aggregateWindowCount,
+		*aggregateWindowDistinctCount, *aggregateWindowMax, *aggregateWindowMin,
+		*aggregateWindowPercentile, *aggregateWindowSum, *formatToCSV, *formatToParquet,
+		*metaThreshold, *utilityControl, *utilityDedupe, *utilityRateLimit:
+		return true
+	case *sendAWSDataFirehose, *sendAWSDynamoDBPut, *sendAWSEventBridge,
+		*sendAWSKinesisDataStream, *sendAWSLambda, *sendAWSS3, *sendAWSSNS,
+		*sendAWSSQS, *sendFile, *sendHTTPPost, *sendKafka, *sendStdout:
+		return true
+	case *formatToAvro:
+		// Object Container Files are written when a control message is received.
+		return t.conf.Format == "ocf"
+	case *metaErr:
+		return anyOrdered(t.tfs)
+	case *metaForEach:
+		return anyOrdered(t.tfs)
+	case *metaKVStoreLock:
+		return anyOrdered(t.tfs)
+	case *metaMetricDuration:
+		return anyOrdered(t.tfs)
+	case *metaRetry:
+		return anyOrdered(t.transforms)
+	case *metaSwitch:
+		for _, c := range t.conditional {
+			if anyOrdered(c.transformers) {
+				return true
+			}
+		}
+
+		return false
+	case interface{ Unwrap() Transformer }:
+		return isOrdered(t.Unwrap())
+	default:
+		return false
+	}
+}
+
+func anyOrdered(tf []Transformer) bool {
+	for _, t := range tf {
+		if isOrdered(t) {
+			return true
+		}
+	}
+
+	return false
+}
+
 func bytesToValue(b []byte) message.Value {
 	msg := message.New()
 	_ = msg.SetValue("_", b)
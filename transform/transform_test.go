@@ -2,6 +2,7 @@ package transform
 
 import (
 	"context"
+	"fmt"
 	"reflect"
 	"testing"
 
@@ -108,6 +109,176 @@ func TestTransform(t *testing.T) {
 	}
 }
 
+var applyConcurrentTests = []struct {
+	name string
+	cfg  []config.Config
+}{
+	{
+		"stateless",
+		[]config.Config{
+			{
+				Type: "object_copy",
+				Settings: map[string]interface{}{
+					"object": map[string]interface{}{"source_key": "n", "target_key": "c"},
+				},
+			},
+			{
+				Type: "hash_sha256",
+				Settings: map[string]interface{}{
+					"object": map[string]interface{}{"source_key": "n", "target_key": "h"},
+				},
+			},
+		},
+	},
+	{
+		"aggregate_to_array",
+		[]config.Config{
+			{
+				Type: "object_copy",
+				Settings: map[string]interface{}{
+					"object": map[string]interface{}{"source_key": "n", "target_key": "c"},
+				},
+			},
+			{
+				Type: "aggregate_to_array",
+				Settings: map[string]interface{}{
+					"batch": map[string]interface{}{"count": 7},
+				},
+			},
+			{
+				Type: "aggregate_from_array",
+			},
+		},
+	},
+	{
+		"utility_control",
+		[]config.Config{
+			{
+				Type: "utility_control",
+				Settings: map[string]interface{}{
+					"batch": map[string]interface{}{"count": 5},
+				},
+			},
+			{
+				Type: "object_copy",
+				Settings: map[string]interface{}{
+					"object": map[string]interface{}{"source_key": "n", "target_key": "c"},
+				},
+			},
+			{
+				Type: "aggregate_to_array",
+				Settings: map[string]interface{}{
+					"batch": map[string]interface{}{"count": 100},
+				},
+			},
+		},
+	},
+	{
+		"meta_switch",
+		[]config.Config{
+			{
+				Type: "meta_switch",
+				Settings: map[string]interface{}{
+					"cases": []map[string]interface{}{
+						{
+							"transforms": []config.Config{
+								{
+									Type: "aggregate_to_string",
+									Settings: map[string]interface{}{
+										"separator": ",",
+										"batch":     map[string]interface{}{"count": 3},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	},
+}
+
+func applyConcurrentMessages() []*message.Message {
+	var msgs []*message.Message
+	for i := 0; i < 50; i++ {
+		msgs = append(msgs, message.New().SetData([]byte(fmt.Sprintf(`{"n":%d}`, i))))
+
+		// Control messages are added in the middle and at the end of the messages.
+		if i == 24 || i == 49 {
+			msgs = append(msgs, message.New().AsControl())
+		}
+	}
+
+	return msgs
+}
+
+func TestApplyConcurrent(t *testing.T) {
+	ctx := context.TODO()
+
+	for _, test := range applyConcurrentTests {
+		t.Run(test.name, func(t *testing.T) {
+			var results [2][][]byte
+			for i, concurrency := range []int{1, 4} {
+				var tfs []Transformer
+				for _, c := range test.cfg {
+					tf, err := New(ctx, c)
+					if err != nil {
+						t.Fatal(err)
+					}
+
+					tfs = append(tfs, tf)
+				}
+
+				msgs, err := ApplyConcurrent(ctx, tfs, concurrency, applyConcurrentMessages()...)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				for _, m := range msgs {
+					if m.IsControl() {
+						results[i] = append(results[i], []byte("ctrl"))
+						continue
+					}
+
+					results[i] = append(results[i], m.Data())
+				}
+			}
+
+			if !reflect.DeepEqual(results[0], results[1]) {
+				t.Errorf("expected %s, got %s", results[0], results[1])
+			}
+		})
+	}
+}
+
+func benchmarkApplyConcurrent(b *testing.B, concurrency int) {
+	ctx := context.TODO()
+	tf, err := New(ctx, config.Config{
+		Type: "hash_sha256",
+		Settings: map[string]interface{}{
+			"object": map[string]interface{}{"source_key": "n", "target_key": "h"},
+		},
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	msgs := applyConcurrentMessages()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = ApplyConcurrent(ctx, []Transformer{tf, tf, tf}, concurrency, msgs...)
+	}
+}
+
+func BenchmarkApplyConcurrent(b *testing.B) {
+	for _, concurrency := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("concurrency_%d", concurrency), func(b *testing.B) {
+			benchmarkApplyConcurrent(b, concurrency)
+		})
+	}
+}
+
 var truncateTTLTests = []struct {
 	name     string
 	test     []byte
@@ -0,0 +1,165 @@
+package transform
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+
+	iconfig "github.com/brexhq/substation/v2/internal/config"
+	"github.com/brexhq/substation/v2/internal/kv"
+)
+
+type utilityDedupeConfig struct {
+	// Keys are the keys of values in the message that identify duplicates
+	// (e.g., ["user.id", "event.action"]). The values are hashed together and
+	// missing values are hashed as null.
+	//
+	// This is optional and defaults to hashing the message data.
+	Keys []string `json:"keys"`
+	// Prefix is prepended to the hash and can be used to simplify
+	// data management within a KV store.
+	//
+	// This is optional and defaults to an empty string.
+	Prefix string `json:"prefix"`
+	// TTLOffset is the window of time that repeats of a message are considered
+	// duplicates (e.g., "5m"). The window begins when the first message is
+	// seen.
+	TTLOffset string `json:"ttl_offset"`
+
+	ID string `json:"id"`
+	// Object.TargetKey is used to tag duplicates instead of dropping them.
+	// If this is set, then the value is set to true for duplicates and is not
+	// set for the first message.
+	Object  iconfig.Object `json:"object"`
+	KVStore config.Config  `json:"kv_store"`
+}
+
+func (c *utilityDedupeConfig) Decode(in interface{}) error {
+	return iconfig.Decode(in, c)
+}
+
+func (c *utilityDedupeConfig) Validate() error {
+	if c.TTLOffset == "" {
+		return fmt.Errorf("ttl_offset: %v", iconfig.ErrMissingRequiredOption)
+	}
+
+	if c.KVStore.Type == "" {
+		return fmt.Errorf("kv_store: %v", iconfig.ErrMissingRequiredOption)
+	}
+
+	return nil
+}
+
+func newUtilityDedupe(ctx context.Context, cfg config.Config) (*utilityDedupe, error) {
+	conf := utilityDedupeConfig{}
+	if err := conf.Decode(cfg.Settings); err != nil {
+		return nil, fmt.Errorf("transform utility_dedupe: %v", err)
+	}
+
+	if conf.ID == "" {
+		conf.ID = "utility_dedupe"
+	}
+
+	if err := conf.Validate(); err != nil {
+		return nil, fmt.Errorf("transform %s: %v", conf.ID, err)
+	}
+
+	dur, err := time.ParseDuration(conf.TTLOffset)
+	if err != nil {
+		return nil, fmt.Errorf("transform %s: %v", conf.ID, err)
+	}
+
+	if dur < time.Second {
+		return nil, fmt.Errorf("transform %s: ttl_offset %s: %v", conf.ID, conf.TTLOffset, iconfig.ErrInvalidOption)
+	}
+
+	locker, err := kv.GetLocker(conf.KVStore)
+	if err != nil {
+		return nil, fmt.Errorf("transform %s: %v", conf.ID, err)
+	}
+
+	if err := locker.Setup(ctx); err != nil {
+		return nil, fmt.Errorf("transform %s: %v", conf.ID, err)
+	}
+
+	tf := utilityDedupe{
+		conf:   conf,
+		locker: locker,
+		ttl:    dur,
+	}
+
+	return &tf, nil
+}
+
+// utilityDedupe drops or tags messages that were already seen within a
+// window of time. Messages are identified by a hash that is locked in a KV
+// store, so the check and set is atomic and can be shared by multiple
+// processes if the store supports it (e.g., aws_dynamodb).
+type utilityDedupe struct {
+	conf   utilityDedupeConfig
+	locker kv.Locker
+	ttl    time.Duration
+}
+
+func (tf *utilityDedupe) Transform(ctx context.Context, msg *message.Message) ([]*message.Message, error) {
+	if msg.IsControl() {
+		return []*message.Message{msg}, nil
+	}
+
+	key, err := tf.hash(msg)
+	if err != nil {
+		return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, err)
+	}
+
+	err = tf.locker.Lock(ctx, key, time.Now().Add(tf.ttl).Unix())
+	switch {
+	case err == nil:
+		return []*message.Message{msg}, nil
+	case err != kv.ErrNoLock:
+		return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, err)
+	}
+
+	// The message is a duplicate.
+	if tf.conf.Object.TargetKey == "" {
+		return []*message.Message{}, nil
+	}
+
+	if err := msg.SetValue(tf.conf.Object.TargetKey, true); err != nil {
+		return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, err)
+	}
+
+	return []*message.Message{msg}, nil
+}
+
+func (tf *utilityDedupe) String() string {
+	b, _ := json.Marshal(tf.conf)
+	return string(b)
+}
+
+// hash returns the SHA256 hash of the message data or the configured values.
+func (tf *utilityDedupe) hash(msg *message.Message) (string, error) {
+	b := msg.Data()
+	if len(tf.conf.Keys) > 0 {
+		values := make([]interface{}, len(tf.conf.Keys))
+		for i, k := range tf.conf.Keys {
+			values[i] = msg.GetValue(k).Value()
+		}
+
+		var err error
+		if b, err = json.Marshal(values); err != nil {
+			return "", err
+		}
+	}
+
+	sum := sha256.Sum256(b)
+	if tf.conf.Prefix != "" {
+		return fmt.Sprintf("%s:%x", tf.conf.Prefix, sum), nil
+	}
+
+	return fmt.Sprintf("%x", sum), nil
+}
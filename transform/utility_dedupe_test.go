@@ -0,0 +1,205 @@
+package transform
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+)
+
+var _ Transformer = &utilityDedupe{}
+
+var utilityDedupeTests = []struct {
+	name     string
+	cfg      config.Config
+	test     [][]byte
+	expected [][]byte
+}{
+	{
+		"data",
+		config.Config{
+			Settings: map[string]interface{}{
+				"ttl_offset": "1m",
+				"kv_store": map[string]interface{}{
+					"type": "memory",
+				},
+			},
+		},
+		[][]byte{
+			[]byte(`{"a":"b"}`),
+			[]byte(`{"a":"c"}`),
+			[]byte(`{"a":"b"}`),
+		},
+		[][]byte{
+			[]byte(`{"a":"b"}`),
+			[]byte(`{"a":"c"}`),
+		},
+	},
+	{
+		"keys",
+		config.Config{
+			Settings: map[string]interface{}{
+				"keys":       []string{"user", "action"},
+				"prefix":     "keys",
+				"ttl_offset": "1m",
+				"kv_store": map[string]interface{}{
+					"type": "memory",
+				},
+			},
+		},
+		[][]byte{
+			[]byte(`{"user":"alice","action":"login","time":1}`),
+			[]byte(`{"user":"alice","action":"login","time":2}`),
+			[]byte(`{"user":"alice","action":"logout","time":3}`),
+			[]byte(`{"user":"bob","time":4}`),
+			[]byte(`{"user":"bob","action":null,"time":5}`),
+		},
+		[][]byte{
+			[]byte(`{"user":"alice","action":"login","time":1}`),
+			[]byte(`{"user":"alice","action":"logout","time":3}`),
+			[]byte(`{"user":"bob","time":4}`),
+		},
+	},
+	{
+		"tag",
+		config.Config{
+			Settings: map[string]interface{}{
+				"prefix":     "tag",
+				"ttl_offset": "1m",
+				"object": map[string]interface{}{
+					"target_key": "meta duplicate",
+				},
+				"kv_store": map[string]interface{}{
+					"type": "memory",
+				},
+			},
+		},
+		[][]byte{
+			[]byte(`{"a":"b"}`),
+			[]byte(`{"a":"b"}`),
+		},
+		[][]byte{
+			[]byte(`{"a":"b"}`),
+			[]byte(`{"a":"b"}`),
+		},
+	},
+}
+
+func TestUtilityDedupe(t *testing.T) {
+	ctx := context.TODO()
+	for _, test := range utilityDedupeTests {
+		t.Run(test.name, func(t *testing.T) {
+			tf, err := newUtilityDedupe(ctx, test.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var result [][]byte
+			for _, b := range test.test {
+				msgs, err := tf.Transform(ctx, message.New().SetData(b))
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				for _, m := range msgs {
+					result = append(result, m.Data())
+				}
+			}
+
+			if fmt.Sprint(result) != fmt.Sprint(test.expected) {
+				t.Errorf("expected %s, got %s", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestUtilityDedupeTag(t *testing.T) {
+	ctx := context.TODO()
+	tf, err := newUtilityDedupe(ctx, config.Config{
+		Settings: map[string]interface{}{
+			"prefix":     "tag_metadata",
+			"ttl_offset": "1m",
+			"object": map[string]interface{}{
+				"target_key": "meta duplicate",
+			},
+			"kv_store": map[string]interface{}{
+				"type": "memory",
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, expected := range []bool{false, true, true} {
+		msgs, err := tf.Transform(ctx, message.New().SetData([]byte(`{"a":"b"}`)))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if dup := msgs[0].GetValue("meta duplicate").Bool(); dup != expected {
+			t.Errorf("message %d: expected %v, got %v", i, expected, dup)
+		}
+	}
+}
+
+func benchmarkUtilityDedupe(b *testing.B, tf *utilityDedupe, data []byte) {
+	ctx := context.TODO()
+	for i := 0; i < b.N; i++ {
+		msg := message.New().SetData(data)
+		_, _ = tf.Transform(ctx, msg)
+	}
+}
+
+func BenchmarkUtilityDedupe(b *testing.B) {
+	for _, test := range utilityDedupeTests {
+		tf, err := newUtilityDedupe(context.TODO(), test.cfg)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(test.name,
+			func(b *testing.B) {
+				benchmarkUtilityDedupe(b, tf, test.test[0])
+			},
+		)
+	}
+}
+
+func FuzzTestUtilityDedupe(f *testing.F) {
+	testcases := [][]byte{
+		[]byte(`{"a":"b"}`),
+		[]byte(`{"a":"b"}`),
+		[]byte(`{"user":"alice","action":"login"}`),
+		[]byte(``),
+	}
+
+	for _, tc := range testcases {
+		f.Add(tc)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ctx := context.TODO()
+		msg := message.New().SetData(data)
+
+		tf, err := newUtilityDedupe(ctx, config.Config{
+			Settings: map[string]interface{}{
+				"keys":       []string{"user", "action"},
+				"ttl_offset": "1m",
+				"kv_store": map[string]interface{}{
+					"type": "memory",
+				},
+			},
+		})
+		if err != nil {
+			return
+		}
+
+		_, err = tf.Transform(ctx, msg)
+		if err != nil {
+			return
+		}
+	})
+}
@@ -64,6 +64,10 @@ func (tf *utilityMetricBytes) Transform(ctx context.Context, msg *message.Messag
 			return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, err)
 		}
 
+		if err := metrics.Flush(ctx, tf.metric); err != nil {
+			return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, err)
+		}
+
 		atomic.StoreUint32(&tf.bytes, 0)
 		return []*message.Message{msg}, nil
 	}
@@ -102,6 +102,10 @@ func (tf *utilityMetricFreshness) Transform(ctx context.Context, msg *message.Me
 			return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, err)
 		}
 
+		if err := metrics.Flush(ctx, tf.metric); err != nil {
+			return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, err)
+		}
+
 		atomic.StoreUint32(&tf.success, 0)
 		atomic.StoreUint32(&tf.failure, 0)
 		return []*message.Message{msg}, nil
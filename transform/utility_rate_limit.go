@@ -0,0 +1,266 @@
+package transform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+
+	iconfig "github.com/brexhq/substation/v2/internal/config"
+)
+
+// utilityRateLimitPruneSize is the number of keys that are stored before
+// idle keys are removed.
+const utilityRateLimitPruneSize = 10000
+
+type utilityRateLimitConfig struct {
+	// Rate is the number of messages per second that are allowed for each
+	// key. Messages are limited using a token bucket that refills at this
+	// rate.
+	//
+	// This is optional if SampleRate is set.
+	Rate float64 `json:"rate"`
+	// Burst is the maximum number of messages that are allowed at once for
+	// each key.
+	//
+	// This is optional and defaults to the rate rounded up, or 1.
+	Burst int `json:"burst"`
+	// SampleRate is the fraction (greater than 0, up to 1) of keys that are
+	// allowed. Keys are sampled by their hash, so every transform that uses
+	// the same sample rate allows the same keys (e.g., trace IDs). If this is
+	// used with Rate, then messages are sampled before they are limited.
+	//
+	// This is optional and defaults to allowing all keys.
+	SampleRate float64 `json:"sample_rate"`
+	// Action determines what happens to messages that are not allowed. Must
+	// be one of:
+	//
+	// - drop: the message is removed from the pipeline.
+	//
+	// - tag: the message is sent and Object.TargetKey is set to true.
+	//
+	// - delay: the message is sent when the rate allows it. This cannot be
+	// used with SampleRate.
+	//
+	// This is optional and defaults to drop.
+	Action string `json:"action"`
+
+	ID string `json:"id"`
+	// Object.SourceKey retrieves the key that messages are limited by (e.g.,
+	// "host.name"). If this is not set, then the message data is used for
+	// sampling and all messages share one limit.
+	Object iconfig.Object `json:"object"`
+}
+
+func (c *utilityRateLimitConfig) Decode(in interface{}) error {
+	return iconfig.Decode(in, c)
+}
+
+func (c *utilityRateLimitConfig) Validate() error {
+	if c.Rate == 0 && c.SampleRate == 0 {
+		return fmt.Errorf("rate: %v", iconfig.ErrMissingRequiredOption)
+	}
+
+	if c.Rate < 0 {
+		return fmt.Errorf("rate %v: %v", c.Rate, iconfig.ErrInvalidOption)
+	}
+
+	if c.Burst < 0 {
+		return fmt.Errorf("burst %v: %v", c.Burst, iconfig.ErrInvalidOption)
+	}
+
+	if c.SampleRate < 0 || c.SampleRate > 1 {
+		return fmt.Errorf("sample_rate %v: %v", c.SampleRate, iconfig.ErrInvalidOption)
+	}
+
+	switch c.Action {
+	case "drop":
+	case "tag":
+		if c.Object.TargetKey == "" {
+			return fmt.Errorf("object_target_key: %v", iconfig.ErrMissingRequiredOption)
+		}
+	case "delay":
+		if c.SampleRate != 0 {
+			return fmt.Errorf("action %s: %v", c.Action, iconfig.ErrInvalidOption)
+		}
+	default:
+		return fmt.Errorf("action %s: %v", c.Action, iconfig.ErrInvalidOption)
+	}
+
+	return nil
+}
+
+func newUtilityRateLimit(_ context.Context, cfg config.Config) (*utilityRateLimit, error) {
+	conf := utilityRateLimitConfig{}
+	if err := conf.Decode(cfg.Settings); err != nil {
+		return nil, fmt.Errorf("transform utility_rate_limit: %v", err)
+	}
+
+	if conf.ID == "" {
+		conf.ID = "utility_rate_limit"
+	}
+
+	if conf.Action == "" {
+		conf.Action = "drop"
+	}
+
+	if conf.Burst == 0 {
+		conf.Burst = int(math.Max(1, math.Ceil(conf.Rate)))
+	}
+
+	if err := conf.Validate(); err != nil {
+		return nil, fmt.Errorf("transform %s: %v", conf.ID, err)
+	}
+
+	tf := utilityRateLimit{
+		conf:    conf,
+		now:     time.Now,
+		buckets: make(map[string]*utilityRateLimitBucket),
+		prune:   utilityRateLimitPruneSize,
+	}
+
+	return &tf, nil
+}
+
+type utilityRateLimitBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// utilityRateLimit limits the rate of messages per key with token buckets and
+// optionally samples keys.
+type utilityRateLimit struct {
+	conf utilityRateLimitConfig
+	now  func() time.Time
+
+	// mu is required to prevent concurrent access to the buckets map.
+	mu      sync.Mutex
+	buckets map[string]*utilityRateLimitBucket
+	prune   int
+}
+
+func (tf *utilityRateLimit) Transform(ctx context.Context, msg *message.Message) ([]*message.Message, error) {
+	if msg.IsControl() {
+		return []*message.Message{msg}, nil
+	}
+
+	var key []byte
+	if tf.conf.Object.SourceKey != "" {
+		key = msg.GetValue(tf.conf.Object.SourceKey).Bytes()
+	}
+
+	allowed := true
+	if tf.conf.SampleRate != 0 {
+		b := key
+		if tf.conf.Object.SourceKey == "" {
+			b = msg.Data()
+		}
+
+		allowed = utilityRateLimitSample(b, tf.conf.SampleRate)
+	}
+
+	if allowed && tf.conf.Rate != 0 {
+		wait, ok := tf.reserve(string(key))
+		if wait > 0 {
+			timer := time.NewTimer(wait)
+			defer timer.Stop()
+
+			select {
+			case <-ctx.Done():
+				return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, ctx.Err())
+			case <-timer.C:
+			}
+		}
+
+		allowed = ok
+	}
+
+	if allowed {
+		return []*message.Message{msg}, nil
+	}
+
+	if tf.conf.Action == "tag" {
+		if err := msg.SetValue(tf.conf.Object.TargetKey, true); err != nil {
+			return nil, fmt.Errorf("transform %s: %v", tf.conf.ID, err)
+		}
+
+		return []*message.Message{msg}, nil
+	}
+
+	return []*message.Message{}, nil
+}
+
+func (tf *utilityRateLimit) String() string {
+	b, _ := json.Marshal(tf.conf)
+	return string(b)
+}
+
+// reserve takes a token from the bucket of the key. If no token is available
+// and the action is delay, then a token is reserved and the time to wait for
+// it is returned.
+func (tf *utilityRateLimit) reserve(key string) (time.Duration, bool) {
+	tf.mu.Lock()
+	defer tf.mu.Unlock()
+
+	now := tf.now()
+	burst := float64(tf.conf.Burst)
+
+	b, ok := tf.buckets[key]
+	if !ok {
+		if len(tf.buckets) >= tf.prune {
+			tf.pruneBuckets(now)
+		}
+
+		b = &utilityRateLimitBucket{tokens: burst, last: now}
+		tf.buckets[key] = b
+	}
+
+	b.tokens = math.Min(burst, b.tokens+now.Sub(b.last).Seconds()*tf.conf.Rate)
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0, true
+	}
+
+	if tf.conf.Action != "delay" {
+		return 0, false
+	}
+
+	// The bucket is allowed to go below zero so that messages wait in the
+	// order they were received.
+	wait := time.Duration((1 - b.tokens) / tf.conf.Rate * float64(time.Second))
+	b.tokens--
+
+	return wait, true
+}
+
+// pruneBuckets removes buckets that are full, which are the same as buckets
+// that do not exist.
+func (tf *utilityRateLimit) pruneBuckets(now time.Time) {
+	burst := float64(tf.conf.Burst)
+	for key, b := range tf.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*tf.conf.Rate >= burst {
+			delete(tf.buckets, key)
+		}
+	}
+
+	// If most keys are active, then pruning is delayed until the number of
+	// keys doubles.
+	tf.prune = max(utilityRateLimitPruneSize, len(tf.buckets)*2)
+}
+
+// utilityRateLimitSample returns true if the hash of the value is within the
+// sample rate.
+func utilityRateLimitSample(b []byte, rate float64) bool {
+	h := fnv.New64a()
+	_, _ = h.Write(b)
+
+	return float64(h.Sum64())/math.MaxUint64 < rate
+}
@@ -0,0 +1,267 @@
+package transform
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/brexhq/substation/v2/config"
+	"github.com/brexhq/substation/v2/message"
+)
+
+var _ Transformer = &utilityRateLimit{}
+
+var utilityRateLimitTests = []struct {
+	name     string
+	cfg      config.Config
+	data     []aggWindowTestMessage
+	expected []string
+}{
+	{
+		"drop",
+		config.Config{
+			Settings: map[string]interface{}{
+				"rate":  1,
+				"burst": 2,
+				"object": map[string]interface{}{
+					"source_key": "host",
+				},
+			},
+		},
+		[]aggWindowTestMessage{
+			{0, `{"host":"a","n":1}`},
+			{0, `{"host":"a","n":2}`},
+			{0, `{"host":"a","n":3}`},
+			{0, `{"host":"b","n":4}`},
+			{500 * time.Millisecond, `{"host":"a","n":5}`},
+			{time.Second, `{"host":"a","n":6}`},
+			{time.Second, `{"host":"a","n":7}`},
+		},
+		[]string{
+			`{"host":"a","n":1}`,
+			`{"host":"a","n":2}`,
+			`{"host":"b","n":4}`,
+			`{"host":"a","n":6}`,
+		},
+	},
+	{
+		"tag",
+		config.Config{
+			Settings: map[string]interface{}{
+				"rate":   1,
+				"action": "tag",
+				"object": map[string]interface{}{
+					"target_key": "limited",
+				},
+			},
+		},
+		[]aggWindowTestMessage{
+			{0, `{"n":1}`},
+			{0, `{"n":2}`},
+			{time.Second, `{"n":3}`},
+		},
+		[]string{
+			`{"n":1}`,
+			`{"n":2,"limited":true}`,
+			`{"n":3}`,
+		},
+	},
+}
+
+func TestUtilityRateLimit(t *testing.T) {
+	ctx := context.TODO()
+	for _, test := range utilityRateLimitTests {
+		t.Run(test.name, func(t *testing.T) {
+			tf, err := newUtilityRateLimit(ctx, test.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			start := time.Unix(3600, 0)
+
+			var now time.Time
+			tf.now = func() time.Time { return now }
+
+			var result []string
+			for _, d := range test.data {
+				now = start.Add(d.offset)
+
+				msgs, err := tf.Transform(ctx, message.New().SetData([]byte(d.data)))
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				for _, m := range msgs {
+					result = append(result, string(m.Data()))
+				}
+			}
+
+			if fmt.Sprint(result) != fmt.Sprint(test.expected) {
+				t.Errorf("expected %s, got %s", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestUtilityRateLimitDelay(t *testing.T) {
+	ctx := context.TODO()
+	tf, err := newUtilityRateLimit(ctx, config.Config{
+		Settings: map[string]interface{}{
+			"rate":   100,
+			"burst":  1,
+			"action": "delay",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		msgs, err := tf.Transform(ctx, message.New().SetData([]byte(`{"a":"b"}`)))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(msgs) != 1 {
+			t.Fatalf("expected 1 message, got %d", len(msgs))
+		}
+	}
+
+	// The first message is sent immediately and the others wait 10ms each.
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Errorf("expected a delay of at least 20ms, got %v", elapsed)
+	}
+}
+
+func TestUtilityRateLimitSample(t *testing.T) {
+	ctx := context.TODO()
+	cfg := config.Config{
+		Settings: map[string]interface{}{
+			"sample_rate": 0.25,
+			"object": map[string]interface{}{
+				"source_key": "trace_id",
+			},
+		},
+	}
+
+	// Two transforms simulate two stages of a pipeline.
+	tf1, err := newUtilityRateLimit(ctx, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tf2, err := newUtilityRateLimit(ctx, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var allowed int
+	for i := 0; i < 10000; i++ {
+		data := []byte(fmt.Sprintf(`{"trace_id":"%d"}`, i))
+
+		msgs1, err := tf1.Transform(ctx, message.New().SetData(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		msgs2, err := tf2.Transform(ctx, message.New().SetData(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(msgs1) != len(msgs2) {
+			t.Fatalf("trace %d: expected the same result from both transforms", i)
+		}
+
+		allowed += len(msgs1)
+	}
+
+	if allowed < 2300 || allowed > 2700 {
+		t.Errorf("expected about 2500 messages, got %d", allowed)
+	}
+}
+
+func TestUtilityRateLimitPrune(t *testing.T) {
+	tf, err := newUtilityRateLimit(context.TODO(), config.Config{
+		Settings: map[string]interface{}{
+			"rate": 1,
+			"object": map[string]interface{}{
+				"source_key": "host",
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Unix(3600, 0)
+	now := start
+	tf.now = func() time.Time { return now }
+
+	for i := 0; i < utilityRateLimitPruneSize; i++ {
+		tf.reserve(fmt.Sprint(i))
+	}
+
+	// All buckets are full after 1 second.
+	now = start.Add(time.Second)
+	tf.reserve("new")
+
+	if len(tf.buckets) != 1 {
+		t.Errorf("expected 1 bucket, got %d", len(tf.buckets))
+	}
+}
+
+func TestUtilityRateLimitValidate(t *testing.T) {
+	invalid := []map[string]interface{}{
+		{},
+		{"rate": -1},
+		{"sample_rate": 2},
+		{"rate": 1, "action": "tag"},
+		{"sample_rate": 0.5, "action": "delay"},
+		{"rate": 1, "action": "queue"},
+	}
+
+	for _, settings := range invalid {
+		if _, err := newUtilityRateLimit(context.TODO(), config.Config{Settings: settings}); err == nil {
+			t.Errorf("%v: expected error", settings)
+		}
+	}
+}
+
+func FuzzTestUtilityRateLimit(f *testing.F) {
+	testcases := [][]byte{
+		[]byte(`{"host":"a"}`),
+		[]byte(`{"host":"a"}`),
+		[]byte(`{"host":["a","b"]}`),
+		[]byte(``),
+	}
+
+	for _, tc := range testcases {
+		f.Add(tc)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ctx := context.TODO()
+		msg := message.New().SetData(data)
+
+		tf, err := newUtilityRateLimit(ctx, config.Config{
+			Settings: map[string]interface{}{
+				"rate":        10,
+				"sample_rate": 0.5,
+				"object": map[string]interface{}{
+					"source_key": "host",
+				},
+			},
+		})
+		if err != nil {
+			return
+		}
+
+		_, err = tf.Transform(ctx, msg)
+		if err != nil {
+			return
+		}
+	})
+}